@@ -1,6 +1,7 @@
 package budgeter
 
 import (
+	_ "embed"
 	"fmt"
 	"io"
 	"strconv"
@@ -8,14 +9,24 @@ import (
 	"time"
 
 	"github.com/Anthony-Fiddes/budgeter/internal/inpt"
+	"github.com/Anthony-Fiddes/budgeter/model/category"
+	"github.com/Anthony-Fiddes/budgeter/model/payee"
+	"github.com/Anthony-Fiddes/budgeter/model/rule"
 	"github.com/Anthony-Fiddes/budgeter/model/transaction"
 )
 
 type add struct {
+	account      string
+	currency     string
 	lastDate     string
 	lastUnix     int64
+	payees       *payee.Normalizer
+	rules        *rule.Engine
+	classifier   *category.Classifier
 	in           *inpt.Scanner
 	Out          io.Writer
+	Payees       PayeeTable
+	Rules        RuleTable
 	Transactions Table
 }
 
@@ -23,6 +34,8 @@ func newAdd(c *CLI) *add {
 	result := &add{}
 	result.in = c.in
 	result.Out = c.Out
+	result.Payees = c.Payees
+	result.Rules = c.Rules
 	result.Transactions = c.Transactions
 	return result
 }
@@ -31,12 +44,17 @@ func (a add) Name() string {
 	return "add"
 }
 
+//go:embed addUsage.txt
+var addUsage string
+
 func (a add) Usage() string {
-	return "add adds a new transaction to your budgeter. It doesn't have any options yet."
+	return addUsage
 }
 
 func (a add) Run(cmdArgs []string) error {
 	fs := getFlagset(a.Name())
+	fs.StringVar(&a.account, "account", "", "")
+	fs.StringVar(&a.currency, "currency", "", "")
 	if err := fs.Parse(cmdArgs); err != nil {
 		return err
 	}
@@ -54,17 +72,34 @@ func (a add) Run(cmdArgs []string) error {
 func (a *add) interactiveAdd() error {
 	// TODO: allow short dates like "21" or "6/21" that
 	// default to this month or year
+	var err error
+	a.payees, err = loadPayees(a.Payees)
+	if err != nil {
+		return err
+	}
+	a.rules, err = loadRules(a.Rules)
+	if err != nil {
+		return err
+	}
+	a.classifier, err = trainClassifier(a.Transactions)
+	if err != nil {
+		return err
+	}
 	for {
 		tx, err := a.getTransaction()
 		if err != nil {
 			return err
 		}
-		if err := a.Transactions.Insert(tx); err != nil {
+		// Every transaction is its own operation, so that undo only removes
+		// the last one.
+		beginOperation(a.Transactions, a.Name())
+		if _, err := a.Transactions.Insert(tx); err != nil {
 			return err
 		}
+		a.classifier.Learn(tx)
 
 		// TODO: Add context when adding transactions between sessions.
-		// e.g. making the last used date the new default?, enabling an undo command
+		// e.g. making the last used date the new default?
 		fmt.Fprint(a.Out, "\nWould you like to add another transaction? (y/[n]) ")
 		confirmed, err := inpt.Confirm()
 		fmt.Println()
@@ -90,9 +125,9 @@ func (a *add) getField(field string) (string, error) {
 
 func (a *add) getDate() (int64, error) {
 	if a.lastDate == "" {
-		now := time.Now()
-		a.lastDate = now.Format(transaction.DateLayout)
-		a.lastUnix = now.Unix()
+		today := today()
+		a.lastDate = today.Format(transaction.DateLayout)
+		a.lastUnix = today.Unix()
 	}
 	fmt.Fprintf(a.Out, "%s [%s]: ", transaction.DateCol, a.lastDate)
 	response, err := a.in.Line()
@@ -108,7 +143,7 @@ func (a *add) getDate() (int64, error) {
 		// the user may enter a short date in the format of MM/DD
 		// the year will be taken from their last response.
 		response += "/"
-		t := time.Unix(a.lastUnix, 0)
+		t := time.Unix(a.lastUnix, 0).UTC()
 		lastYear := strconv.Itoa(t.Year())
 		response += lastYear
 	}
@@ -136,7 +171,7 @@ func (a *add) getTransaction() (transaction.Transaction, error) {
 	if err != nil {
 		return transaction.Transaction{}, err
 	}
-	tx.Amount, err = transaction.GetCents(amount)
+	tx.Amount, tx.Currency, err = parseAmount(amount, a.currency)
 	if err != nil {
 		return transaction.Transaction{}, err
 	}
@@ -144,5 +179,45 @@ func (a *add) getTransaction() (transaction.Transaction, error) {
 	if err != nil {
 		return transaction.Transaction{}, err
 	}
+	tx.Account = a.account
+	tx, def := autofill(tx, a.payees, a.rules, a.classifier)
+	tx.Category, err = prompt(a.Out, a.in, transaction.CategoryCol, def)
+	if err != nil {
+		return transaction.Transaction{}, err
+	}
 	return tx, nil
 }
+
+// parseAmount reads an amount typed by the user in the currency with the given
+// code, or the home currency if the code is empty. It returns the amount and
+// the code of its currency.
+func parseAmount(amount, code string) (transaction.Cent, string, error) {
+	cur, err := lookupCurrency(code)
+	if err != nil {
+		return 0, "", err
+	}
+	result, err := transaction.ParseAmount(amount, cur)
+	if err != nil {
+		return 0, "", err
+	}
+	return result, cur.Code, nil
+}
+
+// autofill normalizes the payee of a new transaction and applies the user's
+// rules to it. It also returns the category that the transaction should have
+// by default: the one set by the user's rules or, if they didn't set one, the
+// one suggested by the transactions that have been categorized before.
+func autofill(
+	tx transaction.Transaction,
+	payees *payee.Normalizer,
+	rules *rule.Engine,
+	classifier *category.Classifier,
+) (transaction.Transaction, string) {
+	tx.Entity = payees.Normalize(tx.Entity)
+	tx = rules.Apply(tx)
+	def := tx.Category
+	if def == "" {
+		def, _ = classifier.Suggest(tx.Entity)
+	}
+	return tx, def
+}
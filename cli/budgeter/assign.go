@@ -0,0 +1,90 @@
+package budgeter
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+type assign struct {
+	month        string
+	Config       Store
+	Envelopes    EnvelopeTable
+	Out          io.Writer
+	Rates        RateTable
+	Transactions Table
+}
+
+func newAssign(c *CLI) *assign {
+	result := &assign{}
+	result.Config = c.Config
+	result.Envelopes = c.Envelopes
+	result.Out = c.Out
+	result.Rates = c.Rates
+	result.Transactions = c.Transactions
+	return result
+}
+
+func (a assign) Name() string {
+	return "assign"
+}
+
+//go:embed assignUsage.txt
+var assignUsage string
+
+func (a assign) Usage() string {
+	return assignUsage
+}
+
+// assign moves money from what's to be budgeted into the envelope of a
+// category.
+func (a assign) Run(cmdArgs []string) error {
+	fs := getFlagset(a.Name())
+	fs.StringVar(&a.month, "month", "", "")
+	if err := fs.Parse(cmdArgs); err != nil {
+		return err
+	}
+	args := fs.Args()
+	if len(args) != 2 {
+		return fmt.Errorf("%s takes a category and an amount", a.Name())
+	}
+	m, err := parseMonth(a.month)
+	if err != nil {
+		return err
+	}
+	if _, ok, err := envelopeStart(a.Config); err != nil || !ok {
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("envelope budgeting is off. try `budgeter %s start`", envelopes{}.Name())
+	}
+	amount, err := transaction.GetCents(args[1])
+	if err != nil {
+		return err
+	}
+	if amount == 0 {
+		return fmt.Errorf("the amount to assign can't be 0")
+	}
+	if err := a.Envelopes.Assign(m, args[0], amount); err != nil {
+		return err
+	}
+
+	budget, err := envelopeBudget(a.Config, a.Envelopes, a.Rates, a.Transactions, m)
+	if err != nil {
+		return err
+	}
+	for _, env := range budget.Envelopes {
+		if strings.EqualFold(env.Category, args[0]) {
+			fmt.Fprintf(a.Out, "%s has %s available in %s.\n", env.Category, env.Balance, formatMonth(m))
+		}
+	}
+	if budget.ToBeBudgeted < 0 {
+		fmt.Fprintf(a.Out, "You've assigned %s more than you have.\n", -budget.ToBeBudgeted)
+		return nil
+	}
+	fmt.Fprintf(a.Out, "%s is left to be budgeted.\n", budget.ToBeBudgeted)
+	return nil
+}
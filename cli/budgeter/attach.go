@@ -0,0 +1,187 @@
+package budgeter
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/model/attachment"
+	"github.com/cheynewallace/tabby"
+)
+
+type AttachmentTable interface {
+	Add(transactionID int, name string, data []byte, at time.Time) (attachment.Attachment, error)
+	Counts() (map[int]int, error)
+	Get(attachmentID int) (attachment.Attachment, []byte, error)
+	List(transactionID int) ([]attachment.Attachment, error)
+	Prune() (int, error)
+	Remove(attachmentID int) error
+}
+
+type attach struct {
+	Attachments  AttachmentTable
+	Out          io.Writer
+	Transactions Table
+}
+
+func newAttach(c *CLI) *attach {
+	return &attach{Attachments: c.Attachments, Out: c.Out, Transactions: c.Transactions}
+}
+
+func (a attach) Name() string {
+	return "attach"
+}
+
+//go:embed attachUsage.txt
+var attachUsage string
+
+func (a attach) Usage() string {
+	return attachUsage
+}
+
+// attach manages the files attached to transactions, like receipts.
+func (a attach) Run(cmdArgs []string) error {
+	fs := getFlagset(a.Name())
+	if err := fs.Parse(cmdArgs); err != nil {
+		return err
+	}
+	args := fs.Args()
+	if len(args) == 0 {
+		return fmt.Errorf("%s takes a subcommand", a.Name())
+	}
+
+	subArgs := args[1:]
+	switch args[0] {
+	case "add":
+		if len(subArgs) < 2 {
+			return fmt.Errorf("%s add takes a transaction ID and at least one file", a.Name())
+		}
+		txID, err := a.parseID(subArgs[0], recent{}.Name())
+		if err != nil {
+			return err
+		}
+		if _, err := a.Transactions.Get(txID); err != nil {
+			return err
+		}
+		for _, path := range subArgs[1:] {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			added, err := a.Attachments.Add(txID, filepath.Base(path), data, time.Now())
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(a.Out, "Attached \"%s\" to transaction #%d as #%d.\n", added.Name, txID, added.ID)
+		}
+		return nil
+	case "list":
+		if len(subArgs) > 1 {
+			return fmt.Errorf("%s list takes at most one argument", a.Name())
+		}
+		txID := 0
+		if len(subArgs) == 1 {
+			var err error
+			if txID, err = a.parseID(subArgs[0], recent{}.Name()); err != nil {
+				return err
+			}
+		}
+		return a.list(txID)
+	case "extract":
+		if len(subArgs) != 1 && len(subArgs) != 2 {
+			return fmt.Errorf("%s extract takes an attachment ID and an optional path", a.Name())
+		}
+		attachmentID, err := a.parseID(subArgs[0], a.Name()+" list")
+		if err != nil {
+			return err
+		}
+		found, data, err := a.Attachments.Get(attachmentID)
+		if err != nil {
+			return err
+		}
+		path := found.Name
+		if len(subArgs) == 2 {
+			path = subArgs[1]
+		}
+		// Existing files are never overwritten.
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(data); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.Out, "Saved attachment #%d to \"%s\".\n", attachmentID, path)
+		return nil
+	case "remove":
+		if len(subArgs) != 1 {
+			return fmt.Errorf("%s remove takes one argument", a.Name())
+		}
+		attachmentID, err := a.parseID(subArgs[0], a.Name()+" list")
+		if err != nil {
+			return err
+		}
+		if err := a.Attachments.Remove(attachmentID); err != nil {
+			return fmt.Errorf("could not remove attachment #%d: %w", attachmentID, err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%s has no subcommand \"%s\"", a.Name(), args[0])
+	}
+}
+
+// parseID parses an ID, with an error that suggests the command that lists
+// them if it isn't one.
+func (a attach) parseID(s, lister string) (int, error) {
+	id, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("\"%s\" isn't a numerical ID. try `budgeter %s` to see some IDs.", s, lister)
+	}
+	return id, nil
+}
+
+// list prints the attachments of a transaction, or of every transaction if
+// the ID is 0.
+func (a attach) list(transactionID int) error {
+	attachments, err := a.Attachments.List(transactionID)
+	if err != nil {
+		return err
+	}
+	if len(attachments) == 0 {
+		fmt.Fprintln(a.Out, "There are no attachments.")
+		return nil
+	}
+	tab := tabby.NewCustom(newTabWriter(a.Out))
+	tab.AddHeader("ID", "Transaction", "Name", "Size", "Added")
+	for _, at := range attachments {
+		added := time.Unix(at.Added, 0).In(timezone).Format("2006-01-02 15:04")
+		tab.AddLine(at.ID, at.TransactionID, at.Name, formatSize(at.Size), added)
+	}
+	tab.Print()
+	return nil
+}
+
+// formatSize returns a number of bytes in a short, readable form, e.g. "1.5
+// MB".
+func formatSize(bytes int64) string {
+	const unit = 1000
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	size := float64(bytes) / unit
+	for _, prefix := range "kMGT" {
+		if size < unit {
+			return fmt.Sprintf("%.1f %cB", size, prefix)
+		}
+		size /= unit
+	}
+	return fmt.Sprintf("%.1f PB", size)
+}
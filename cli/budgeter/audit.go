@@ -0,0 +1,143 @@
+package budgeter
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+	"github.com/cheynewallace/tabby"
+)
+
+type AuditTable interface {
+	Audit(limit int) ([]transaction.AuditEntry, error)
+	VerifyAudit() (int, string, error)
+}
+
+type audit struct {
+	limit int
+	Audit AuditTable
+	Out   io.Writer
+}
+
+func newAudit(c *CLI) *audit {
+	return &audit{Audit: c.Audit, Out: c.Out}
+}
+
+func (a audit) Name() string {
+	return "audit"
+}
+
+//go:embed auditUsage.txt
+var auditUsage string
+
+func (a audit) Usage() string {
+	return auditUsage
+}
+
+// audit lists the newest changes in the audit log, or checks that it hasn't
+// been tampered with.
+func (a audit) Run(cmdArgs []string) error {
+	const defaultAuditLimit = 20
+
+	fs := getFlagset(a.Name())
+	fs.IntVar(&a.limit, "l", defaultAuditLimit, "")
+	if err := fs.Parse(cmdArgs); err != nil {
+		return err
+	}
+	args := fs.Args()
+	if len(args) == 0 {
+		return a.list()
+	}
+	switch args[0] {
+	case "verify":
+		if len(args) != 1 {
+			return fmt.Errorf("%s verify takes no arguments", a.Name())
+		}
+		return a.verify()
+	default:
+		return fmt.Errorf("%s has no subcommand \"%s\"", a.Name(), args[0])
+	}
+}
+
+// list prints the newest changes in the audit log.
+func (a audit) list() error {
+	entries, err := a.Audit.Audit(a.limit)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(a.Out, "The audit log is empty.")
+		return nil
+	}
+	tab := tabby.NewCustom(newTabWriter(a.Out))
+	tab.AddHeader("ID", "Time", "User", "Action", "Transaction", "Change")
+	for _, e := range entries {
+		at := time.Unix(e.Time, 0).In(timezone).Format("2006-01-02 15:04")
+		tab.AddLine(e.ID, at, e.User, e.Action, e.TransactionID, describeChange(e.Before, e.After))
+	}
+	tab.Print()
+	return nil
+}
+
+// verify checks the audit log's chain of hashes.
+func (a audit) verify() error {
+	count, hash, err := a.Audit.VerifyAudit()
+	if err != nil {
+		return fmt.Errorf("verified %d entries, then: %w", count, err)
+	}
+	fmt.Fprintf(a.Out, "The audit log is intact. It has %d entries.\n", count)
+	if count > 0 {
+		fmt.Fprintf(a.Out, "The newest entry's hash is %s\n", hash)
+	}
+	return nil
+}
+
+// describeChange returns a short description of a change to a transaction:
+// the transaction itself if it was inserted or removed, or the fields that
+// changed if it was updated.
+func describeChange(before, after *transaction.Transaction) string {
+	if before == nil || after == nil {
+		tx := before
+		if tx == nil {
+			tx = after
+		}
+		return fmt.Sprintf("%s %s %s", tx.DateString(), tx.Entity, tx.AmountString())
+	}
+	fields := []struct{ name, before, after string }{
+		{"date", before.DateString(), after.DateString()},
+		{"entity", before.Entity, after.Entity},
+		{"amount", before.AmountString(), after.AmountString()},
+		{"note", before.Note, after.Note},
+		{"category", before.Category, after.Category},
+		{"tags", strings.Join(before.Tags, transaction.TagSeparator), strings.Join(after.Tags, transaction.TagSeparator)},
+		{"account", before.Account, after.Account},
+		{"status", string(before.Status), string(after.Status)},
+		{"kind", string(before.Kind), string(after.Kind)},
+		{"shares", describeShares(*before), describeShares(*after)},
+	}
+	var changes []string
+	for _, f := range fields {
+		if f.before != f.after {
+			changes = append(changes, fmt.Sprintf("%s %q -> %q", f.name, f.before, f.after))
+		}
+	}
+	if before.Deleted == 0 && after.Deleted != 0 {
+		changes = append(changes, "moved to the trash")
+	} else if before.Deleted != 0 && after.Deleted == 0 {
+		changes = append(changes, "taken out of the trash")
+	}
+	return strings.Join(changes, ", ")
+}
+
+// describeShares lists the shares of "tx" by person ID, e.g. "person #1
+// -$30.00, person #2 -$30.00".
+func describeShares(tx transaction.Transaction) string {
+	var shares []string
+	for _, s := range tx.Shares {
+		shares = append(shares, fmt.Sprintf("person #%d %s", s.Person, s.Amount.Format(tx.CurrencyInfo())))
+	}
+	return strings.Join(shares, ", ")
+}
@@ -38,16 +38,18 @@ func (b backup) Run(cmdArgs []string) error {
 	if err != nil {
 		return fmt.Errorf("error opening \"%s\" to read: %w", b.DBPath, err)
 	}
-	// TODO: make some consideration for the case where a file is already present.
-	// Consider writing to a temp file first or something
+	defer dbFile.Close()
+	// TODO: Consider writing to a temp file first so that a failed backup
+	// doesn't clobber an older one.
 	targetPath := cmdArgs[0]
-	target, err := os.OpenFile(targetPath, os.O_CREATE|os.O_WRONLY, 0644)
+	target, err := os.OpenFile(targetPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
 	if err != nil {
 		return fmt.Errorf("error opening \"%s\" to write: %w", targetPath, err)
 	}
 	_, err = io.Copy(target, dbFile)
 	if err != nil {
+		target.Close()
 		return fmt.Errorf("error writing backup to \"%s\": %w", targetPath, err)
 	}
-	return nil
+	return target.Close()
 }
@@ -2,9 +2,13 @@ package budgeter
 
 import (
 	"flag"
+	"fmt"
 	"io"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
 	"time"
 
 	_ "embed"
@@ -17,11 +21,32 @@ import (
 var usage string
 
 type Table interface {
-	Insert(transaction.Transaction) error
-	RangeTotal(start, end time.Time) (transaction.Cent, error)
+	Balances() (map[int]map[string]transaction.Cent, error)
+	Clear(tx transaction.Transaction) error
+	Get(transactionID int) (transaction.Transaction, error)
+	Insert(transaction.Transaction) (int, error)
+	NormalizeDates(loc *time.Location) error
+	Observe(transaction.Observer)
+	Purge(before time.Time) (int, error)
+	Range(start, end time.Time, limit int) (*transaction.Rows, error)
+	RangeCategoryTotals(start, end time.Time, exclude ...transaction.Status) (map[string]map[string]transaction.Cent, error)
+	RangeEntityExpenses(start, end time.Time, exclude ...transaction.Status) (map[string]map[string]transaction.Cent, error)
+	RangeKindTotals(start, end time.Time, exclude ...transaction.Status) (map[transaction.Kind]map[string]transaction.Cent, error)
+	RangeTotal(start, end time.Time, exclude ...transaction.Status) (transaction.Cent, error)
+	RangeTotals(start, end time.Time, exclude ...transaction.Status) (map[string]transaction.Cent, error)
+	Recover(transactionID int) error
 	Remove(transactionID int) error
+	RemoveAll(transactionIDs []int) error
 	Search(query string, limit int) (*transaction.Rows, error)
+	SetShares(transactionID int, shares []transaction.Share) error
+	SetStatus(transactionID int, status transaction.Status) error
+	SetStatusAll(transactionIDs []int, status transaction.Status) error
+	Snapshot(transactionID int) (*transaction.Transaction, error)
 	Total() (transaction.Cent, error)
+	Totals() (map[string]transaction.Cent, error)
+	Trash(limit int) (*transaction.Rows, error)
+	Update(transaction.Transaction) error
+	UpdateAll(txs []transaction.Transaction, also func(e transaction.Execer) error) error
 }
 
 type Store interface {
@@ -34,26 +59,86 @@ type Store interface {
 
 type CLI struct {
 	args []string
+	// Attachments is a table of files attached to Transactions. It does not
+	// have a default, so it must be set.
+	Attachments AttachmentTable
+	// Audit is the log of every change to Transactions. It does not have a
+	// default, so it must be set.
+	Audit AuditTable
 	// Config is a store where CLI can persist data in a key, value format.
 	Config Store
+	// DB closes the connections to the database at DBPath, so that the
+	// restore command can replace it. If it's nil, the database is replaced
+	// without closing it first.
+	DB io.Closer
 	// DBPath is the filepath for the datastore being used. It does not have a
 	// default, so it must be set. The wipe and backup commands currently assume
 	// that the database is stored in a local file.
 	DBPath string
+	// Envelopes is a table of the money assigned to envelopes in envelope
+	// budgeting. It does not have a default, so it must be set.
+	Envelopes EnvelopeTable
 	// Err is used by CLI to log errors. By default, it writes to stderr with no
 	// date prefix.
 	Err io.Writer
 	err *log.Logger
+	// Goals is a table of savings goals and their contributions. It does not
+	// have a default, so it must be set.
+	Goals GoalTable
 	// In is the input stream that the CLI reads from. It defaults to stdin.
 	In io.Reader
 	in *inpt.Scanner
+	// Journal records every operation that changes Transactions, so that it
+	// can be undone. It does not have a default, so it must be set.
+	Journal JournalTable
 	// Out is where CLI prints its regular output. It defaults to stdout
 	Out io.Writer
+	// People is a table of the people that the user shares expenses with. It
+	// does not have a default, so it must be set.
+	People PeopleTable
+	// Payees is a table of aliases that map the entity names banks use to
+	// canonical payee names. It does not have a default, so it must be set.
+	Payees PayeeTable
+	// Rates is a table of exchange rates, used to convert amounts to the home
+	// currency. It does not have a default, so it must be set.
+	Rates RateTable
+	// Recurring is a table of recurring transaction rules. Any occurrences that
+	// are due are posted to Transactions every time the CLI runs. It does not
+	// have a default, so it must be set.
+	Recurring RecurTable
+	// Rules is a table of rules that categorize and clean up transactions
+	// before they're added. It does not have a default, so it must be set.
+	Rules RuleTable
 	// Transactions is a Transactions table, it allows the CLI app to interact
 	// with a store of transactions. It does not have a default, so it must be set.
 	Transactions Table
 }
 
+// postsRecurring are the commands that read or change transactions. The
+// recurring transactions that are due are posted before they run, so that
+// they're up to date, but not before any other command. Undo and redo are left
+// out, since posting is an operation of its own, and they'd undo or redo it
+// instead of the user's last one.
+var postsRecurring = map[string]bool{
+	"add": true, "attach": true, "categorize": true, "classify": true, "envelopes": true, "export": true,
+	"forecast": true, "ingest": true, "payees": true, "people": true, "recent": true, "reconcile": true,
+	"remove": true, "report": true, "rules": true, "serve": true, "settle": true, "share": true,
+	"status": true, "trash": true, "tui": true,
+}
+
+// leavesDataAlone are the commands that work on the database as a whole or on
+// the history of changes to it. Nothing is changed before they run: the trash
+// isn't emptied and no operation is started, so that a backup has exactly
+// what the user had, a restore doesn't write to a database it's replacing, and
+// undo and redo act on the user's last operation.
+var leavesDataAlone = map[string]bool{
+	"audit": true, "backup": true, "redo": true, "restore": true, "undo": true,
+}
+
+// changesSettings are the commands that change the settings loaded from the
+// config before every command. They run even if a setting is invalid.
+var changesSettings = map[string]bool{"locale": true, "rates": true}
+
 type command interface {
 	Name() string
 	Run(args []string) error
@@ -70,6 +155,36 @@ func (c *CLI) Run(args []string) int {
 	if c.Transactions == nil {
 		panic("budgeter: Transactions must be set on CLI")
 	}
+	if c.Attachments == nil {
+		panic("budgeter: Attachments must be set on CLI")
+	}
+	if c.Audit == nil {
+		panic("budgeter: Audit must be set on CLI")
+	}
+	if c.Envelopes == nil {
+		panic("budgeter: Envelopes must be set on CLI")
+	}
+	if c.Goals == nil {
+		panic("budgeter: Goals must be set on CLI")
+	}
+	if c.Journal == nil {
+		panic("budgeter: Journal must be set on CLI")
+	}
+	if c.People == nil {
+		panic("budgeter: People must be set on CLI")
+	}
+	if c.Payees == nil {
+		panic("budgeter: Payees must be set on CLI")
+	}
+	if c.Rates == nil {
+		panic("budgeter: Rates must be set on CLI")
+	}
+	if c.Recurring == nil {
+		panic("budgeter: Recurring must be set on CLI")
+	}
+	if c.Rules == nil {
+		panic("budgeter: Rules must be set on CLI")
+	}
 
 	if c.Err == nil {
 		c.Err = os.Stderr
@@ -90,18 +205,61 @@ func (c *CLI) Run(args []string) int {
 
 	alias := args[1]
 	c.args = args[2:]
-	cmds := []command{newAdd(c), newBackup(c), newExport(c), newIngest(c), newRecent(c), newRemove(c)}
+	for _, load := range []func(Store) error{loadTimezone, loadLocale, loadHomeCurrency} {
+		err := load(c.Config)
+		if err == nil {
+			continue
+		}
+		// The commands that change these settings still run, so that an
+		// invalid one can be fixed.
+		if !changesSettings[alias] {
+			c.err.Println(err)
+			return 1
+		}
+		c.err.Printf("warning: %v", err)
+	}
+	if !leavesDataAlone[alias] {
+		if err := c.Transactions.NormalizeDates(timezone); err != nil {
+			c.err.Println(err)
+			return 1
+		}
+	}
+	journal := newJournaled(c.Transactions, transaction.CurrentUser())
+	c.Transactions = journal
+
+	cmds := []command{
+		newAdd(c), newAssign(c), newAttach(c), newAudit(c), newBackup(c), newCategorize(c), newClassify(c),
+		newEnvelopes(c), newExport(c), newForecast(c), newGoals(c), newHistory(c), newIngest(c), newLocale(c),
+		newPayees(c), newPeople(c), newRates(c), newRecent(c), newReconcile(c), newRecur(c), newRedo(c),
+		newRemove(c), newReport(c), newRestore(c), newRules(c), newServe(c), newSettle(c), newShare(c),
+		newStatus(c), newTrash(c), newTUI(c), newUndo(c),
+	}
 	for _, cmd := range cmds {
-		if cmd.Name() == alias {
-			err := cmd.Run(c.args)
-			if err != nil {
-				c.err.Println(err)
+		if cmd.Name() != alias {
+			continue
+		}
+		if !leavesDataAlone[alias] {
+			if postsRecurring[alias] {
+				journal.begin(recur{}.Name())
+				if err := postRecurring(c); err != nil {
+					c.err.Printf("could not post recurring transactions: %v", err)
+					c.err.Println()
+				}
+			}
+			if err := purgeTrash(c); err != nil {
+				c.err.Printf("could not empty the trash: %v", err)
 				c.err.Println()
-				c.err.Println(cmd.Usage())
-				return 1
 			}
-			return 0
+			journal.begin(alias)
 		}
+		err := cmd.Run(c.args)
+		if err != nil {
+			c.err.Println(err)
+			c.err.Println()
+			c.err.Println(cmd.Usage())
+			return 1
+		}
+		return 0
 	}
 
 	c.err.Printf("command \"%s\" does not exist", alias)
@@ -113,3 +271,98 @@ func getFlagset(commandName string) *flag.FlagSet {
 	fs.SetOutput(io.Discard)
 	return fs
 }
+
+// parseInterspersed parses the flags in "args" with "fs" like fs.Parse, but
+// also parses the flags that come after other arguments, which fs.Parse stops
+// at. It returns the other arguments in order. Everything after "--" is
+// returned as is.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var result []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		parsed := len(args) - fs.NArg()
+		if parsed > 0 && args[parsed-1] == "--" {
+			return append(result, fs.Args()...), nil
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return result, nil
+		}
+		result = append(result, args[0])
+		args = args[1:]
+	}
+}
+
+// prompt asks the user for a field and returns their response. If "def" isn't
+// empty, it's shown to the user and returned when they don't enter anything.
+func prompt(out io.Writer, in *inpt.Scanner, field, def string) (string, error) {
+	if def == "" {
+		fmt.Fprintf(out, "%s: ", field)
+	} else {
+		fmt.Fprintf(out, "%s [%s]: ", field, def)
+	}
+	response, err := in.Line()
+	if err != nil {
+		return "", err
+	}
+	if response == "" {
+		return def, nil
+	}
+	return response, nil
+}
+
+// now returns the current time in the user's time zone.
+func now() time.Time {
+	return time.Now().In(timezone)
+}
+
+// today returns the user's current date in the same form that transaction
+// dates are stored in.
+func today() time.Time {
+	return time.Unix(transaction.DateOf(now()), 0).UTC()
+}
+
+// alignAmount returns the string form of "amount", padded so that positive and
+// negative amounts line up in a table.
+func alignAmount(amount transaction.Cent) string {
+	return align(amount.String(), amount < 0)
+}
+
+// alignTxAmount returns the amount of "tx" in its own currency, padded so
+// that positive and negative amounts line up in a table.
+func alignTxAmount(tx transaction.Transaction) string {
+	return align(tx.AmountString(), tx.Amount < 0)
+}
+
+// align pads a formatted amount so that it lines up with negative amounts,
+// which have a minus sign, or parentheses in the accounting style.
+func align(amount string, negative bool) string {
+	if negative {
+		return amount
+	}
+	if transaction.Locale().Accounting {
+		return " " + amount + " "
+	}
+	return " " + amount
+}
+
+// printLocked tells the user that the reconciled transactions with the given
+// IDs were left out of a change, if there are any.
+func printLocked(w io.Writer, transactionIDs []int) {
+	if len(transactionIDs) == 0 {
+		return
+	}
+	ids := make([]string, len(transactionIDs))
+	for i, id := range transactionIDs {
+		ids[i] = "#" + strconv.Itoa(id)
+	}
+	fmt.Fprintf(w, "Skipped %d reconciled transactions: %s\n", len(ids), strings.Join(ids, ", "))
+}
+
+// newTabWriter returns a tabwriter that writes to "w" using the same settings
+// as tabby.
+func newTabWriter(w io.Writer) *tabwriter.Writer {
+	return tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+}
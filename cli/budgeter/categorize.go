@@ -0,0 +1,137 @@
+package budgeter
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/Anthony-Fiddes/budgeter/internal/inpt"
+	"github.com/Anthony-Fiddes/budgeter/model/category"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+// trainClassifier returns a classifier that has learned from every categorized
+// transaction in "t".
+func trainClassifier(t Table) (*category.Classifier, error) {
+	rows, err := t.Search("", -1)
+	if err != nil {
+		return nil, err
+	}
+	transactions, err := rows.ScanSet()
+	if err != nil {
+		return nil, err
+	}
+	return category.Train(transactions), nil
+}
+
+type categorize struct {
+	confirmed    bool
+	confidence   float64
+	in           *inpt.Scanner
+	Out          io.Writer
+	Transactions Table
+}
+
+func newCategorize(c *CLI) *categorize {
+	result := &categorize{}
+	result.in = c.in
+	result.Out = c.Out
+	result.Transactions = c.Transactions
+	return result
+}
+
+func (c categorize) Name() string {
+	return "categorize"
+}
+
+//go:embed categorizeUsage.txt
+var categorizeUsage string
+
+func (c categorize) Usage() string {
+	return categorizeUsage
+}
+
+// categorize goes through the transactions that don't have a category and
+// suggests one for each of them based on the transactions that do.
+func (c categorize) Run(cmdArgs []string) error {
+	const (
+		// defaultConfidence is the default confidence that a suggestion needs
+		// to be accepted by the -y option.
+		defaultConfidence = 0.8
+		skip              = "-"
+		quit              = "q"
+	)
+
+	fs := getFlagset(c.Name())
+	fs.BoolVar(&c.confirmed, "y", false, "")
+	fs.Float64Var(&c.confidence, "c", defaultConfidence, "")
+	if err := fs.Parse(cmdArgs); err != nil {
+		return err
+	}
+	if len(fs.Args()) > 0 {
+		return fmt.Errorf("%s takes no arguments", c.Name())
+	}
+	if c.confidence < 0 || c.confidence > 1 {
+		return fmt.Errorf("-c must be between 0 and 1")
+	}
+
+	rows, err := c.Transactions.Search("", -1)
+	if err != nil {
+		return err
+	}
+	transactions, err := rows.ScanSet()
+	if err != nil {
+		return err
+	}
+	classifier := category.Train(transactions)
+
+	if !c.confirmed {
+		fmt.Fprintf(
+			c.Out,
+			"Press enter to accept a suggestion, type a category, enter \"%s\" to skip, or \"%s\" to stop.\n",
+			skip, quit,
+		)
+	}
+	// The categories are saved in one database transaction at the end, so
+	// either all of them are saved or none are. Reconciled transactions can't
+	// be changed, so they're skipped.
+	var categorized []transaction.Transaction
+	var locked []int
+	for _, tx := range transactions {
+		if tx.Category != "" {
+			continue
+		}
+		if tx.Status == transaction.Reconciled {
+			locked = append(locked, tx.ID)
+			continue
+		}
+		suggestion, confidence := classifier.Suggest(tx.Entity)
+		if c.confirmed {
+			if suggestion == "" || confidence < c.confidence {
+				continue
+			}
+			tx.Category = suggestion
+		} else {
+			fmt.Fprintf(c.Out, "\n#%d %s %s %s %s\n", tx.ID, tx.DateString(), tx.Entity, tx.AmountString(), tx.Note)
+			response, err := prompt(c.Out, c.in, transaction.CategoryCol, suggestion)
+			if err != nil {
+				return err
+			}
+			if response == quit {
+				break
+			}
+			if response == "" || response == skip {
+				continue
+			}
+			tx.Category = response
+		}
+		categorized = append(categorized, tx)
+		classifier.Learn(tx)
+	}
+	if err := c.Transactions.UpdateAll(categorized, nil); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.Out, "Categorized %d transactions.\n", len(categorized))
+	printLocked(c.Out, locked)
+	return nil
+}
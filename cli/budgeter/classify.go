@@ -0,0 +1,93 @@
+package budgeter
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+// autoKind is the name used to clear a transaction's kind, so that it's
+// classified by its amount again.
+const autoKind = "auto"
+
+type classify struct {
+	Out          io.Writer
+	Transactions Table
+}
+
+func newClassify(c *CLI) *classify {
+	return &classify{Out: c.Out, Transactions: c.Transactions}
+}
+
+func (c classify) Name() string {
+	return "classify"
+}
+
+//go:embed classifyUsage.txt
+var classifyUsage string
+
+func (c classify) Usage() string {
+	return classifyUsage
+}
+
+// classify marks transactions as income, expenses or transfers.
+func (c classify) Run(cmdArgs []string) error {
+	fs := getFlagset(c.Name())
+	if err := fs.Parse(cmdArgs); err != nil {
+		return err
+	}
+	args := fs.Args()
+	if len(args) < 2 {
+		return fmt.Errorf("%s takes at least two arguments", c.Name())
+	}
+	var kind transaction.Kind
+	name := args[len(args)-1]
+	if name != autoKind {
+		var err error
+		if kind, err = transaction.ParseKind(name); err != nil {
+			return err
+		}
+	}
+
+	for _, arg := range args[:len(args)-1] {
+		txID, err := strconv.Atoi(arg)
+		if err != nil {
+			return fmt.Errorf(
+				"%s takes numerical IDs. try `budgeter %s` to see some IDs.",
+				c.Name(),
+				recent{}.Name(),
+			)
+		}
+		tx, err := c.Transactions.Get(txID)
+		if err != nil {
+			return err
+		}
+		tx.Kind = kind
+		if err := c.Transactions.Update(tx); err != nil {
+			return fmt.Errorf("could not classify transaction #%d: %w", txID, err)
+		}
+		fmt.Fprintf(c.Out, "Transaction #%d is %s.\n", txID, describeKind(tx))
+	}
+	return nil
+}
+
+// describeKind returns the kind of "tx", noting whether it was classified by
+// its amount, e.g. "an expense (by its amount)".
+func describeKind(tx transaction.Transaction) string {
+	var result string
+	switch tx.Classify() {
+	case transaction.Income:
+		result = "income"
+	case transaction.Expense:
+		result = "an expense"
+	case transaction.Transfer:
+		result = "a transfer"
+	}
+	if tx.Kind == "" {
+		result += " (by its amount)"
+	}
+	return result
+}
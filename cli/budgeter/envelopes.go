@@ -0,0 +1,217 @@
+package budgeter
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/internal/month"
+	"github.com/Anthony-Fiddes/budgeter/model/envelope"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+	"github.com/cheynewallace/tabby"
+)
+
+const (
+	// envelopeStartKey is the config key for the month that envelope
+	// budgeting started in, in monthLayout. Envelope budgeting is off if it's
+	// empty.
+	envelopeStartKey = "envelope_start"
+	// monthLayout is the format that months are given in, e.g. "10/2026".
+	monthLayout = "1/2006"
+)
+
+type EnvelopeTable interface {
+	All() ([]envelope.Assignment, error)
+	Assign(month int64, category string, amount transaction.Cent) error
+}
+
+// parseMonth returns the first day of the month "s", given in monthLayout,
+// in the same form as transaction dates. It returns the current month if "s"
+// is empty.
+func parseMonth(s string) (int64, error) {
+	if s == "" {
+		return envelope.MonthOf(today().Unix()), nil
+	}
+	t, err := time.Parse(monthLayout, s)
+	if err != nil {
+		return 0, fmt.Errorf("month \"%s\" must be provided in M/YYYY format", s)
+	}
+	return t.Unix(), nil
+}
+
+// formatMonth returns a month stored like a transaction date, e.g. "October
+// 2026".
+func formatMonth(m int64) string {
+	return time.Unix(m, 0).UTC().Format("January 2006")
+}
+
+// envelopeStart returns the month that envelope budgeting started in, or
+// false if it's off.
+func envelopeStart(config Store) (int64, bool, error) {
+	value, err := config.Get(envelopeStartKey)
+	if err != nil || value == "" {
+		return 0, false, err
+	}
+	start, err := time.Parse(monthLayout, value)
+	if err != nil {
+		return 0, false, fmt.Errorf("\"%s\" in your config must be a month in M/YYYY format", envelopeStartKey)
+	}
+	return start.Unix(), true, nil
+}
+
+// envelopeBudget returns the envelope budget for the month "m". The money
+// that was to be budgeted when envelope budgeting started is the total of
+// every transaction before then, i.e. the money that the user had.
+func envelopeBudget(
+	config Store, assignments EnvelopeTable, rates RateTable, transactions Table, m int64,
+) (envelope.Budget, error) {
+	start, ok, err := envelopeStart(config)
+	if err != nil {
+		return envelope.Budget{}, err
+	}
+	if !ok {
+		return envelope.Budget{}, fmt.Errorf(
+			"envelope budgeting is off. try `budgeter %s start`", envelopes{}.Name(),
+		)
+	}
+	before := time.Unix(start, 0).UTC().Add(-time.Second)
+	openingTotals, err := transactions.RangeTotals(time.Unix(0, 0).UTC(), before)
+	if err != nil {
+		return envelope.Budget{}, err
+	}
+	opening, err := totalToHome(rates, openingTotals, before)
+	if err != nil {
+		return envelope.Budget{}, err
+	}
+
+	activity := make(map[int64]envelope.Activity)
+	for current := time.Unix(start, 0).UTC(); current.Unix() <= m; current = month.Add(current, 1) {
+		end := month.End(current)
+		totals, err := transactions.RangeCategoryTotals(current, end)
+		if err != nil {
+			return envelope.Budget{}, err
+		}
+		monthActivity := make(envelope.Activity)
+		for category, amounts := range totals {
+			if monthActivity[category], err = totalToHome(rates, amounts, end); err != nil {
+				return envelope.Budget{}, err
+			}
+		}
+		activity[current.Unix()] = monthActivity
+	}
+
+	all, err := assignments.All()
+	if err != nil {
+		return envelope.Budget{}, err
+	}
+	return envelope.Compute(start, opening, all, activity, m)
+}
+
+type envelopes struct {
+	month        string
+	Config       Store
+	Envelopes    EnvelopeTable
+	Out          io.Writer
+	Rates        RateTable
+	Transactions Table
+}
+
+func newEnvelopes(c *CLI) *envelopes {
+	result := &envelopes{}
+	result.Config = c.Config
+	result.Envelopes = c.Envelopes
+	result.Out = c.Out
+	result.Rates = c.Rates
+	result.Transactions = c.Transactions
+	return result
+}
+
+func (e envelopes) Name() string {
+	return "envelopes"
+}
+
+//go:embed envelopesUsage.txt
+var envelopesUsage string
+
+func (e envelopes) Usage() string {
+	return envelopesUsage
+}
+
+// envelopes shows the envelope budget, and turns envelope budgeting on and
+// off.
+func (e envelopes) Run(cmdArgs []string) error {
+	fs := getFlagset(e.Name())
+	fs.StringVar(&e.month, "month", "", "")
+	if err := fs.Parse(cmdArgs); err != nil {
+		return err
+	}
+	args := fs.Args()
+	if len(args) == 0 {
+		m, err := parseMonth(e.month)
+		if err != nil {
+			return err
+		}
+		return e.print(m)
+	}
+	if e.month != "" {
+		return fmt.Errorf("-month is only used to view a month")
+	}
+
+	subArgs := args[1:]
+	switch args[0] {
+	case "start":
+		if len(subArgs) > 1 {
+			return fmt.Errorf("%s start takes at most one argument", e.Name())
+		}
+		start, err := parseMonth(fs.Arg(1))
+		if err != nil {
+			return err
+		}
+		if err := e.Config.Put(envelopeStartKey, time.Unix(start, 0).UTC().Format(monthLayout)); err != nil {
+			return err
+		}
+		fmt.Fprintf(e.Out, "Envelope budgeting starts in %s.\n", formatMonth(start))
+		return nil
+	case "stop":
+		if len(subArgs) > 0 {
+			return fmt.Errorf("%s stop takes no arguments", e.Name())
+		}
+		return e.Config.Put(envelopeStartKey, "")
+	default:
+		return fmt.Errorf("%s has no subcommand \"%s\"", e.Name(), args[0])
+	}
+}
+
+// print prints the envelope budget for the month "m".
+func (e envelopes) print(m int64) error {
+	budget, err := envelopeBudget(e.Config, e.Envelopes, e.Rates, e.Transactions, m)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(e.Out, formatMonth(m))
+	fmt.Fprintf(e.Out, "To be budgeted: %s\n", budget.ToBeBudgeted)
+	if len(budget.Envelopes) == 0 {
+		fmt.Fprintln(e.Out, "There are no envelopes yet. Try `budgeter assign <category> <amount>`.")
+		return nil
+	}
+	fmt.Fprintln(e.Out)
+	tab := tabby.NewCustom(newTabWriter(e.Out))
+	tab.AddHeader("Envelope", "Carried", "Assigned", "Activity", "Available", "")
+	for _, env := range budget.Envelopes {
+		note := ""
+		if env.Balance < 0 {
+			note = "overspent"
+		}
+		tab.AddLine(
+			env.Category,
+			alignAmount(env.Carried),
+			alignAmount(env.Assigned),
+			alignAmount(env.Activity),
+			alignAmount(env.Balance),
+			note,
+		)
+	}
+	tab.Print()
+	return nil
+}
@@ -22,7 +22,7 @@ func (e export) Name() string {
 }
 
 func (e export) Usage() string {
-	return "export writes all of your budgeter's transactions to a file. The file extension specified determines the format of the output."
+	return "export writes all of your budgeter's transactions to a file. The file extension specified determines the format of the output. CSV files have the columns that ingest reads, plus each transaction's category, tags, account and kind, so they can be ingested again. Amounts are written as plain numbers like -1234.56, so the file means the same thing in every locale, and they're signed in the same way as ingested files; see `budgeter locale signs`."
 }
 
 // export writes all of the transactions in the given table to the given file name.
@@ -0,0 +1,213 @@
+package budgeter
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/internal/money"
+	"github.com/Anthony-Fiddes/budgeter/model/recurring"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+	"github.com/cheynewallace/tabby"
+)
+
+type forecast struct {
+	days         int
+	spending     bool
+	history      int
+	Out          io.Writer
+	Rates        RateTable
+	Recurring    RecurTable
+	Transactions Table
+}
+
+func newForecast(c *CLI) *forecast {
+	result := &forecast{}
+	result.Out = c.Out
+	result.Rates = c.Rates
+	result.Recurring = c.Recurring
+	result.Transactions = c.Transactions
+	return result
+}
+
+func (f forecast) Name() string {
+	return "forecast"
+}
+
+//go:embed forecastUsage.txt
+var forecastUsage string
+
+func (f forecast) Usage() string {
+	return forecastUsage
+}
+
+// forecast projects the user's balance over the next few days using their
+// recurring transactions and, optionally, how much they usually spend.
+func (f forecast) Run(cmdArgs []string) error {
+	const (
+		// defaultForecastDays is the default number of days to project.
+		defaultForecastDays = 30
+		// defaultHistoryDays is the default number of days of history used to
+		// calculate average spending.
+		defaultHistoryDays = 90
+	)
+
+	fs := getFlagset(f.Name())
+	fs.IntVar(&f.days, "days", defaultForecastDays, "")
+	fs.BoolVar(&f.spending, "spending", false, "")
+	fs.IntVar(&f.history, "history", defaultHistoryDays, "")
+	if err := fs.Parse(cmdArgs); err != nil {
+		return err
+	}
+	if len(fs.Args()) > 0 {
+		return fmt.Errorf("%s takes no arguments", f.Name())
+	}
+	if f.days < 1 {
+		return fmt.Errorf("-days must be at least 1")
+	}
+	if f.history < 1 {
+		return fmt.Errorf("-history must be at least 1")
+	}
+
+	totals, err := f.Transactions.Totals()
+	if err != nil {
+		return err
+	}
+	balance, err := totalToHome(f.Rates, totals, today())
+	if err != nil {
+		return err
+	}
+	rules, err := f.Recurring.All()
+	if err != nil {
+		return err
+	}
+	var spent transaction.Cent
+	if f.spending {
+		spent, err = f.discretionarySpending(rules)
+		if err != nil {
+			return err
+		}
+	}
+
+	start := today().AddDate(0, 0, 1)
+	changes, err := f.recurringChanges(rules, start)
+	if err != nil {
+		return err
+	}
+
+	tab := tabby.NewCustom(newTabWriter(f.Out))
+	tab.AddHeader("Date", "Change", "Balance")
+	lowest := balance
+	lowestDate := today()
+	var spentSoFar transaction.Cent
+	for day, change := range changes {
+		if f.spending {
+			// Spread the average spending evenly across the days without
+			// letting the rounding add up.
+			total, err := spent.Mul(int64(day + 1))
+			if err == nil {
+				total, err = total.Div(int64(f.history), money.Down)
+			}
+			if err == nil {
+				change, err = transaction.Sum(change, total, -spentSoFar)
+			}
+			if err != nil {
+				return err
+			}
+			spentSoFar = total
+		}
+		balance, err = balance.Add(change)
+		if err != nil {
+			return err
+		}
+		date := start.AddDate(0, 0, day)
+		if balance < lowest {
+			lowest = balance
+			lowestDate = date
+		}
+		tab.AddLine(date.Format(transaction.DateLayout), alignAmount(change), alignAmount(balance))
+	}
+	tab.Print()
+
+	fmt.Fprintln(f.Out)
+	fmt.Fprintf(f.Out, "Lowest balance: %s on %s\n", lowest, lowestDate.Format(transaction.DateLayout))
+	if lowest < 0 {
+		fmt.Fprintln(f.Out, "Your balance is projected to go below zero!")
+	}
+	return nil
+}
+
+// recurringChanges returns the total of the given recurring rules'
+// occurrences in the home currency on each of the f.days days from "start".
+func (f forecast) recurringChanges(rules []recurring.Rule, start time.Time) ([]transaction.Cent, error) {
+	result := make([]transaction.Cent, f.days)
+	for _, r := range rules {
+		for _, date := range r.Occurrences(start, start.AddDate(0, 0, f.days-1)) {
+			tx := r.Transaction(date)
+			amount, err := toHome(f.Rates, tx.Amount, tx.Currency, date)
+			if err != nil {
+				return nil, err
+			}
+			day := int(date.Sub(start).Hours() / 24)
+			if result[day], err = result[day].Add(amount); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// discretionarySpending returns the total spent over the last f.history days
+// in the home currency. Like in reports, void transactions, transfers and
+// other people's shares aren't counted, and neither are any transactions
+// posted by the given recurring rules.
+func (f forecast) discretionarySpending(rules []recurring.Rule) (transaction.Cent, error) {
+	type key struct {
+		entity   string
+		amount   transaction.Cent
+		currency string
+		note     string
+	}
+	recurs := make(map[key]bool)
+	for _, r := range rules {
+		code := r.Transaction(today()).CurrencyInfo().Code
+		recurs[key{r.Entity, r.Amount, code, r.Note}] = true
+	}
+
+	start := today().AddDate(0, 0, -f.history+1)
+	end := today().Add(24*time.Hour - time.Second)
+	totals, err := f.Transactions.RangeKindTotals(start, end)
+	if err != nil {
+		return 0, err
+	}
+	expenses := totals[transaction.Expense]
+
+	// Take out the recurring transactions that were counted.
+	rows, err := f.Transactions.Range(start, end, -1)
+	if err != nil {
+		return 0, err
+	}
+	history, err := rows.ScanSet()
+	if err != nil {
+		return 0, err
+	}
+	for _, tx := range history {
+		if !recurs[key{tx.Entity, tx.Amount, tx.CurrencyInfo().Code, tx.Note}] || tx.Status == transaction.Void ||
+			tx.Classify() != transaction.Expense {
+			continue
+		}
+		if tx, err = f.Transactions.Get(tx.ID); err != nil {
+			return 0, err
+		}
+		own, err := tx.OwnAmount()
+		if err != nil {
+			return 0, err
+		}
+		code := tx.CurrencyInfo().Code
+		if expenses[code], err = expenses[code].Sub(own); err != nil {
+			return 0, err
+		}
+	}
+	return totalToHome(f.Rates, expenses, today())
+}
@@ -0,0 +1,268 @@
+package budgeter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/internal/period"
+	"github.com/Anthony-Fiddes/budgeter/model/currency"
+	"github.com/Anthony-Fiddes/budgeter/model/recurring"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+// newTestForecast returns a forecast backed by an in-memory database with the
+// given transactions in it, no recurring transactions and a rate of 0.01
+// dollars per yen.
+func newTestForecast(t *testing.T, transactions ...transaction.Transaction) forecast {
+	t.Helper()
+	db := newTestDB(t)
+	table := &transaction.Table{DB: db}
+	if err := table.Init(); err != nil {
+		t.Fatal(err)
+	}
+	rates := &currency.Table{DB: db}
+	if err := rates.Init(); err != nil {
+		t.Fatal(err)
+	}
+	rate := currency.Rate{Date: today().AddDate(0, 0, -365).Unix(), From: "JPY", To: "USD", Rate: 0.01}
+	if err := rates.Put(rate); err != nil {
+		t.Fatal(err)
+	}
+	recurs := &recurring.Table{DB: db}
+	if err := recurs.Init(); err != nil {
+		t.Fatal(err)
+	}
+	for _, tx := range transactions {
+		if _, err := table.Insert(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return forecast{Rates: rates, Recurring: recurs, Transactions: table}
+}
+
+// daysAgo returns the date "n" days before today in the same form that
+// transaction dates are stored in.
+func daysAgo(n int) int64 {
+	return today().AddDate(0, 0, -n).Unix()
+}
+
+func TestForecastDiscretionarySpending(t *testing.T) {
+	rent := recurring.Rule{
+		Entity:   "Landlord",
+		Amount:   -100000,
+		Schedule: recurring.Schedule{Period: period.Month, Every: 1},
+		Start:    daysAgo(40),
+	}
+	// Charging the same amount in dollars isn't an occurrence of a rule in
+	// yen.
+	ryokan := recurring.Rule{
+		Entity:   "Ryokan",
+		Amount:   -30000,
+		Currency: "JPY",
+		Note:     "Stay",
+		Schedule: recurring.Schedule{Period: period.Month, Every: 1},
+		Start:    daysAgo(40),
+	}
+	tests := []struct {
+		name         string
+		transactions []transaction.Transaction
+		rules        []recurring.Rule
+		want         transaction.Cent
+	}{
+		{
+			name: "expenses and refunds",
+			transactions: []transaction.Transaction{
+				{Date: daysAgo(0), Entity: "Grocer", Amount: -5000},
+				{Date: daysAgo(10), Entity: "Cafe", Amount: -500},
+				{Date: daysAgo(10), Entity: "Grocer", Amount: 1000, Kind: transaction.Expense},
+			},
+			want: -4500,
+		},
+		{
+			name: "income, transfers and void transactions",
+			transactions: []transaction.Transaction{
+				{Date: daysAgo(1), Entity: "Grocer", Amount: -5000},
+				{Date: daysAgo(2), Entity: "Paycheck", Amount: 200000},
+				{Date: daysAgo(3), Entity: "Credit Card", Amount: -30000, Kind: transaction.Transfer},
+				{Date: daysAgo(4), Entity: "Cafe", Amount: -500, Status: transaction.Void},
+			},
+			want: -5000,
+		},
+		{
+			name: "shares",
+			transactions: []transaction.Transaction{
+				{
+					Date: daysAgo(1), Entity: "Restaurant", Amount: -9000,
+					Shares: []transaction.Share{{Person: 1, Amount: -3000}},
+				},
+			},
+			want: -6000,
+		},
+		{
+			name: "other currencies",
+			transactions: []transaction.Transaction{
+				{Date: daysAgo(1), Entity: "Ramen", Amount: -1200, Currency: "JPY"},
+				{Date: daysAgo(1), Entity: "Grocer", Amount: -5000},
+			},
+			want: -6200,
+		},
+		{
+			name: "recurring transactions",
+			transactions: []transaction.Transaction{
+				rent.Transaction(time.Unix(rent.Start, 0).UTC()),
+				{Date: daysAgo(5), Entity: "Landlord", Amount: -2500, Note: "Late fee"},
+			},
+			rules: []recurring.Rule{rent},
+			want:  -2500,
+		},
+		{
+			name: "recurring transactions in other currencies",
+			transactions: []transaction.Transaction{
+				ryokan.Transaction(time.Unix(ryokan.Start, 0).UTC()),
+				{Date: daysAgo(5), Entity: "Ryokan", Amount: -30000, Note: "Stay"},
+			},
+			rules: []recurring.Rule{ryokan},
+			want:  -30000,
+		},
+		{
+			name: "history",
+			transactions: []transaction.Transaction{
+				{Date: daysAgo(89), Entity: "Grocer", Amount: -5000},
+				{Date: daysAgo(90), Entity: "Grocer", Amount: -7000},
+			},
+			want: -5000,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newTestForecast(t, test.transactions...)
+			f.history = 90
+			got, err := f.discretionarySpending(test.rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestForecastRecurringChanges(t *testing.T) {
+	start := today().AddDate(0, 0, 1)
+	tests := []struct {
+		name  string
+		rules []recurring.Rule
+		want  []transaction.Cent
+	}{
+		{
+			name: "none",
+			want: []transaction.Cent{0, 0, 0, 0, 0},
+		},
+		{
+			name: "every other day",
+			rules: []recurring.Rule{{
+				Entity:   "Cafe",
+				Amount:   -500,
+				Schedule: recurring.Schedule{Period: period.Day, Every: 2},
+				Start:    start.Unix(),
+			}},
+			want: []transaction.Cent{-500, 0, -500, 0, -500},
+		},
+		{
+			name: "other currencies",
+			rules: []recurring.Rule{
+				{
+					Entity:   "Ryokan",
+					Amount:   -30000,
+					Currency: "JPY",
+					Schedule: recurring.Schedule{Period: period.Day, Every: 2},
+					Start:    start.Unix(),
+				},
+				{
+					Entity:   "Paycheck",
+					Amount:   200000,
+					Currency: "USD",
+					Schedule: recurring.Schedule{Period: period.Week, Every: 1},
+					Start:    start.AddDate(0, 0, 1).Unix(),
+				},
+			},
+			want: []transaction.Cent{-30000, 200000, -30000, 0, -30000},
+		},
+		{
+			name: "same day",
+			rules: []recurring.Rule{
+				{
+					Entity:   "Paycheck",
+					Amount:   200000,
+					Schedule: recurring.Schedule{Period: period.Week, Every: 1},
+					Start:    start.AddDate(0, 0, -5).Unix(),
+				},
+				{
+					Entity:   "Savings",
+					Amount:   -50000,
+					Schedule: recurring.Schedule{Period: period.Week, Every: 1},
+					Start:    start.AddDate(0, 0, 2).Unix(),
+				},
+			},
+			want: []transaction.Cent{0, 0, 150000, 0, 0},
+		},
+		{
+			name: "ended",
+			rules: []recurring.Rule{{
+				Entity:   "Gym",
+				Amount:   -3000,
+				Schedule: recurring.Schedule{Period: period.Day, Every: 1},
+				Start:    start.AddDate(0, 0, -10).Unix(),
+				End:      start.AddDate(0, 0, 1).Unix(),
+			}},
+			want: []transaction.Cent{-3000, -3000, 0, 0, 0},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newTestForecast(t)
+			f.days = len(test.want)
+			got, err := f.recurringChanges(test.rules, start)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for day := range test.want {
+				if got[day] != test.want[day] {
+					t.Errorf("got %v, want %v", got, test.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+// TestForecastRun tests that a forecast converts recurring transactions in
+// other currencies before adding them to the balance.
+func TestForecastRun(t *testing.T) {
+	f := newTestForecast(t, transaction.Transaction{Date: daysAgo(1), Entity: "Paycheck", Amount: 100000})
+	rule := recurring.Rule{
+		Entity:   "Ryokan",
+		Amount:   -30000,
+		Currency: "JPY",
+		Schedule: recurring.Schedule{Period: period.Week, Every: 1},
+		Start:    today().AddDate(0, 0, 3).Unix(),
+	}
+	if err := f.Recurring.Insert(rule); err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	f.Out = &out
+	if err := f.Run([]string{"-days", "5"}); err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("Lowest balance: $700.00 on %s\n", today().AddDate(0, 0, 3).Format(transaction.DateLayout))
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("expected %q in the forecast but got:\n%s", want, out.String())
+	}
+	if strings.Contains(out.String(), "below zero") {
+		t.Errorf("expected the balance to stay above zero but got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,301 @@
+package budgeter
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/internal/inpt"
+	"github.com/Anthony-Fiddes/budgeter/model/goal"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+	"github.com/cheynewallace/tabby"
+)
+
+type GoalTable interface {
+	Add(goal.Goal) (int, error)
+	All() ([]goal.Goal, error)
+	Contribute(goal.Contribution) (int, error)
+	Contributions(goalID int) ([]goal.Contribution, error)
+	Find(name string) (goal.Goal, error)
+	Remove(goalID int) error
+}
+
+type goals struct {
+	by            string
+	confirmed     bool
+	date          string
+	transactionID int
+	in            *inpt.Scanner
+	Goals         GoalTable
+	Out           io.Writer
+	Rates         RateTable
+	Transactions  Table
+}
+
+func newGoals(c *CLI) *goals {
+	result := &goals{}
+	result.in = c.in
+	result.Goals = c.Goals
+	result.Out = c.Out
+	result.Rates = c.Rates
+	result.Transactions = c.Transactions
+	return result
+}
+
+func (g goals) Name() string {
+	return "goal"
+}
+
+//go:embed goalUsage.txt
+var goalUsage string
+
+func (g goals) Usage() string {
+	return goalUsage
+}
+
+// goal manages savings goals and shows how close they are to being reached.
+func (g goals) Run(cmdArgs []string) error {
+	fs := getFlagset(g.Name())
+	if err := fs.Parse(cmdArgs); err != nil {
+		return err
+	}
+	args := fs.Args()
+	if len(args) == 0 {
+		return g.list()
+	}
+
+	subArgs := args[1:]
+	switch args[0] {
+	case "add":
+		fs := getFlagset(g.Name() + " add")
+		fs.StringVar(&g.by, "by", "", "")
+		if err := fs.Parse(subArgs); err != nil {
+			return err
+		}
+		if fs.NArg() != 2 {
+			return fmt.Errorf("%s add takes a name and a target", g.Name())
+		}
+		target, err := transaction.GetCents(fs.Arg(1))
+		if err != nil {
+			return err
+		}
+		var deadline int64
+		if g.by != "" {
+			if deadline, err = transaction.Unix(g.by); err != nil {
+				return err
+			}
+		}
+		_, err = g.Goals.Add(goal.Goal{Name: fs.Arg(0), Target: target, Deadline: deadline, Created: time.Now().Unix()})
+		return err
+	case "contribute", "withdraw":
+		fs := getFlagset(g.Name() + " " + args[0])
+		fs.StringVar(&g.date, "date", "", "")
+		if args[0] == "contribute" {
+			fs.IntVar(&g.transactionID, "id", 0, "")
+		}
+		if err := fs.Parse(subArgs); err != nil {
+			return err
+		}
+		if fs.NArg() < 1 || fs.NArg() > 2 || (fs.NArg() == 1 && g.transactionID == 0) {
+			return fmt.Errorf("%s %s takes a goal and an amount", g.Name(), args[0])
+		}
+		return g.contribute(fs.Arg(0), fs.Arg(1), args[0] == "withdraw")
+	case "show":
+		if len(subArgs) != 1 {
+			return fmt.Errorf("%s show takes one argument", g.Name())
+		}
+		return g.show(subArgs[0])
+	case "remove":
+		fs := getFlagset(g.Name() + " remove")
+		fs.BoolVar(&g.confirmed, "y", false, "")
+		if err := fs.Parse(subArgs); err != nil {
+			return err
+		}
+		if fs.NArg() != 1 {
+			return fmt.Errorf("%s remove takes one argument", g.Name())
+		}
+		found, err := g.Goals.Find(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		if !g.confirmed {
+			fmt.Fprintf(g.Out, "This will remove \"%s\" and its contributions. Continue? (y/[n]) ", found.Name)
+			ok, err := g.in.Confirm()
+			if err != nil || !ok {
+				return err
+			}
+		}
+		return g.Goals.Remove(found.ID)
+	default:
+		return fmt.Errorf("%s has no subcommand \"%s\"", g.Name(), args[0])
+	}
+}
+
+// contribute adds a contribution of "amount" to the goal with the given name,
+// or takes it out if "withdraw" is true. If the contribution is from a
+// transaction, its amount defaults to the transaction's, and its date is the
+// transaction's.
+func (g goals) contribute(name, amount string, withdraw bool) error {
+	found, err := g.Goals.Find(name)
+	if err != nil {
+		return err
+	}
+	c := goal.Contribution{Goal: found.ID, TransactionID: g.transactionID, Date: today().Unix()}
+	if g.transactionID != 0 {
+		if g.date != "" {
+			return fmt.Errorf("a contribution from a transaction is made on its date")
+		}
+		tx, err := g.Transactions.Get(g.transactionID)
+		if err != nil {
+			return err
+		}
+		c.Date = tx.Date
+		// A transfer to savings is money leaving the account it was made
+		// with, so either sign counts toward the goal.
+		if c.Amount, err = toHome(g.Rates, tx.Amount, tx.Currency, time.Unix(tx.Date, 0)); err != nil {
+			return err
+		}
+		if c.Amount < 0 {
+			c.Amount = -c.Amount
+		}
+	}
+	if amount != "" {
+		if c.Amount, err = transaction.GetCents(amount); err != nil {
+			return err
+		}
+		if c.Amount <= 0 {
+			return fmt.Errorf("the amount must be more than 0")
+		}
+	}
+	if g.date != "" {
+		if c.Date, err = transaction.Unix(g.date); err != nil {
+			return err
+		}
+	}
+	if withdraw {
+		c.Amount = -c.Amount
+	}
+	if _, err := g.Goals.Contribute(c); err != nil {
+		return err
+	}
+	return g.summarize(found)
+}
+
+// progress returns how far along "found" is.
+func (g goals) progress(found goal.Goal) (goal.Progress, []goal.Contribution, error) {
+	contributions, err := g.Goals.Contributions(found.ID)
+	if err != nil {
+		return goal.Progress{}, nil, err
+	}
+	p, err := found.Progress(contributions, today().Unix())
+	return p, contributions, err
+}
+
+// formatDate returns a date stored like a transaction date in M/D/YYYY
+// format, or "" if it's 0.
+func formatDate(date int64) string {
+	if date == 0 {
+		return ""
+	}
+	return time.Unix(date, 0).UTC().Format(transaction.DateLayout)
+}
+
+// formatProjected returns the month that a goal is projected to be reached
+// in, e.g. "March 2027".
+func formatProjected(p goal.Progress) string {
+	switch {
+	case p.Reached():
+		return "reached"
+	case p.Projected.IsZero():
+		return ""
+	}
+	return p.Projected.Format("January 2006")
+}
+
+// list prints every goal and its progress.
+func (g goals) list() error {
+	all, err := g.Goals.All()
+	if err != nil {
+		return err
+	}
+	if len(all) == 0 {
+		fmt.Fprintf(g.Out, "There are no goals. Try `budgeter %s add <name> <target>`.\n", g.Name())
+		return nil
+	}
+	tab := tabby.NewCustom(newTabWriter(g.Out))
+	tab.AddHeader("Goal", "Saved", "Target", "Progress", "Deadline", "Monthly", "Projected")
+	for _, found := range all {
+		p, _, err := g.progress(found)
+		if err != nil {
+			return err
+		}
+		monthly := ""
+		if p.Monthly != 0 {
+			monthly = alignAmount(p.Monthly)
+		}
+		tab.AddLine(
+			found.Name,
+			alignAmount(p.Saved),
+			alignAmount(found.Target),
+			fmt.Sprintf("%d%%", p.Percent),
+			formatDate(found.Deadline),
+			monthly,
+			formatProjected(p),
+		)
+	}
+	tab.Print()
+	return nil
+}
+
+// summarize prints a sentence or two about the progress of "found".
+func (g goals) summarize(found goal.Goal) error {
+	p, _, err := g.progress(found)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(g.Out, "%s: %s of %s saved (%d%%).\n", found.Name, p.Saved, found.Target, p.Percent)
+	switch {
+	case p.Reached():
+		fmt.Fprintln(g.Out, "The goal has been reached!")
+		return nil
+	case p.Overdue:
+		fmt.Fprintf(g.Out, "The deadline of %s has passed with %s to go.\n", formatDate(found.Deadline), p.Remaining)
+	case found.Deadline != 0:
+		fmt.Fprintf(
+			g.Out, "Save %s a month to reach it by %s.\n", p.Monthly, formatDate(found.Deadline),
+		)
+	}
+	if !p.Projected.IsZero() {
+		fmt.Fprintf(g.Out, "At your pace so far, you'll reach it in %s.\n", formatProjected(p))
+	}
+	return nil
+}
+
+// show prints the progress of the goal with the given name and its
+// contributions.
+func (g goals) show(name string) error {
+	found, err := g.Goals.Find(name)
+	if err != nil {
+		return err
+	}
+	if err := g.summarize(found); err != nil {
+		return err
+	}
+	_, contributions, err := g.progress(found)
+	if err != nil || len(contributions) == 0 {
+		return err
+	}
+	fmt.Fprintln(g.Out)
+	tab := tabby.NewCustom(newTabWriter(g.Out))
+	tab.AddHeader("Date", "Amount", "Transaction")
+	for _, c := range contributions {
+		transactionID := ""
+		if c.TransactionID != 0 {
+			transactionID = fmt.Sprintf("#%d", c.TransactionID)
+		}
+		tab.AddLine(formatDate(c.Date), alignAmount(c.Amount), transactionID)
+	}
+	tab.Print()
+	return nil
+}
@@ -0,0 +1,74 @@
+package budgeter
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cheynewallace/tabby"
+)
+
+type history struct {
+	limit   int
+	Journal JournalTable
+	Out     io.Writer
+}
+
+func newHistory(c *CLI) *history {
+	return &history{Journal: c.Journal, Out: c.Out}
+}
+
+func (h history) Name() string {
+	return "history"
+}
+
+//go:embed historyUsage.txt
+var historyUsage string
+
+func (h history) Usage() string {
+	return historyUsage
+}
+
+// history lists the most recent operations that changed transactions.
+func (h history) Run(cmdArgs []string) error {
+	const (
+		defaultHistoryLimit = 20
+		idHeader            = "ID"
+		timeHeader          = "Time"
+		userHeader          = "User"
+		commandHeader       = "Command"
+		changesHeader       = "Changes"
+		undoneHeader        = "Undone"
+	)
+
+	fs := getFlagset(h.Name())
+	fs.IntVar(&h.limit, "l", defaultHistoryLimit, "")
+	if err := fs.Parse(cmdArgs); err != nil {
+		return err
+	}
+	if len(fs.Args()) > 0 {
+		return fmt.Errorf("%s takes no arguments", h.Name())
+	}
+	ops, err := h.Journal.Operations(h.limit)
+	if err != nil {
+		return err
+	}
+	if len(ops) == 0 {
+		fmt.Fprintln(h.Out, "Nothing has been changed yet.")
+		return nil
+	}
+
+	t := tabby.NewCustom(newTabWriter(h.Out))
+	t.AddHeader(idHeader, timeHeader, userHeader, commandHeader, changesHeader, undoneHeader)
+	for _, op := range ops {
+		undone := ""
+		if op.Undone {
+			undone = "yes"
+		}
+		at := time.Unix(op.Time, 0).In(timezone).Format("2006-01-02 15:04")
+		t.AddLine(op.ID, at, op.User, op.Command, op.Changes, undone)
+	}
+	t.Print()
+	return nil
+}
@@ -3,9 +3,11 @@ package budgeter
 import (
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	_ "embed"
 
@@ -15,14 +17,24 @@ import (
 const (
 	extCSV          = ".csv"
 	fieldsPerRecord = 4
+	// pendingMatchDays is how many days a pending transaction can take to
+	// clear. A cleared row in a file only replaces a pending transaction from
+	// that many days before it, at most.
+	pendingMatchDays = 10
 )
 
 type ingest struct {
+	account      string
+	currency     string
+	pending      bool
+	Out          io.Writer
+	Payees       PayeeTable
+	Rules        RuleTable
 	Transactions Table
 }
 
 func newIngest(c *CLI) *ingest {
-	return &ingest{Transactions: c.Transactions}
+	return &ingest{Out: c.Out, Payees: c.Payees, Rules: c.Rules, Transactions: c.Transactions}
 }
 
 func (i ingest) Name() string {
@@ -45,6 +57,9 @@ func (i ingest) Run(cmdArgs []string) error {
 	// TODO: write tests
 	// TODO: use a transaction so that all of the file is added or none of it is!
 	fs := getFlagset(i.Name())
+	fs.StringVar(&i.account, "account", "", "")
+	fs.StringVar(&i.currency, "currency", "", "")
+	fs.BoolVar(&i.pending, "pending", false, "")
 	err := fs.Parse(cmdArgs)
 	if err != nil {
 		return err
@@ -65,19 +80,14 @@ func (i ingest) Run(cmdArgs []string) error {
 		}
 		defer f.Close()
 
-		cw := transaction.NewCSVReader(f)
-		for {
-			tx, err := cw.Read()
-			if err != nil {
-				if err == io.EOF {
-					break
-				}
-				return err
-			}
-			err = i.Transactions.Insert(tx)
-			if err != nil {
-				return err
-			}
+		result, err := i.readCSV(f)
+		if err != nil {
+			return err
+		}
+		if result.Cleared > 0 {
+			fmt.Fprintf(i.Out, "Added %d transactions and cleared %d pending ones.\n", result.Added, result.Cleared)
+		} else if result.Added > 0 {
+			fmt.Fprintf(i.Out, "Added %d transactions.\n", result.Added)
 		}
 	case "":
 		return fmt.Errorf("no file type specified")
@@ -87,3 +97,125 @@ func (i ingest) Run(cmdArgs []string) error {
 
 	return nil
 }
+
+// ingested counts what ingesting a file did.
+type ingested struct {
+	// Added is how many transactions were added.
+	Added int `json:"added"`
+	// Cleared is how many pending transactions were updated by cleared rows
+	// instead of the rows being added.
+	Cleared int `json:"cleared"`
+}
+
+// readCSV reads transactions from CSV data in "r" and inserts them after
+// applying the user's payee aliases and rules. A cleared row that matches a
+// pending transaction updates it instead, since banks often list a charge
+// again with its final amount once it goes through.
+func (i ingest) readCSV(r io.Reader) (ingested, error) {
+	var result ingested
+	payees, err := loadPayees(i.Payees)
+	if err != nil {
+		return result, err
+	}
+	rules, err := loadRules(i.Rules)
+	if err != nil {
+		return result, err
+	}
+	cr := transaction.NewCSVReader(r)
+	cr.Currency, err = lookupCurrency(i.currency)
+	if err != nil {
+		return result, err
+	}
+	for {
+		tx, err := cr.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return result, err
+		}
+		if tx.Status == "" && i.pending {
+			tx.Status = transaction.Pending
+		}
+		if tx.Account == "" {
+			tx.Account = i.account
+		}
+		tx.Entity = payees.Normalize(tx.Entity)
+		tx = rules.Apply(tx)
+
+		if tx.Status == "" || tx.Status == transaction.Cleared {
+			cleared, err := i.clearPending(tx)
+			if err != nil {
+				return result, err
+			}
+			if cleared {
+				result.Cleared++
+				continue
+			}
+		}
+		if _, err := i.Transactions.Insert(tx); err != nil {
+			return result, err
+		}
+		result.Added++
+	}
+	return result, nil
+}
+
+// clearPending looks for a pending transaction that the cleared transaction
+// "tx" is the final version of: one made with the same entity, account and
+// currency in the pendingMatchDays before it. The closest in amount is
+// updated with the amount and date of "tx" and cleared. It returns whether
+// there was one.
+func (i ingest) clearPending(tx transaction.Transaction) (bool, error) {
+	end := time.Unix(tx.Date, 0).UTC()
+	rows, err := i.Transactions.Range(end.AddDate(0, 0, -pendingMatchDays), end, -1)
+	if err != nil {
+		return false, err
+	}
+	candidates, err := rows.ScanSet()
+	if err != nil {
+		return false, err
+	}
+
+	var match transaction.Transaction
+	found := false
+	for _, c := range candidates {
+		if c.Status != transaction.Pending || !strings.EqualFold(c.Entity, tx.Entity) ||
+			c.Account != tx.Account || c.CurrencyInfo().Code != tx.CurrencyInfo().Code {
+			continue
+		}
+		// Candidates are in chronological order, so ties go to the latest.
+		if !found || distance(c.Amount, tx.Amount) <= distance(match.Amount, tx.Amount) {
+			match = c
+			found = true
+		}
+	}
+	if !found {
+		return false, nil
+	}
+
+	match.Amount = tx.Amount
+	match.Date = tx.Date
+	if tx.Note != "" {
+		match.Note = tx.Note
+	}
+	if err := i.Transactions.Clear(match); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// distance returns how far apart two amounts are. It saturates instead of
+// overflowing, since it's only used to compare amounts.
+func distance(a, b transaction.Cent) transaction.Cent {
+	d, err := a.Sub(b)
+	if err != nil {
+		return math.MaxInt64
+	}
+	if d < 0 {
+		if d == math.MinInt64 {
+			return math.MaxInt64
+		}
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,112 @@
+package budgeter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Anthony-Fiddes/budgeter/model/payee"
+	"github.com/Anthony-Fiddes/budgeter/model/rule"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+func TestIngestClearsPending(t *testing.T) {
+	db := newTestDB(t)
+	transactions := &transaction.Table{DB: db}
+	if err := transactions.Init(); err != nil {
+		t.Fatal(err)
+	}
+	payees := &payee.Table{DB: db}
+	if err := payees.Init(); err != nil {
+		t.Fatal(err)
+	}
+	rules := &rule.Table{DB: db}
+	if err := rules.Init(); err != nil {
+		t.Fatal(err)
+	}
+	i := ingest{account: "Checking", Payees: payees, Rules: rules, Transactions: transactions}
+
+	pending := "1/6/2021,Gas Station,-$40.00,\n" +
+		"1/7/2021,Gas Station,-$1.00,\n" +
+		"1/7/2021,Hotel,-$100.00,,pending\n"
+	i.pending = true
+	result, err := i.readCSV(strings.NewReader(pending))
+	if err != nil || result.Added != 3 || result.Cleared != 0 {
+		t.Fatalf("ingesting pending transactions returned %+v: %v", result, err)
+	}
+
+	cleared := "1/9/2021,Gas Station,-$38.52,Final\n" +
+		// Too long after the pending charge to be the same one.
+		"1/30/2021,Hotel,-$100.00,\n"
+	i.pending = false
+	result, err = i.readCSV(strings.NewReader(cleared))
+	if err != nil || result.Added != 1 || result.Cleared != 1 {
+		t.Fatalf("ingesting cleared transactions returned %+v: %v", result, err)
+	}
+
+	rows, err := transactions.Search("Gas Station", -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gas, err := rows.ScanSet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gas) != 2 {
+		t.Fatalf("expected 2 gas station transactions but found %+v", gas)
+	}
+	// The most recent is first.
+	final, small := gas[0], gas[1]
+	if final.Amount != -3852 || final.DateString() != "1/9/2021" || final.Note != "Final" ||
+		final.Status != transaction.Cleared {
+		t.Errorf("expected the closest pending charge to be cleared but found %+v", final)
+	}
+	if small.Amount != -100 || small.Status != transaction.Pending {
+		t.Errorf("expected the other pending charge to be left alone but found %+v", small)
+	}
+}
+
+// TestIngestExported tests that exported transactions keep their account,
+// category, tags and kind when they're ingested again.
+func TestIngestExported(t *testing.T) {
+	db := newTestDB(t)
+	transactions := &transaction.Table{DB: db}
+	if err := transactions.Init(); err != nil {
+		t.Fatal(err)
+	}
+	payees := &payee.Table{DB: db}
+	if err := payees.Init(); err != nil {
+		t.Fatal(err)
+	}
+	rules := &rule.Table{DB: db}
+	if err := rules.Init(); err != nil {
+		t.Fatal(err)
+	}
+	i := ingest{account: "Checking", Payees: payees, Rules: rules, Transactions: transactions}
+
+	exported := "1/9/2021,Falafel King,-1234.56,,cleared,USD,Dining,\"Friends,Lunch\",Visa,\n" +
+		"1/10/2021,Savings,-500.00,,cleared,USD,,,,transfer\n"
+	result, err := i.readCSV(strings.NewReader(exported))
+	if err != nil || result.Added != 2 {
+		t.Fatalf("ingesting exported transactions returned %+v: %v", result, err)
+	}
+	rows, err := transactions.Search("", -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txs, err := rows.ScanSet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) != 2 {
+		t.Fatalf("expected 2 transactions but found %+v", txs)
+	}
+	// The most recent is first.
+	savings, falafel := txs[0], txs[1]
+	if falafel.Amount != -123456 || falafel.Account != "Visa" || falafel.Category != "Dining" ||
+		strings.Join(falafel.Tags, ",") != "Friends,Lunch" {
+		t.Errorf("expected the exported fields to be kept but found %+v", falafel)
+	}
+	if savings.Account != "Checking" || savings.Kind != transaction.Transfer {
+		t.Errorf("expected the given account and the exported kind but found %+v", savings)
+	}
+}
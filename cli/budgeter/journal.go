@@ -0,0 +1,128 @@
+package budgeter
+
+import (
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/model/journal"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+type JournalTable interface {
+	Operations(limit int) ([]journal.Operation, error)
+	Redo() (journal.Operation, error)
+	Undo(operationID int) (journal.Operation, error)
+}
+
+// journaled is a Table that records every change made through it in a
+// journal, so that it can be undone. Each change is recorded in the same
+// database transaction as the change itself, so a change is never made
+// without being recorded.
+//
+// Changes are grouped into operations. An operation is started by the first
+// change after begin is called, so commands that don't change anything don't
+// add empty operations to the journal.
+//
+// Purging the trash isn't recorded, since what's purged is gone for good.
+// Undoing an operation that a purged transaction was part of fails with a
+// conflict instead.
+type journaled struct {
+	Table
+	user    string
+	command string
+	// operation is the ID of the current operation, or 0 if it hasn't changed
+	// anything yet.
+	operation int
+}
+
+// newJournaled returns a journaled "t", which observes every change made to
+// it from now on.
+func newJournaled(t Table, user string) *journaled {
+	result := &journaled{Table: t, user: user}
+	t.Observe(result.record)
+	return result
+}
+
+// begin starts a new operation for the given command.
+func (j *journaled) begin(command string) {
+	j.command = command
+	j.operation = 0
+}
+
+// beginOperation starts a new operation if "t" is journaled. It's used by
+// commands like serve that make several unrelated changes in one run.
+func beginOperation(t Table, command string) {
+	if j, ok := t.(*journaled); ok {
+		j.begin(command)
+	}
+}
+
+// record adds a change to the current operation, starting it if it hasn't
+// been. It's the table's observer, so it runs in the change's database
+// transaction.
+func (j *journaled) record(e transaction.Execer, transactionID int, before, after *transaction.Transaction) error {
+	if j.operation == 0 {
+		op, err := journal.Begin(e, j.command, j.user, time.Now())
+		if err != nil {
+			return err
+		}
+		j.operation = op
+	}
+	return journal.Record(e, j.operation, journal.Change{TransactionID: transactionID, Before: before, After: after})
+}
+
+// atomic runs "change". If it fails, its database transaction is rolled back,
+// so an operation that it started is forgotten too.
+func (j *journaled) atomic(change func() error) error {
+	operation := j.operation
+	if err := change(); err != nil {
+		j.operation = operation
+		return err
+	}
+	return nil
+}
+
+func (j *journaled) Insert(tx transaction.Transaction) (int, error) {
+	var id int
+	err := j.atomic(func() error {
+		var err error
+		id, err = j.Table.Insert(tx)
+		return err
+	})
+	return id, err
+}
+
+func (j *journaled) Update(tx transaction.Transaction) error {
+	return j.atomic(func() error { return j.Table.Update(tx) })
+}
+
+func (j *journaled) UpdateAll(txs []transaction.Transaction, also func(e transaction.Execer) error) error {
+	return j.atomic(func() error { return j.Table.UpdateAll(txs, also) })
+}
+
+func (j *journaled) Remove(transactionID int) error {
+	return j.atomic(func() error { return j.Table.Remove(transactionID) })
+}
+
+func (j *journaled) RemoveAll(transactionIDs []int) error {
+	return j.atomic(func() error { return j.Table.RemoveAll(transactionIDs) })
+}
+
+func (j *journaled) Recover(transactionID int) error {
+	return j.atomic(func() error { return j.Table.Recover(transactionID) })
+}
+
+func (j *journaled) SetShares(transactionID int, shares []transaction.Share) error {
+	return j.atomic(func() error { return j.Table.SetShares(transactionID, shares) })
+}
+
+func (j *journaled) SetStatus(transactionID int, status transaction.Status) error {
+	return j.atomic(func() error { return j.Table.SetStatus(transactionID, status) })
+}
+
+func (j *journaled) SetStatusAll(transactionIDs []int, status transaction.Status) error {
+	return j.atomic(func() error { return j.Table.SetStatusAll(transactionIDs, status) })
+}
+
+func (j *journaled) Clear(tx transaction.Transaction) error {
+	return j.atomic(func() error { return j.Table.Clear(tx) })
+}
@@ -0,0 +1,199 @@
+package budgeter
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/internal/money"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+const (
+	// localeKey is the config key for the name of the locale that amounts are
+	// formatted and parsed in.
+	localeKey = "locale"
+	// accountingKey is the config key for whether negative amounts are
+	// written in parentheses.
+	accountingKey = "accounting"
+	// roundingKey is the config key for how amounts with too many digits
+	// after the decimal point are read.
+	roundingKey = "rounding"
+	// timezoneKey is the config key for the IANA name of the user's time
+	// zone, which determines what day it is for them.
+	timezoneKey = "timezone"
+	// signsKey is the config key for the sign convention of the amounts in
+	// the files that transactions are ingested from and exported to.
+	signsKey = "signs"
+)
+
+// timezone is the user's time zone. It's the system's time zone by default.
+var timezone = time.Local
+
+// loadTimezone sets the user's time zone to the one in the config, if there is
+// one. If it's invalid, the time zone is left as it was.
+func loadTimezone(config Store) error {
+	name, err := config.Get(timezoneKey)
+	if err != nil || name == "" {
+		return err
+	}
+	zone, err := time.LoadLocation(name)
+	if err != nil {
+		return fmt.Errorf("the time zone in your config is invalid: %w", err)
+	}
+	timezone = zone
+	return nil
+}
+
+// loadLocale sets the locale and the sign convention of files to the ones in
+// the config, if there are any. If any of them are invalid, neither is
+// changed.
+func loadLocale(config Store) error {
+	l := money.Default
+	name, err := config.Get(localeKey)
+	if err != nil {
+		return err
+	}
+	if name != "" {
+		l, err = money.Lookup(name)
+		if err != nil {
+			return fmt.Errorf("the locale in your config is invalid: %w", err)
+		}
+	}
+	accounting, err := config.Get(accountingKey)
+	if err != nil {
+		return err
+	}
+	if accounting != "" {
+		l.Accounting, err = strconv.ParseBool(accounting)
+		if err != nil {
+			return fmt.Errorf("\"%s\" in your config must be true or false", accountingKey)
+		}
+	}
+	rounding, err := config.Get(roundingKey)
+	if err != nil {
+		return err
+	}
+	if rounding != "" {
+		l.Rounding, err = money.ParseRounding(rounding)
+		if err != nil {
+			return fmt.Errorf("the rounding in your config is invalid: %w", err)
+		}
+	}
+	signs, err := config.Get(signsKey)
+	if err != nil {
+		return err
+	}
+	fileSigns := transaction.FileSigns()
+	if signs != "" {
+		fileSigns, err = transaction.ParseSigns(signs)
+		if err != nil {
+			return fmt.Errorf("the sign convention in your config is invalid: %w", err)
+		}
+	}
+	transaction.SetFileSigns(fileSigns)
+	transaction.SetLocale(l)
+	return nil
+}
+
+type locale struct {
+	Config Store
+	Out    io.Writer
+}
+
+func newLocale(c *CLI) *locale {
+	result := &locale{}
+	result.Config = c.Config
+	result.Out = c.Out
+	return result
+}
+
+func (l locale) Name() string {
+	return "locale"
+}
+
+//go:embed localeUsage.txt
+var localeUsage string
+
+func (l locale) Usage() string {
+	return localeUsage
+}
+
+func (l locale) Run(cmdArgs []string) error {
+	fs := getFlagset(l.Name())
+	if err := fs.Parse(cmdArgs); err != nil {
+		return err
+	}
+	args := fs.Args()
+	if len(args) == 0 {
+		current := transaction.Locale()
+		fmt.Fprintf(l.Out, "Locale: %s\n", current.Name)
+		fmt.Fprintf(l.Out, "Accounting: %t\n", current.Accounting)
+		fmt.Fprintf(l.Out, "Rounding: %s\n", current.Rounding)
+		fmt.Fprintf(l.Out, "Time zone: %s\n", timezone)
+		fmt.Fprintf(l.Out, "Signs in files: %s\n", transaction.FileSigns())
+		fmt.Fprintf(l.Out, "E.g. %s\n", transaction.Cent(-123456).String())
+		return nil
+	}
+
+	subArgs := args[1:]
+	switch args[0] {
+	case "list":
+		if len(subArgs) != 0 {
+			return fmt.Errorf("%s list takes no arguments", l.Name())
+		}
+		for _, name := range money.Names() {
+			fmt.Fprintln(l.Out, name)
+		}
+		return nil
+	case "accounting":
+		if len(subArgs) != 1 {
+			return fmt.Errorf("%s accounting takes one argument", l.Name())
+		}
+		accounting, err := strconv.ParseBool(subArgs[0])
+		if err != nil {
+			return fmt.Errorf("%s accounting must be given true or false", l.Name())
+		}
+		return l.Config.Put(accountingKey, strconv.FormatBool(accounting))
+	case "rounding":
+		if len(subArgs) != 1 {
+			return fmt.Errorf("%s rounding takes one argument", l.Name())
+		}
+		rounding, err := money.ParseRounding(subArgs[0])
+		if err != nil {
+			return err
+		}
+		return l.Config.Put(roundingKey, rounding.String())
+	case "timezone":
+		if len(subArgs) != 1 {
+			return fmt.Errorf("%s timezone takes one argument", l.Name())
+		}
+		zone, err := time.LoadLocation(subArgs[0])
+		if err != nil {
+			return fmt.Errorf("unknown time zone \"%s\". try a name like America/New_York", subArgs[0])
+		}
+		return l.Config.Put(timezoneKey, zone.String())
+	case "signs":
+		if len(subArgs) != 1 {
+			return fmt.Errorf("%s signs takes one argument", l.Name())
+		}
+		signs, err := transaction.ParseSigns(subArgs[0])
+		if err != nil {
+			return fmt.Errorf(
+				"%s signs must be given %s or %s", l.Name(), transaction.IncomePositive, transaction.SpendingPositive,
+			)
+		}
+		return l.Config.Put(signsKey, string(signs))
+	default:
+		if len(subArgs) != 0 {
+			return fmt.Errorf("%s takes at most one argument", l.Name())
+		}
+		chosen, err := money.Lookup(args[0])
+		if err != nil {
+			return fmt.Errorf("%w. try `budgeter %s list`", err, l.Name())
+		}
+		return l.Config.Put(localeKey, chosen.Name)
+	}
+}
@@ -0,0 +1,170 @@
+package budgeter
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Anthony-Fiddes/budgeter/model/payee"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+	"github.com/cheynewallace/tabby"
+)
+
+type PayeeTable interface {
+	All() ([]payee.Alias, error)
+	Insert(payee.Alias) error
+	Remove(aliasID int) error
+}
+
+// loadPayees returns a normalizer that uses all of the aliases in "t".
+func loadPayees(t PayeeTable) (*payee.Normalizer, error) {
+	aliases, err := t.All()
+	if err != nil {
+		return nil, err
+	}
+	return payee.NewNormalizer(aliases)
+}
+
+type payees struct {
+	regex        bool
+	Out          io.Writer
+	Payees       PayeeTable
+	Transactions Table
+}
+
+func newPayees(c *CLI) *payees {
+	result := &payees{}
+	result.Out = c.Out
+	result.Payees = c.Payees
+	result.Transactions = c.Transactions
+	return result
+}
+
+func (p payees) Name() string {
+	return "payees"
+}
+
+//go:embed payeesUsage.txt
+var payeesUsage string
+
+func (p payees) Usage() string {
+	return payeesUsage
+}
+
+func (p payees) Run(cmdArgs []string) error {
+	fs := getFlagset(p.Name())
+	if err := fs.Parse(cmdArgs); err != nil {
+		return err
+	}
+	args := fs.Args()
+	if len(args) == 0 {
+		return p.list()
+	}
+
+	subArgs := args[1:]
+	switch args[0] {
+	case "alias":
+		fs := getFlagset(p.Name() + " alias")
+		fs.BoolVar(&p.regex, "r", false, "")
+		if err := fs.Parse(subArgs); err != nil {
+			return err
+		}
+		if len(fs.Args()) != 2 {
+			return fmt.Errorf("%s alias takes two arguments", p.Name())
+		}
+		a := payee.Alias{Pattern: fs.Arg(0), Regex: p.regex, Name: fs.Arg(1)}
+		return p.Payees.Insert(a)
+	case "rename", "merge":
+		if len(subArgs) != 2 {
+			return fmt.Errorf("%s %s takes two arguments", p.Name(), args[0])
+		}
+		return p.merge(subArgs[0], subArgs[1])
+	case "remove":
+		if len(subArgs) != 1 {
+			return fmt.Errorf("%s remove takes one argument", p.Name())
+		}
+		aliasID, err := strconv.Atoi(subArgs[0])
+		if err != nil {
+			return fmt.Errorf(
+				"%s remove takes a numerical ID. try `budgeter %s` to see some IDs.",
+				p.Name(),
+				p.Name(),
+			)
+		}
+		if err := p.Payees.Remove(aliasID); err != nil {
+			return fmt.Errorf("could not remove alias #%d: %v", aliasID, err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%s has no subcommand \"%s\"", p.Name(), args[0])
+	}
+}
+
+// list prints every alias, grouped by payee.
+func (p payees) list() error {
+	aliases, err := p.Payees.All()
+	if err != nil {
+		return err
+	}
+	tab := tabby.NewCustom(newTabWriter(p.Out))
+	tab.AddHeader("Payee", "ID", "Alias", "Type")
+	lastName := ""
+	for _, a := range aliases {
+		name := a.Name
+		if name == lastName {
+			name = ""
+		}
+		lastName = a.Name
+		aliasType := "exact"
+		if a.Regex {
+			aliasType = "pattern"
+		}
+		tab.AddLine(name, a.ID, a.Pattern, aliasType)
+	}
+	tab.Print()
+	return nil
+}
+
+// merge makes "from" an alias of "into", moving all of the aliases and
+// transactions of "from" to "into". If "into" is a new name, this renames
+// "from". Everything is moved in one database transaction, except for
+// reconciled transactions, which are left as they were.
+func (p payees) merge(from, into string) error {
+	if strings.TrimSpace(into) == "" {
+		return fmt.Errorf("a payee's name can't be empty")
+	}
+	rows, err := p.Transactions.Search(from, -1)
+	if err != nil {
+		return err
+	}
+	transactions, err := rows.ScanSet()
+	if err != nil {
+		return err
+	}
+	var moved []transaction.Transaction
+	locked := 0
+	for _, tx := range transactions {
+		if !strings.EqualFold(tx.Entity, from) {
+			continue
+		}
+		if tx.Status == transaction.Reconciled {
+			locked++
+			continue
+		}
+		tx.Entity = into
+		moved = append(moved, tx)
+	}
+	err = p.Transactions.UpdateAll(moved, func(e transaction.Execer) error {
+		return payee.Merge(e, from, into)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(p.Out, "Moved %d transactions from \"%s\" to \"%s\".\n", len(moved), from, into)
+	if locked > 0 {
+		fmt.Fprintf(p.Out, "Left %d reconciled transactions as they were.\n", locked)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package budgeter
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/Anthony-Fiddes/budgeter/model/person"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+	"github.com/cheynewallace/tabby"
+)
+
+type PeopleTable interface {
+	Add(name string) (person.Person, error)
+	All() ([]person.Person, error)
+	Find(name string) (person.Person, error)
+	Remove(personID int) error
+}
+
+// personNames returns the names of everyone in "t", keyed by ID.
+func personNames(t PeopleTable) (map[int]string, error) {
+	people, err := t.All()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[int]string)
+	for _, p := range people {
+		result[p.ID] = p.Name
+	}
+	return result, nil
+}
+
+// personName returns the name of the person with the given ID, or a
+// placeholder if they've been removed.
+func personName(names map[int]string, personID int) string {
+	if name, ok := names[personID]; ok {
+		return name
+	}
+	return fmt.Sprintf("person #%d", personID)
+}
+
+// describeBalance describes what a balance that a person owes the user means,
+// e.g. "Alice owes you $30.00".
+func describeBalance(name string, balance transaction.Cent, cur string) (string, error) {
+	c, err := lookupCurrency(cur)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case balance > 0:
+		return fmt.Sprintf("%s owes you %s", name, balance.Format(c)), nil
+	case balance < 0:
+		return fmt.Sprintf("you owe %s %s", name, (-balance).Format(c)), nil
+	default:
+		return fmt.Sprintf("%s and you are settled up", name), nil
+	}
+}
+
+type people struct {
+	Out          io.Writer
+	People       PeopleTable
+	Rates        RateTable
+	Transactions Table
+}
+
+func newPeople(c *CLI) *people {
+	result := &people{}
+	result.Out = c.Out
+	result.People = c.People
+	result.Rates = c.Rates
+	result.Transactions = c.Transactions
+	return result
+}
+
+func (p people) Name() string {
+	return "people"
+}
+
+//go:embed peopleUsage.txt
+var peopleUsage string
+
+func (p people) Usage() string {
+	return peopleUsage
+}
+
+// people manages the people that the user shares expenses with, and shows
+// who owes whom.
+func (p people) Run(cmdArgs []string) error {
+	fs := getFlagset(p.Name())
+	if err := fs.Parse(cmdArgs); err != nil {
+		return err
+	}
+	args := fs.Args()
+	if len(args) == 0 {
+		return p.balances()
+	}
+
+	subArgs := args[1:]
+	switch args[0] {
+	case "add":
+		if len(subArgs) != 1 {
+			return fmt.Errorf("%s add takes one argument", p.Name())
+		}
+		_, err := p.People.Add(subArgs[0])
+		return err
+	case "remove":
+		if len(subArgs) != 1 {
+			return fmt.Errorf("%s remove takes one argument", p.Name())
+		}
+		found, err := p.People.Find(subArgs[0])
+		if err != nil {
+			return err
+		}
+		balances, err := p.Transactions.Balances()
+		if err != nil {
+			return err
+		}
+		if _, ok := balances[found.ID]; ok {
+			return fmt.Errorf(
+				"%s still has shares of transactions. try `budgeter share -c <ID>` to remove them first",
+				found.Name,
+			)
+		}
+		return p.People.Remove(found.ID)
+	default:
+		return fmt.Errorf("%s has no subcommand \"%s\"", p.Name(), args[0])
+	}
+}
+
+// balances prints everyone and how much they owe the user in the home
+// currency, from most to least.
+func (p people) balances() error {
+	everyone, err := p.People.All()
+	if err != nil {
+		return err
+	}
+	if len(everyone) == 0 {
+		fmt.Fprintf(p.Out, "There are no people. Try `budgeter %s add <name>`.\n", p.Name())
+		return nil
+	}
+	balances, err := p.Transactions.Balances()
+	if err != nil {
+		return err
+	}
+	totals := make(map[int]transaction.Cent)
+	for _, someone := range everyone {
+		if totals[someone.ID], err = totalToHome(p.Rates, balances[someone.ID], now()); err != nil {
+			return err
+		}
+	}
+	sort.SliceStable(everyone, func(i, j int) bool { return totals[everyone[i].ID] > totals[everyone[j].ID] })
+
+	tab := tabby.NewCustom(newTabWriter(p.Out))
+	tab.AddHeader("Person", "Owes You", "")
+	for _, someone := range everyone {
+		total := totals[someone.ID]
+		description, err := describeBalance(someone.Name, total, "")
+		if err != nil {
+			return err
+		}
+		tab.AddLine(someone.Name, alignAmount(total), description)
+	}
+	tab.Print()
+	return nil
+}
@@ -0,0 +1,208 @@
+package budgeter
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/model/currency"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+	"github.com/cheynewallace/tabby"
+)
+
+// homeCurrencyKey is the config key for the code of the currency that totals
+// are reported in.
+const homeCurrencyKey = "home_currency"
+
+type RateTable interface {
+	All() ([]currency.Rate, error)
+	Put(currency.Rate) error
+	Rate(from, to string, date int64) (float64, error)
+}
+
+// loadHomeCurrency sets the home currency to the one in the config, if there is
+// one.
+func loadHomeCurrency(config Store) error {
+	code, err := config.Get(homeCurrencyKey)
+	if err != nil || code == "" {
+		return err
+	}
+	home, err := currency.Lookup(code)
+	if err != nil {
+		return fmt.Errorf("the home currency in your config is invalid: %w", err)
+	}
+	transaction.SetHome(home)
+	return nil
+}
+
+// toHome converts "amount" in the currency with the given code to the home
+// currency, using the exchange rate on "date".
+func toHome(rates RateTable, amount transaction.Cent, code string, date time.Time) (transaction.Cent, error) {
+	home := transaction.Home()
+	if code == "" || code == home.Code {
+		return amount, nil
+	}
+	from, err := currency.Lookup(code)
+	if err != nil {
+		return 0, err
+	}
+	rate, err := rates.Rate(from.Code, home.Code, date.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("%w. try `budgeter rates import`", err)
+	}
+	result, err := currency.Convert(int64(amount), from, home, rate)
+	if err != nil {
+		return 0, err
+	}
+	return transaction.Cent(result), nil
+}
+
+// totalToHome converts totals in several currencies, keyed by currency code,
+// to the home currency and adds them up.
+func totalToHome(rates RateTable, totals map[string]transaction.Cent, date time.Time) (transaction.Cent, error) {
+	var result transaction.Cent
+	for code, total := range totals {
+		amount, err := toHome(rates, total, code, date)
+		if err != nil {
+			return 0, err
+		}
+		result, err = result.Add(amount)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return result, nil
+}
+
+// lookupCurrency returns the currency with the given code, or the home
+// currency if the code is empty.
+func lookupCurrency(code string) (currency.Currency, error) {
+	if code == "" {
+		return transaction.Home(), nil
+	}
+	return currency.Lookup(code)
+}
+
+type rates struct {
+	Config Store
+	Out    io.Writer
+	Rates  RateTable
+}
+
+func newRates(c *CLI) *rates {
+	result := &rates{}
+	result.Config = c.Config
+	result.Out = c.Out
+	result.Rates = c.Rates
+	return result
+}
+
+func (r rates) Name() string {
+	return "rates"
+}
+
+//go:embed ratesUsage.txt
+var ratesUsage string
+
+func (r rates) Usage() string {
+	return ratesUsage
+}
+
+func (r rates) Run(cmdArgs []string) error {
+	fs := getFlagset(r.Name())
+	if err := fs.Parse(cmdArgs); err != nil {
+		return err
+	}
+	args := fs.Args()
+	if len(args) == 0 {
+		return r.list()
+	}
+
+	subArgs := args[1:]
+	switch args[0] {
+	case "import":
+		if len(subArgs) != 1 {
+			return fmt.Errorf("%s import takes one argument", r.Name())
+		}
+		return r.importCSV(subArgs[0])
+	case "home":
+		if len(subArgs) == 0 {
+			fmt.Fprintln(r.Out, transaction.Home().Code)
+			return nil
+		} else if len(subArgs) > 1 {
+			return fmt.Errorf("%s home takes at most one argument", r.Name())
+		}
+		home, err := currency.Lookup(subArgs[0])
+		if err != nil {
+			return err
+		}
+		return r.Config.Put(homeCurrencyKey, home.Code)
+	default:
+		return fmt.Errorf("%s has no subcommand \"%s\"", r.Name(), args[0])
+	}
+}
+
+// list prints every exchange rate.
+func (r rates) list() error {
+	all, err := r.Rates.All()
+	if err != nil {
+		return err
+	}
+	tab := tabby.NewCustom(newTabWriter(r.Out))
+	tab.AddHeader("Date", "From", "To", "Rate")
+	for _, rate := range all {
+		tab.AddLine(
+			transaction.Transaction{Date: rate.Date}.DateString(),
+			rate.From,
+			rate.To,
+			strconv.FormatFloat(rate.Rate, 'f', -1, 64),
+		)
+	}
+	tab.Print()
+	return nil
+}
+
+// importCSV reads exchange rates from a CSV file with the columns Date, From,
+// To, Rate into the rates table.
+func (r rates) importCSV(filePath string) error {
+	const numCols = 4
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("could not open \"%s\": %v", filePath, err)
+	}
+	defer f.Close()
+
+	cr := csv.NewReader(f)
+	cr.FieldsPerRecord = numCols
+	cr.TrimLeadingSpace = true
+	imported := 0
+	for {
+		cols, err := cr.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		rate := currency.Rate{From: cols[1], To: cols[2]}
+		rate.Date, err = transaction.Unix(cols[0])
+		if err != nil {
+			return err
+		}
+		rate.Rate, err = strconv.ParseFloat(strings.TrimSpace(cols[3]), 64)
+		if err != nil {
+			return fmt.Errorf("exchange rate \"%s\" is not a number", cols[3])
+		}
+		if err := r.Rates.Put(rate); err != nil {
+			return err
+		}
+		imported++
+	}
+	fmt.Fprintf(r.Out, "Imported %d exchange rates.\n", imported)
+	return nil
+}
@@ -3,9 +3,11 @@ package budgeter
 import (
 	_ "embed"
 	"fmt"
-	"time"
+	"io"
+	"strings"
 
 	"github.com/Anthony-Fiddes/budgeter/internal/month"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
 	"github.com/cheynewallace/tabby"
 )
 
@@ -13,11 +15,18 @@ type recent struct {
 	limit        int
 	search       string
 	flip         bool
+	status       string
+	Attachments  AttachmentTable
+	Out          io.Writer
+	Rates        RateTable
 	Transactions Table
 }
 
 func newRecent(c *CLI) *recent {
 	result := recent{}
+	result.Attachments = c.Attachments
+	result.Out = c.Out
+	result.Rates = c.Rates
 	result.Transactions = c.Transactions
 	return &result
 }
@@ -37,22 +46,16 @@ func (r recent) Usage() string {
 // TODO: Add a "pinned" feature/subcommand?
 // TODO: Add a total for searches
 func (r recent) Run(cmdArgs []string) error {
-	const (
-		// defaultRecentLimit specifies the default number of items to receive when
-		// the recent command is called
-		defaultRecentLimit = 20
-		idHeader           = "ID"
-		dateHeader         = "Date"
-		entityHeader       = "Entity"
-		amountHeader       = "Amount"
-		noteHeader         = "Note"
-	)
+	// defaultRecentLimit specifies the default number of items to receive when
+	// the recent command is called
+	const defaultRecentLimit = 20
 
 	var err error
 	fs := getFlagset(r.Name())
 	fs.StringVar(&r.search, "s", "", "")
 	fs.BoolVar(&r.flip, "f", false, "")
 	fs.IntVar(&r.limit, "l", defaultRecentLimit, "")
+	fs.StringVar(&r.status, "status", "", "")
 	if err := fs.Parse(cmdArgs); err != nil {
 		return err
 	}
@@ -62,7 +65,16 @@ func (r recent) Run(cmdArgs []string) error {
 		return fmt.Errorf("%s takes no arguments", r.Name())
 	}
 
-	rows, err := r.Transactions.Search(r.search, r.limit)
+	statuses, err := transaction.ParseStatuses(r.status)
+	if err != nil {
+		return err
+	}
+	limit := r.limit
+	if len(statuses) > 0 {
+		// The transactions are filtered by status after they're found.
+		limit = -1
+	}
+	rows, err := r.Transactions.Search(r.search, limit)
 	if err != nil {
 		return err
 	}
@@ -70,38 +82,108 @@ func (r recent) Run(cmdArgs []string) error {
 	if err != nil {
 		return err
 	}
+	if len(statuses) > 0 {
+		transactions = withStatus(transactions, statuses, r.limit)
+	}
 
-	tab := tabby.New()
-	tab.AddHeader(idHeader, dateHeader, entityHeader, amountHeader, noteHeader)
-	for i := 0; i < len(transactions); i++ {
-		index := i
-		if !r.flip {
-			index = len(transactions) - 1 - index
+	if !r.flip {
+		// The most recent transactions are printed last, just above the total.
+		for i, j := 0, len(transactions)-1; i < j; i, j = i+1, j-1 {
+			transactions[i], transactions[j] = transactions[j], transactions[i]
 		}
-		tx := transactions[index]
-		// Align all the amount cells
-		amount := tx.Amount.String()
-		if tx.Amount >= 0 {
-			amount = " " + amount
-		}
-		tab.AddLine(tx.ID, tx.DateString(), tx.Entity, amount, tx.Note)
 	}
-	tab.Print()
+	attached, err := r.Attachments.Counts()
+	if err != nil {
+		return err
+	}
+	printTransactions(r.Out, transactions, attached)
 
 	if r.search == "" {
 		// TODO: make this configurable with limit subcommand
 		// TODO: maybe add a test for this since it was buggy before?
-		now := time.Now().UTC()
-		monthTotal, err := r.Transactions.RangeTotal(month.Start(now), now)
+		current := now()
+		monthTotals, err := r.Transactions.RangeTotals(month.Start(current), current, excluded(statuses)...)
 		if err != nil {
 			return err
 		}
-		totalStr := fmt.Sprintf("Current Month: %s", monthTotal)
-		for i := 0; i < len(totalStr); i++ {
-			fmt.Print("=")
+		monthTotal, err := totalToHome(r.Rates, monthTotals, current)
+		if err != nil {
+			return err
 		}
-		fmt.Println()
-		fmt.Println(totalStr)
+		totalStr := fmt.Sprintf("Current Month: %s", monthTotal)
+		fmt.Fprintln(r.Out, strings.Repeat("=", len(totalStr)))
+		fmt.Fprintln(r.Out, totalStr)
 	}
 	return nil
 }
+
+// printTransactions prints a table of transactions in the order they're given.
+// If "attached" isn't nil, it maps transaction IDs to their number of
+// attachments, and a column shows them.
+func printTransactions(w io.Writer, transactions []transaction.Transaction, attached map[int]int) {
+	const (
+		idHeader       = "ID"
+		dateHeader     = "Date"
+		entityHeader   = "Entity"
+		amountHeader   = "Amount"
+		noteHeader     = "Note"
+		categoryHeader = "Category"
+		statusHeader   = "Status"
+		filesHeader    = "Files"
+	)
+	tab := tabby.NewCustom(newTabWriter(w))
+	headers := []interface{}{idHeader, dateHeader, entityHeader, amountHeader, noteHeader, categoryHeader, statusHeader}
+	if attached != nil {
+		headers = append(headers, filesHeader)
+	}
+	tab.AddHeader(headers...)
+	for _, tx := range transactions {
+		line := []interface{}{tx.ID, tx.DateString(), tx.Entity, alignTxAmount(tx), tx.Note, tx.Category, tx.Status}
+		if attached != nil {
+			files := ""
+			if n := attached[tx.ID]; n > 0 {
+				files = fmt.Sprintf("%d", n)
+			}
+			line = append(line, files)
+		}
+		tab.AddLine(line...)
+	}
+	tab.Print()
+}
+
+// withStatus returns up to "limit" of the transactions that have one of the
+// given statuses. A negative "limit" returns all of them.
+func withStatus(transactions []transaction.Transaction, statuses []transaction.Status, limit int) []transaction.Transaction {
+	var result []transaction.Transaction
+	for _, tx := range transactions {
+		if limit >= 0 && len(result) == limit {
+			break
+		}
+		for _, s := range statuses {
+			if tx.Status == s {
+				result = append(result, tx)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// excluded returns the statuses that aren't in "included". If "included" is
+// empty, nothing is excluded.
+func excluded(included []transaction.Status) []transaction.Status {
+	if len(included) == 0 {
+		return nil
+	}
+	var result []transaction.Status
+	for _, s := range transaction.Statuses() {
+		found := false
+		for _, i := range included {
+			found = found || i == s
+		}
+		if !found {
+			result = append(result, s)
+		}
+	}
+	return result
+}
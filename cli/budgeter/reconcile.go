@@ -0,0 +1,277 @@
+package budgeter
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/internal/inpt"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+type reconcile struct {
+	currency     string
+	in           *inpt.Scanner
+	Out          io.Writer
+	Transactions Table
+}
+
+func newReconcile(c *CLI) *reconcile {
+	result := &reconcile{}
+	result.in = c.in
+	result.Out = c.Out
+	result.Transactions = c.Transactions
+	return result
+}
+
+func (r reconcile) Name() string {
+	return "reconcile"
+}
+
+//go:embed reconcileUsage.txt
+var reconcileUsage string
+
+func (r reconcile) Usage() string {
+	return reconcileUsage
+}
+
+// reconcile matches an account's transactions against a bank statement. The
+// user ticks off the transactions on the statement until the account's
+// balance matches the statement's, and then they're marked reconciled.
+func (r reconcile) Run(cmdArgs []string) error {
+	const (
+		all  = "a"
+		quit = "q"
+	)
+
+	fs := getFlagset(r.Name())
+	fs.StringVar(&r.currency, "currency", "", "")
+	if err := fs.Parse(cmdArgs); err != nil {
+		return err
+	}
+	args := fs.Args()
+	if len(args) != 3 {
+		return fmt.Errorf("%s takes three arguments", r.Name())
+	}
+	account := args[0]
+	date, err := transaction.Unix(args[1])
+	if err != nil {
+		return err
+	}
+	balance, code, err := parseAmount(args[2], r.currency)
+	if err != nil {
+		return err
+	}
+
+	reconciled, open, err := r.load(account, code, time.Unix(date, 0).UTC())
+	if err != nil {
+		return err
+	}
+	if len(open) == 0 {
+		fmt.Fprintf(r.Out, "%s has no unreconciled transactions in %s on or before %s.\n", account, code, args[1])
+		return nil
+	}
+
+	ticked := make([]bool, len(open))
+	fmt.Fprintf(
+		r.Out,
+		"Enter the numbers of the transactions on the statement to tick them off, e.g. \"1 3 5-7\".\n"+
+			"Enter \"%s\" to tick or untick all of them, or \"%s\" to stop without reconciling.\n",
+		all, quit,
+	)
+	for {
+		cleared := reconciled
+		for i, tx := range open {
+			if !ticked[i] {
+				continue
+			}
+			if cleared, err = cleared.Add(tx.Amount); err != nil {
+				return err
+			}
+		}
+		difference, err := balance.Sub(cleared)
+		if err != nil {
+			return err
+		}
+		r.print(open, ticked)
+		cur := open[0].CurrencyInfo()
+		fmt.Fprintf(
+			r.Out, "\nStatement balance: %s  Cleared balance: %s  Difference: %s\n",
+			balance.Format(cur), cleared.Format(cur), difference.Format(cur),
+		)
+
+		if difference == 0 && count(ticked) > 0 {
+			n := count(ticked)
+			fmt.Fprintf(r.Out, "The balances match. Reconcile %d transactions? (y/[n]) ", n)
+			ok, err := r.in.Confirm()
+			if err != nil {
+				return err
+			}
+			if ok {
+				var ids []int
+				for i, tx := range open {
+					if ticked[i] {
+						ids = append(ids, tx.ID)
+					}
+				}
+				if err := r.Transactions.SetStatusAll(ids, transaction.Reconciled); err != nil {
+					return fmt.Errorf("could not reconcile the transactions: %w", err)
+				}
+				fmt.Fprintf(r.Out, "Reconciled %d transactions.\n", n)
+				return nil
+			}
+		}
+
+		fmt.Fprint(r.Out, "Tick: ")
+		if !r.in.Scan() {
+			if err := r.in.Err(); err != nil {
+				return err
+			}
+			return errors.New("stopped before the balances matched, so nothing was reconciled")
+		}
+		response := strings.TrimSpace(r.in.Text())
+		switch strings.ToLower(response) {
+		case quit:
+			fmt.Fprintln(r.Out, "Nothing was reconciled.")
+			return nil
+		case all:
+			tick := count(ticked) < len(ticked)
+			for i := range ticked {
+				ticked[i] = tick
+			}
+		default:
+			numbers, err := parseNumbers(response, len(open))
+			if err != nil {
+				fmt.Fprintln(r.Out, err)
+				continue
+			}
+			for _, n := range numbers {
+				ticked[n-1] = !ticked[n-1]
+			}
+		}
+	}
+}
+
+// load returns the total of the account's reconciled transactions in the
+// currency with the given code, and its transactions that can still be
+// reconciled on or before the statement date.
+func (r reconcile) load(account, code string, date time.Time) (transaction.Cent, []transaction.Transaction, error) {
+	rows, err := r.Transactions.Range(time.Time{}, date, -1)
+	if err != nil {
+		return 0, nil, err
+	}
+	transactions, err := rows.ScanSet()
+	if err != nil {
+		return 0, nil, err
+	}
+	var reconciled transaction.Cent
+	var open []transaction.Transaction
+	for _, tx := range transactions {
+		if !strings.EqualFold(tx.Account, account) || tx.CurrencyInfo().Code != code {
+			continue
+		}
+		if tx.Status == transaction.Reconciled {
+			if reconciled, err = reconciled.Add(tx.Amount); err != nil {
+				return 0, nil, err
+			}
+			continue
+		}
+		// Pending transactions haven't gone through the bank yet, so they
+		// can't be on the statement.
+		if tx.Status == transaction.Cleared {
+			open = append(open, tx)
+		}
+	}
+	return reconciled, open, nil
+}
+
+// print lists the transactions that can be reconciled, with the ticked ones
+// checked.
+func (r reconcile) print(open []transaction.Transaction, ticked []bool) {
+	w := newTabWriter(r.Out)
+	fmt.Fprintln(w)
+	for i, tx := range open {
+		box := "[ ]"
+		if ticked[i] {
+			box = "[x]"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\n", box, i+1, tx.DateString(), tx.Entity, alignTxAmount(tx), tx.Note)
+	}
+	w.Flush()
+}
+
+// count returns how many of "ticked" are true.
+func count(ticked []bool) int {
+	result := 0
+	for _, t := range ticked {
+		if t {
+			result++
+		}
+	}
+	return result
+}
+
+// parseNumbers reads a list of numbers and ranges of numbers like "1 3 5-7"
+// that are separated by spaces or commas. Every number must be between 1 and
+// "max".
+func parseNumbers(s string, max int) ([]int, error) {
+	ranges, err := parseRanges(s)
+	if err != nil {
+		return nil, err
+	}
+	var result []int
+	for _, r := range ranges {
+		if r.first < 1 || r.last > max {
+			return nil, fmt.Errorf("\"%s\" isn't between 1 and %d", r, max)
+		}
+		for n := r.first; n <= r.last; n++ {
+			result = append(result, n)
+		}
+	}
+	return result, nil
+}
+
+// numberRange is a range of numbers, including "first" and "last".
+type numberRange struct{ first, last int }
+
+func (r numberRange) contains(n int) bool {
+	return r.first <= n && n <= r.last
+}
+
+func (r numberRange) String() string {
+	if r.first == r.last {
+		return strconv.Itoa(r.first)
+	}
+	return fmt.Sprintf("%d-%d", r.first, r.last)
+}
+
+// parseRanges reads a list of numbers and ranges of numbers like "1 3 5-7"
+// that are separated by spaces or commas. A single number is a range that
+// only includes itself.
+func parseRanges(s string) ([]numberRange, error) {
+	var result []numberRange
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' })
+	for _, field := range fields {
+		first, last := field, field
+		if i := strings.Index(field, "-"); i > 0 {
+			first, last = field[:i], field[i+1:]
+		}
+		start, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, fmt.Errorf("\"%s\" isn't a number or a range like 5-7", field)
+		}
+		end, err := strconv.Atoi(last)
+		if err != nil {
+			return nil, fmt.Errorf("\"%s\" isn't a number or a range like 5-7", field)
+		}
+		if start > end {
+			return nil, fmt.Errorf("\"%s\" ends before it starts", field)
+		}
+		result = append(result, numberRange{start, end})
+	}
+	return result, nil
+}
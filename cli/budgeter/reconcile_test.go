@@ -0,0 +1,120 @@
+package budgeter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Anthony-Fiddes/budgeter/internal/inpt"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+func TestReconcile(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		// reconciled is whether the open transactions are reconciled.
+		reconciled bool
+	}{
+		{name: "matched", input: "a\ny\n", reconciled: true},
+		{name: "declined", input: "a\nn\nq\n"},
+		{name: "one missing", input: "1\nq\n"},
+		{name: "stopped", input: "1 2\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			table := &transaction.Table{DB: newTestDB(t)}
+			if err := table.Init(); err != nil {
+				t.Fatal(err)
+			}
+			txs := []transaction.Transaction{
+				{Date: 1609459200, Entity: "Opening", Amount: 10000, Account: "Checking", Status: transaction.Reconciled},
+				{Date: 1609891200, Entity: "Grocer", Amount: -2000, Account: "Checking"},
+				{Date: 1609977600, Entity: "Cafe", Amount: -500, Account: "checking"},
+				// Neither of these can be on the statement.
+				{Date: 1609977600, Entity: "Hotel", Amount: -10000, Account: "Checking", Status: transaction.Pending},
+				{Date: 1609977600, Entity: "Grocer", Amount: -2000, Account: "Savings"},
+			}
+			var ids []int
+			for _, tx := range txs {
+				id, err := table.Insert(tx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids = append(ids, id)
+			}
+
+			var out strings.Builder
+			r := reconcile{in: inpt.NewScanner(strings.NewReader(test.input)), Out: &out, Transactions: table}
+			err := r.Run([]string{"Checking", "1/31/2021", "75.00"})
+			if test.name == "stopped" {
+				if err == nil {
+					t.Error("expected an error when the input ran out")
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if test.reconciled && !strings.Contains(out.String(), "Reconciled 2 transactions.") {
+				t.Errorf("expected two transactions to be reconciled but got:\n%s", out.String())
+			}
+
+			want := []transaction.Status{
+				transaction.Reconciled, transaction.Cleared, transaction.Cleared, transaction.Pending, transaction.Cleared,
+			}
+			if test.reconciled {
+				want[1], want[2] = transaction.Reconciled, transaction.Reconciled
+			}
+			for i, id := range ids {
+				tx, err := table.Get(id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tx.Status != want[i] {
+					t.Errorf("expected %s at %s to be %s but it's %s", tx.Entity, tx.Account, want[i], tx.Status)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRanges(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []numberRange
+		err   bool
+	}{
+		{input: ""},
+		{input: "4", want: []numberRange{{4, 4}}},
+		{input: "1 3 5-7", want: []numberRange{{1, 1}, {3, 3}, {5, 7}}},
+		{input: "1,2, 3", want: []numberRange{{1, 1}, {2, 2}, {3, 3}}},
+		// Overlapping ranges are kept as they are.
+		{input: "2-6 4-9 5", want: []numberRange{{2, 6}, {4, 9}, {5, 5}}},
+		{input: "5-5", want: []numberRange{{5, 5}}},
+		{input: "7-5", err: true},
+		{input: "5-", err: true},
+		{input: "1-2-3", err: true},
+		{input: "a", err: true},
+	}
+	for _, test := range tests {
+		got, err := parseRanges(test.input)
+		if test.err {
+			if err == nil {
+				t.Errorf("parseRanges(%q): expected an error but got %v", test.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseRanges(%q): %v", test.input, err)
+			continue
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("parseRanges(%q) = %v, want %v", test.input, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("parseRanges(%q) = %v, want %v", test.input, got, test.want)
+				break
+			}
+		}
+	}
+}
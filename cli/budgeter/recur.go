@@ -0,0 +1,254 @@
+package budgeter
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/Anthony-Fiddes/budgeter/internal/inpt"
+	"github.com/Anthony-Fiddes/budgeter/model/recurring"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+	"github.com/cheynewallace/tabby"
+)
+
+type RecurTable interface {
+	All() ([]recurring.Rule, error)
+	Insert(recurring.Rule) error
+	Remove(ruleID int) error
+	SetPosted(ruleID int, date int64) error
+}
+
+// postRecurring inserts every occurrence of c's recurring rules that is due
+// into c's transactions table.
+//
+// An occurrence that is already in the transactions table is considered
+// posted, so running postRecurring again never posts a transaction twice.
+func postRecurring(c *CLI) error {
+	rules, err := c.Recurring.All()
+	if err != nil {
+		return err
+	}
+	for _, r := range rules {
+		for _, date := range r.Due(today()) {
+			tx := r.Transaction(date)
+			_, insertErr := c.Transactions.Insert(tx)
+			if insertErr != nil && !errors.Is(insertErr, transaction.ErrDuplicate) {
+				return insertErr
+			}
+			if err := c.Recurring.SetPosted(r.ID, tx.Date); err != nil {
+				return err
+			}
+			if insertErr == nil {
+				fmt.Fprintf(c.Out, "Posted recurring transaction: %s %s %s\n", tx.DateString(), tx.Entity, tx.AmountString())
+			}
+		}
+	}
+	return nil
+}
+
+type recur struct {
+	upcoming  int
+	in        *inpt.Scanner
+	Out       io.Writer
+	Rates     RateTable
+	Recurring RecurTable
+}
+
+func newRecur(c *CLI) *recur {
+	result := &recur{}
+	result.in = c.in
+	result.Out = c.Out
+	result.Rates = c.Rates
+	result.Recurring = c.Recurring
+	return result
+}
+
+func (r recur) Name() string {
+	return "recur"
+}
+
+//go:embed recurUsage.txt
+var recurUsage string
+
+func (r recur) Usage() string {
+	return recurUsage
+}
+
+func (r recur) Run(cmdArgs []string) error {
+	fs := getFlagset(r.Name())
+	fs.IntVar(&r.upcoming, "upcoming", 0, "")
+	if err := fs.Parse(cmdArgs); err != nil {
+		return err
+	}
+	args := fs.Args()
+	if r.upcoming < 0 {
+		return fmt.Errorf("-upcoming must be a positive number of days")
+	}
+	if r.upcoming > 0 {
+		if len(args) > 0 {
+			return fmt.Errorf("%s -upcoming takes no arguments", r.Name())
+		}
+		return r.listUpcoming()
+	}
+	if len(args) == 0 {
+		return r.list()
+	}
+
+	switch args[0] {
+	case "add":
+		if len(args) != 1 {
+			return fmt.Errorf("%s add takes no arguments", r.Name())
+		}
+		return r.add()
+	case "remove":
+		if len(args) != 2 {
+			return fmt.Errorf("%s remove takes one argument", r.Name())
+		}
+		ruleID, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf(
+				"%s remove takes a numerical ID. try `budgeter %s` to see some IDs.",
+				r.Name(),
+				r.Name(),
+			)
+		}
+		if err := r.Recurring.Remove(ruleID); err != nil {
+			return fmt.Errorf("could not remove recurring transaction #%d: %v", ruleID, err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%s has no subcommand \"%s\"", r.Name(), args[0])
+	}
+}
+
+// list prints all of the recurring rules.
+func (r recur) list() error {
+	rules, err := r.Recurring.All()
+	if err != nil {
+		return err
+	}
+	tab := tabby.NewCustom(newTabWriter(r.Out))
+	tab.AddHeader("ID", "Entity", "Amount", "Schedule", "Start", "End", "Next")
+	for _, rule := range rules {
+		end := ""
+		if rule.End != 0 {
+			end = transaction.Transaction{Date: rule.End}.DateString()
+		}
+		next := ""
+		// The transaction for today has already been posted, so the next one
+		// will be tomorrow at the earliest.
+		upcoming := rule.Occurrences(today().AddDate(0, 0, 1), today().AddDate(1, 0, 0))
+		if len(upcoming) > 0 {
+			next = upcoming[0].Format(transaction.DateLayout)
+		}
+		tab.AddLine(
+			rule.ID,
+			rule.Entity,
+			alignTxAmount(rule.Transaction(today())),
+			rule.Schedule,
+			transaction.Transaction{Date: rule.Start}.DateString(),
+			end,
+			next,
+		)
+	}
+	tab.Print()
+	return nil
+}
+
+// listUpcoming prints the occurrences that will be posted in the next
+// r.upcoming days, and their total in the home currency.
+func (r recur) listUpcoming() error {
+	rules, err := r.Recurring.All()
+	if err != nil {
+		return err
+	}
+	var txs []transaction.Transaction
+	from := today().AddDate(0, 0, 1)
+	to := today().AddDate(0, 0, r.upcoming)
+	for _, rule := range rules {
+		for _, date := range rule.Occurrences(from, to) {
+			txs = append(txs, rule.Transaction(date))
+		}
+	}
+	sort.SliceStable(txs, func(i, j int) bool { return txs[i].Date < txs[j].Date })
+
+	tab := tabby.NewCustom(newTabWriter(r.Out))
+	tab.AddHeader("Date", "Entity", "Amount", "Note")
+	var total transaction.Cent
+	for _, tx := range txs {
+		tab.AddLine(tx.DateString(), tx.Entity, alignTxAmount(tx), tx.Note)
+		// The rates for future dates aren't known yet, so the latest ones are
+		// used.
+		amount, err := toHome(r.Rates, tx.Amount, tx.Currency, today())
+		if err != nil {
+			return err
+		}
+		if total, err = total.Add(amount); err != nil {
+			return err
+		}
+	}
+	tab.Print()
+	fmt.Fprintf(r.Out, "Total for the next %d days: %s\n", r.upcoming, total)
+	return nil
+}
+
+// add interactively adds a new recurring rule.
+func (r recur) add() error {
+	var err error
+	rule := recurring.Rule{}
+	rule.Entity, err = prompt(r.Out, r.in, transaction.EntityCol, "")
+	if err != nil {
+		return err
+	}
+	code, err := prompt(r.Out, r.in, recurring.CurrencyCol, transaction.Home().Code)
+	if err != nil {
+		return err
+	}
+	amount, err := prompt(r.Out, r.in, transaction.AmountCol, "")
+	if err != nil {
+		return err
+	}
+	rule.Amount, rule.Currency, err = parseAmount(amount, code)
+	if err != nil {
+		return err
+	}
+	rule.Note, err = prompt(r.Out, r.in, transaction.NoteCol, "")
+	if err != nil {
+		return err
+	}
+	schedule, err := prompt(r.Out, r.in, recurring.ScheduleCol, "monthly")
+	if err != nil {
+		return err
+	}
+	rule.Schedule, err = recurring.ParseSchedule(schedule)
+	if err != nil {
+		return err
+	}
+	start, err := prompt(r.Out, r.in, "Start", today().Format(transaction.DateLayout))
+	if err != nil {
+		return err
+	}
+	rule.Start, err = transaction.Unix(start)
+	if err != nil {
+		return err
+	}
+	end, err := prompt(r.Out, r.in, "End", "never")
+	if err != nil {
+		return err
+	}
+	if end != "never" {
+		rule.End, err = transaction.Unix(end)
+		if err != nil {
+			return err
+		}
+		if rule.End < rule.Start {
+			return fmt.Errorf("a recurring transaction can't end before it starts")
+		}
+	}
+	// Occurrences before today are posted the next time budgeter runs, so the
+	// user can backfill a rule by giving it an earlier start date.
+	return r.Recurring.Insert(rule)
+}
@@ -0,0 +1,44 @@
+package budgeter
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+)
+
+type redo struct {
+	Journal JournalTable
+	Out     io.Writer
+}
+
+func newRedo(c *CLI) *redo {
+	return &redo{Journal: c.Journal, Out: c.Out}
+}
+
+func (r redo) Name() string {
+	return "redo"
+}
+
+//go:embed redoUsage.txt
+var redoUsage string
+
+func (r redo) Usage() string {
+	return redoUsage
+}
+
+// redo makes the changes of the most recently undone operation again.
+func (r redo) Run(cmdArgs []string) error {
+	fs := getFlagset(r.Name())
+	if err := fs.Parse(cmdArgs); err != nil {
+		return err
+	}
+	if len(fs.Args()) > 0 {
+		return fmt.Errorf("%s takes no arguments", r.Name())
+	}
+	op, err := r.Journal.Redo()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(r.Out, "Redid %s.\n", describeOperation(op))
+	return nil
+}
@@ -2,17 +2,28 @@ package budgeter
 
 import (
 	"fmt"
-	"strconv"
+	"io"
+	"sort"
+	"strings"
 
 	_ "embed"
+
+	"github.com/Anthony-Fiddes/budgeter/internal/inpt"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
 )
 
 type remove struct {
+	confirmed    bool
+	search       string
+	from         string
+	to           string
+	in           *inpt.Scanner
+	Out          io.Writer
 	Transactions Table
 }
 
 func newRemove(c *CLI) *remove {
-	return &remove{Transactions: c.Transactions}
+	return &remove{in: c.in, Out: c.Out, Transactions: c.Transactions}
 }
 
 func (r remove) Name() string {
@@ -26,26 +37,138 @@ func (r remove) Usage() string {
 	return removeUsage
 }
 
+// remove moves the transactions with the given IDs, or that match a search or
+// range of dates, to the trash after showing them and asking for
+// confirmation.
 func (r remove) Run(cmdArgs []string) error {
 	fs := getFlagset(r.Name())
-	if err := fs.Parse(cmdArgs); err != nil {
+	fs.BoolVar(&r.confirmed, "y", false, "")
+	fs.StringVar(&r.search, "q", "", "")
+	fs.StringVar(&r.from, "from", "", "")
+	fs.StringVar(&r.to, "to", "", "")
+	// Flags can come after the IDs too, e.g. `remove 12 -y`.
+	args, err := parseInterspersed(fs, cmdArgs)
+	if err != nil {
 		return err
 	}
-	args := fs.Args()
-	if len(args) != 1 {
-		return fmt.Errorf("%s takes one argument", r.Name())
+	if len(args) == 0 && r.search == "" && r.from == "" && r.to == "" {
+		return fmt.Errorf("%s takes IDs, a search or a range of dates", r.Name())
 	}
-	txID, err := strconv.Atoi(args[0])
+	ids, err := parseRanges(strings.Join(args, " "))
 	if err != nil {
-		return fmt.Errorf(
-			"%s takes a numerical ID. try `budgeter %s` to see some IDs.",
-			r.Name(),
-			recent{}.Name(),
-		)
+		return fmt.Errorf("%s takes IDs and ranges of IDs: %w", r.Name(), err)
 	}
-	err = r.Transactions.Remove(txID)
+
+	matched, err := r.match(ids)
 	if err != nil {
-		return fmt.Errorf("could not remove transaction #%d: %v", txID, err)
+		return err
+	}
+	// Reconciled transactions can't be removed, so they're left out.
+	var locked []int
+	removable := matched[:0]
+	for _, tx := range matched {
+		if tx.Status == transaction.Reconciled {
+			locked = append(locked, tx.ID)
+			continue
+		}
+		removable = append(removable, tx)
+	}
+	matched = removable
+	if len(matched) == 0 && len(locked) > 0 {
+		fmt.Fprintln(r.Out, "No transactions can be removed.")
+		printLocked(r.Out, locked)
+		return nil
+	}
+	if len(matched) == 0 {
+		fmt.Fprintln(r.Out, "No transactions matched.")
+		return nil
+	}
+	if !r.confirmed {
+		printTransactions(r.Out, matched, nil)
+		printLocked(r.Out, locked)
+		fmt.Fprintf(r.Out, "Move these %d transactions to the trash? (y/[n]) ", len(matched))
+		ok, err := r.in.Confirm()
+		if err != nil || !ok {
+			return err
+		}
+	}
+	matchedIDs := make([]int, len(matched))
+	for i, tx := range matched {
+		matchedIDs[i] = tx.ID
+	}
+	if err := r.Transactions.RemoveAll(matchedIDs); err != nil {
+		return fmt.Errorf("could not remove the transactions, so none were removed: %w", err)
+	}
+	fmt.Fprintf(r.Out, "Moved %d transactions to the trash. See `budgeter %s` to restore them.\n", len(matched), trash{}.Name())
+	if r.confirmed {
+		printLocked(r.Out, locked)
 	}
 	return nil
 }
+
+// match returns the transactions that match the search, the range of dates
+// and, if there are any, the ranges of IDs, oldest first. It prints the IDs
+// that were asked for on their own but don't exist.
+func (r remove) match(ids []numberRange) ([]transaction.Transaction, error) {
+	var from, to int64
+	var err error
+	if r.from != "" {
+		if from, err = transaction.Unix(r.from); err != nil {
+			return nil, err
+		}
+	}
+	if r.to != "" {
+		if to, err = transaction.Unix(r.to); err != nil {
+			return nil, err
+		}
+	}
+	rows, err := r.Transactions.Search(r.search, -1)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := rows.ScanSet()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []transaction.Transaction
+	found := make(map[int]bool)
+	for _, tx := range candidates {
+		found[tx.ID] = true
+		if r.from != "" && tx.Date < from || r.to != "" && tx.Date > to {
+			continue
+		}
+		if len(ids) > 0 && !anyContains(ids, tx.ID) {
+			continue
+		}
+		result = append(result, tx)
+	}
+	if r.search == "" {
+		var missing []string
+		for _, id := range ids {
+			if id.first == id.last && !found[id.first] {
+				missing = append(missing, "#"+id.String())
+			}
+		}
+		if len(missing) > 0 {
+			fmt.Fprintf(r.Out, "There's no transaction %s.\n", strings.Join(missing, ", "))
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Date != result[j].Date {
+			return result[i].Date < result[j].Date
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+// anyContains returns whether any of the ranges contain "n".
+func anyContains(ranges []numberRange, n int) bool {
+	for _, r := range ranges {
+		if r.contains(n) {
+			return true
+		}
+	}
+	return false
+}
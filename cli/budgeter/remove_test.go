@@ -0,0 +1,140 @@
+package budgeter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Anthony-Fiddes/budgeter/internal/inpt"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+// newTestRemove returns a remove command backed by an in-memory database with
+// the given transactions in it. Their IDs start at 1 in the order given.
+func newTestRemove(t *testing.T, input string, transactions ...transaction.Transaction) (remove, *strings.Builder) {
+	t.Helper()
+	table := &transaction.Table{DB: newTestDB(t)}
+	if err := table.Init(); err != nil {
+		t.Fatal(err)
+	}
+	for _, tx := range transactions {
+		if _, err := table.Insert(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out := &strings.Builder{}
+	return remove{in: inpt.NewScanner(strings.NewReader(input)), Out: out, Transactions: table}, out
+}
+
+func TestRemoveMatch(t *testing.T) {
+	transactions := []transaction.Transaction{
+		{Date: date(t, "1/3/2021"), Entity: "Lyft", Amount: -1368},
+		{Date: date(t, "1/1/2021"), Entity: "Kroger", Amount: -1212},
+		{Date: date(t, "1/2/2021"), Entity: "Lyft", Amount: -900, Status: transaction.Reconciled},
+		{Date: date(t, "1/2/2021"), Entity: "Cafe", Amount: -500, Note: "Lyft driver's tip"},
+		{Date: date(t, "1/5/2021"), Entity: "Paycheck", Amount: 100000},
+	}
+	tests := []struct {
+		name   string
+		search string
+		from   string
+		to     string
+		ids    string
+		want   []int
+	}{
+		{name: "IDs", ids: "5 1", want: []int{1, 5}},
+		{name: "oldest first", ids: "1-4", want: []int{2, 3, 4, 1}},
+		{name: "overlapping ranges", ids: "1-3 2-4 3", want: []int{2, 3, 4, 1}},
+		{name: "missing IDs", ids: "4 9 20-30", want: []int{4}},
+		{name: "search", search: "lyft", want: []int{3, 4, 1}},
+		{name: "search and IDs", search: "Lyft", ids: "1-3", want: []int{3, 1}},
+		{name: "dates", from: "1/2/2021", to: "1/3/2021", want: []int{3, 4, 1}},
+		{name: "dates and IDs", from: "1/2/2021", ids: "1 2 5", want: []int{1, 5}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, _ := newTestRemove(t, "", transactions...)
+			r.search, r.from, r.to = test.search, test.from, test.to
+			ids, err := parseRanges(test.ids)
+			if err != nil {
+				t.Fatal(err)
+			}
+			matched, err := r.match(ids)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []int
+			for _, tx := range matched {
+				got = append(got, tx.ID)
+			}
+			if len(got) != len(test.want) {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Fatalf("got %v, want %v", got, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRemoveRun(t *testing.T) {
+	transactions := []transaction.Transaction{
+		{Date: date(t, "1/1/2021"), Entity: "Kroger", Amount: -1212},
+		{Date: date(t, "1/2/2021"), Entity: "Lyft", Amount: -900, Status: transaction.Reconciled},
+		{Date: date(t, "1/3/2021"), Entity: "Lyft", Amount: -1368},
+	}
+	tests := []struct {
+		name  string
+		args  []string
+		input string
+		// removed are the IDs that are expected to be in the trash.
+		removed []int
+		out     string
+		err     bool
+	}{
+		{name: "flags after IDs", args: []string{"1", "3", "-y"}, removed: []int{1, 3}},
+		{name: "flags around IDs", args: []string{"-q", "Lyft", "1-3", "-y"}, removed: []int{3}},
+		{
+			name:    "reconciled",
+			args:    []string{"1-3", "-y"},
+			removed: []int{1, 3},
+			out:     "Skipped 1 reconciled transactions: #2",
+		},
+		{name: "only reconciled", args: []string{"2", "-y"}, out: "No transactions can be removed."},
+		{name: "declined", args: []string{"1"}, input: "n\n"},
+		{name: "confirmed", args: []string{"1"}, input: "y\n", removed: []int{1}},
+		{name: "reversed range", args: []string{"3-1", "-y"}, err: true},
+		{name: "no arguments", args: []string{"-y"}, err: true},
+		{name: "IDs after --", args: []string{"-y", "--", "1"}, removed: []int{1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, out := newTestRemove(t, test.input, transactions...)
+			err := r.Run(test.args)
+			if test.err {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(out.String(), test.out) {
+				t.Errorf("expected %q in the output but got:\n%s", test.out, out.String())
+			}
+			removed := make(map[int]bool)
+			for _, id := range test.removed {
+				removed[id] = true
+			}
+			for id := 1; id <= len(transactions); id++ {
+				_, err := r.Transactions.Get(id)
+				if removed[id] && err == nil {
+					t.Errorf("expected #%d to be removed", id)
+				} else if !removed[id] && err != nil {
+					t.Errorf("expected #%d to be kept but got %v", id, err)
+				}
+			}
+		})
+	}
+}
@@ -4,7 +4,8 @@ import (
 	"bytes"
 	_ "embed"
 	"fmt"
-	"text/tabwriter"
+	"io"
+	"sort"
 	"time"
 
 	"github.com/Anthony-Fiddes/budgeter/internal/month"
@@ -12,22 +13,70 @@ import (
 	"github.com/cheynewallace/tabby"
 )
 
-const reportName = "report"
+// defaultReportMonths determines how many months to query for when calling
+// the report command.
+const defaultReportMonths = 6
+
+type report struct {
+	payees       bool
+	year         int
+	taxTags      string
+	output       string
+	Attachments  AttachmentTable
+	Config       Store
+	Err          io.Writer
+	Out          io.Writer
+	Payees       PayeeTable
+	Rates        RateTable
+	Transactions Table
+}
+
+func newReport(c *CLI) *report {
+	result := &report{}
+	result.Attachments = c.Attachments
+	result.Config = c.Config
+	result.Err = c.Err
+	result.Out = c.Out
+	result.Payees = c.Payees
+	result.Rates = c.Rates
+	result.Transactions = c.Transactions
+	return result
+}
+
+func (r report) Name() string {
+	return "report"
+}
 
 //go:embed reportUsage.txt
 var reportUsage string
 
+func (r report) Usage() string {
+	return reportUsage
+}
+
 // report tells the user how much they've spent over the last few months.
-func report(c *CLI) int {
-	// defaultReportMonths determines how many months to query for when calling
-	// the report command.
-	const defaultReportMonths = 6
-
-	if len(c.args) != 0 {
-		c.err.Printf("%s takes no arguments", reportName)
-		c.err.Println()
-		c.err.Println(reportUsage)
-		return 1
+func (r report) Run(cmdArgs []string) error {
+	fs := getFlagset(r.Name())
+	fs.BoolVar(&r.payees, "payees", false, "")
+	if err := fs.Parse(cmdArgs); err != nil {
+		return err
+	}
+	args := fs.Args()
+	if len(args) > 0 {
+		switch args[0] {
+		case "cashflow":
+			if len(args) != 1 {
+				return fmt.Errorf("%s cashflow takes no arguments", r.Name())
+			}
+			return r.cashFlow()
+		case "tax":
+			return r.tax(args[1:])
+		default:
+			return fmt.Errorf("%s has no subcommand \"%s\"", r.Name(), args[0])
+		}
+	}
+	if r.payees {
+		return r.payeeTotals()
 	}
 
 	type total struct {
@@ -37,40 +86,136 @@ func report(c *CLI) int {
 
 	sPrintTotals := func(totals []total) string {
 		buf := &bytes.Buffer{}
-		// this tab writer uses the same settings as tabby, except obviously for
-		// where it writes to.
-		tw := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
-		tab := tabby.NewCustom(tw)
+		tab := tabby.NewCustom(newTabWriter(buf))
 		tab.AddHeader("Month", "Spent")
 		for _, t := range totals {
-			amtStr := t.amount.String()
-			// Align all the amount cells
-			if t.amount >= 0 {
-				amtStr = " " + amtStr
-			}
-			tab.AddLine(t.month.String(), amtStr)
+			tab.AddLine(t.month.String(), alignAmount(t.amount))
 		}
 		tab.Print()
 		return buf.String()
 	}
 
 	var totals []total
-	start := month.Start(time.Now().UTC())
+	start := month.Start(now())
 	start = month.Add(start, -defaultReportMonths+1)
 	for i := 0; i < defaultReportMonths; i++ {
 		end := month.End(start)
-		amount, err := c.Transactions.RangeTotal(start, end)
+		// Only expenses are spending. Income and transfers would hide it.
+		amounts, err := r.Transactions.RangeKindTotals(start, end)
+		var amount transaction.Cent
+		if err == nil {
+			amount, err = totalToHome(r.Rates, amounts[transaction.Expense], end)
+		}
 		if err != nil {
-			c.err.Printf("could not get totals for all of the requested months: %v", err)
-			c.err.Println("correctly collected totals: ")
-			c.err.Println(sPrintTotals(totals))
-			return 1
+			fmt.Fprintln(r.Err, "correctly collected totals: ")
+			fmt.Fprintln(r.Err, sPrintTotals(totals))
+			return fmt.Errorf("could not get totals for all of the requested months: %v", err)
 		}
 		totals = append(totals, total{month: start.Month(), amount: amount})
 		start = month.Add(start, 1)
 	}
 
-	fmt.Println(sPrintTotals(totals))
+	fmt.Fprintln(r.Out, sPrintTotals(totals))
+	return nil
+}
+
+// cashFlow prints how much the user earned and spent in each of the last few
+// months, how much of it they kept, and what part of their income that was.
+// Transfers between their own accounts are left out, since the money never
+// left them.
+func (r report) cashFlow() error {
+	tab := tabby.NewCustom(newTabWriter(r.Out))
+	tab.AddHeader("Month", "Income", "Expenses", "Net Savings", "Savings Rate")
+	var income, expenses transaction.Cent
+	start := month.Add(month.Start(now()), -defaultReportMonths+1)
+	for i := 0; i < defaultReportMonths; i++ {
+		end := month.End(start)
+		totals, err := r.Transactions.RangeKindTotals(start, end)
+		if err != nil {
+			return err
+		}
+		in, err := totalToHome(r.Rates, totals[transaction.Income], end)
+		if err != nil {
+			return err
+		}
+		out, err := totalToHome(r.Rates, totals[transaction.Expense], end)
+		if err != nil {
+			return err
+		}
+		if err := addCashFlow(tab, start.Format("January 2006"), in, out); err != nil {
+			return err
+		}
+		if income, err = income.Add(in); err != nil {
+			return err
+		}
+		if expenses, err = expenses.Add(out); err != nil {
+			return err
+		}
+		start = month.Add(start, 1)
+	}
+	if err := addCashFlow(tab, "Total", income, expenses); err != nil {
+		return err
+	}
+	tab.Print()
+	return nil
+}
+
+// addCashFlow adds a line to a cash flow report for the given income and
+// expenses. The savings rate is left out when there's no income to save.
+func addCashFlow(tab *tabby.Tabby, label string, income, expenses transaction.Cent) error {
+	net, err := income.Add(expenses)
+	if err != nil {
+		return err
+	}
+	rate := "-"
+	if income > 0 {
+		rate = fmt.Sprintf("%.1f%%", float64(net)/float64(income)*100)
+	}
+	tab.AddLine(label, alignAmount(income), alignAmount(expenses), alignAmount(net), rate)
+	return nil
+}
 
-	return 0
+// payeeTotals prints how much was spent with each payee over the last few
+// months, from most to least. Transactions are grouped by the canonical names
+// of their payees, even if they were added before the payee's aliases. Like
+// the other reports, only expenses are spending, and void transactions and
+// other people's shares aren't counted.
+func (r report) payeeTotals() error {
+	payees, err := loadPayees(r.Payees)
+	if err != nil {
+		return err
+	}
+	start := month.Add(month.Start(now()), -defaultReportMonths+1)
+	end := month.End(now())
+	entities, err := r.Transactions.RangeEntityExpenses(start, end)
+	if err != nil {
+		return err
+	}
+
+	totals := make(map[string]transaction.Cent)
+	var names []string
+	for entity, amounts := range entities {
+		name := payees.Normalize(entity)
+		if _, ok := totals[name]; !ok {
+			names = append(names, name)
+		}
+		amount, err := totalToHome(r.Rates, amounts, end)
+		if err != nil {
+			return err
+		}
+		totals[name], err = totals[name].Add(amount)
+		if err != nil {
+			return err
+		}
+	}
+	sort.Strings(names)
+	sort.SliceStable(names, func(i, j int) bool { return totals[names[i]] < totals[names[j]] })
+
+	tab := tabby.NewCustom(newTabWriter(r.Out))
+	tab.AddHeader("Payee", "Total")
+	for _, name := range names {
+		tab.AddLine(name, alignAmount(totals[name]))
+	}
+	tab.Print()
+	return nil
 }
@@ -0,0 +1,105 @@
+package budgeter
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/Anthony-Fiddes/budgeter/internal/inpt"
+)
+
+// sqliteHeader is the string that every SQLite database file starts with.
+const sqliteHeader = "SQLite format 3\x00"
+
+type restore struct {
+	confirmed bool
+	in        *inpt.Scanner
+	DB        io.Closer
+	DBPath    string
+	Out       io.Writer
+}
+
+func newRestore(c *CLI) *restore {
+	result := restore{}
+	result.in = c.in
+	result.DB = c.DB
+	result.DBPath = c.DBPath
+	result.Out = c.Out
+	return &result
+}
+
+func (r restore) Name() string {
+	return "restore"
+}
+
+//go:embed restoreUsage.txt
+var restoreUsage string
+
+func (r restore) Usage() string {
+	return restoreUsage
+}
+
+// restore replaces the database with a backup.
+func (r restore) Run(cmdArgs []string) error {
+	fs := getFlagset(r.Name())
+	fs.BoolVar(&r.confirmed, "y", false, "")
+	if err := fs.Parse(cmdArgs); err != nil {
+		return err
+	}
+	args := fs.Args()
+	if len(args) != 1 {
+		return fmt.Errorf("%s only takes one argument", r.Name())
+	}
+	backupPath := args[0]
+
+	backupFile, err := os.Open(backupPath)
+	if err != nil {
+		return fmt.Errorf("error opening \"%s\" to read: %w", backupPath, err)
+	}
+	defer backupFile.Close()
+	header := make([]byte, len(sqliteHeader))
+	if _, err := io.ReadFull(backupFile, header); err != nil || !bytes.Equal(header, []byte(sqliteHeader)) {
+		return fmt.Errorf("\"%s\" isn't a budgeter backup", backupPath)
+	}
+	if _, err := backupFile.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if !r.confirmed {
+		fmt.Fprint(r.Out, "This will replace your budgeting information with the backup. Continue? (y/[n]) ")
+		ok, err := r.in.Confirm()
+		if err != nil || !ok {
+			return err
+		}
+	}
+
+	// The backup is copied next to the database first, so that the database
+	// is either replaced entirely or not at all.
+	temp, err := os.CreateTemp(filepath.Dir(r.DBPath), filepath.Base(r.DBPath)+".restore-*")
+	if err != nil {
+		return fmt.Errorf("error creating a file to restore to: %w", err)
+	}
+	defer os.Remove(temp.Name())
+	if _, err := io.Copy(temp, backupFile); err != nil {
+		temp.Close()
+		return fmt.Errorf("error copying \"%s\": %w", backupPath, err)
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	// Connections to the old database could write to the new one, or keep
+	// reading the old one, after it's replaced.
+	if r.DB != nil {
+		if err := r.DB.Close(); err != nil {
+			return fmt.Errorf("error closing the database: %w", err)
+		}
+	}
+	if err := os.Rename(temp.Name(), r.DBPath); err != nil {
+		return fmt.Errorf("error replacing the database: %w", err)
+	}
+	fmt.Fprintf(r.Out, "Restored your budgeting information from \"%s\".\n", backupPath)
+	return nil
+}
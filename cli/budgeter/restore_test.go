@@ -0,0 +1,69 @@
+package budgeter
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+// openTestTable opens the transactions table of the database at "path".
+func openTestTable(t *testing.T, path string) *transaction.Table {
+	t.Helper()
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := &transaction.Table{DB: db}
+	if err := table.Init(); err != nil {
+		db.Close()
+		t.Fatal(err)
+	}
+	return table
+}
+
+func TestBackupRestore(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "budgeter.db")
+	backupPath := filepath.Join(dir, "backup.db")
+
+	table := openTestTable(t, dbPath)
+	if _, err := table.Insert(transaction.Transaction{Date: 1609459200, Entity: "Grocer", Amount: -5000}); err != nil {
+		t.Fatal(err)
+	}
+	if err := (backup{DBPath: dbPath}).Run([]string{backupPath}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := table.Insert(transaction.Transaction{Date: 1609545600, Entity: "Cafe", Amount: -500}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	r := restore{DB: table.DB, DBPath: dbPath, Out: &out}
+	if err := r.Run([]string{"-y", backupPath}); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.DB.Ping(); err == nil {
+		t.Error("expected restore to close the database")
+	}
+
+	table = openTestTable(t, dbPath)
+	defer table.DB.Close()
+	rows, err := table.Search("", -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txs, err := rows.ScanSet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) != 1 || txs[0].Entity != "Grocer" {
+		t.Errorf("expected only the transaction from before the backup but found %+v", txs)
+	}
+
+	if err := r.Run([]string{"-y", filepath.Join(dir, "missing.db")}); err == nil {
+		t.Error("expected an error restoring a backup that doesn't exist")
+	}
+}
@@ -0,0 +1,278 @@
+package budgeter
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/internal/inpt"
+	"github.com/Anthony-Fiddes/budgeter/model/rule"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+	"github.com/cheynewallace/tabby"
+)
+
+type RuleTable interface {
+	All() ([]rule.Rule, error)
+	Insert(rule.Rule) error
+	Remove(ruleID int) error
+}
+
+// loadRules returns an engine that applies all of the rules in "t".
+func loadRules(t RuleTable) (*rule.Engine, error) {
+	rules, err := t.All()
+	if err != nil {
+		return nil, err
+	}
+	return rule.NewEngine(rules)
+}
+
+type rules struct {
+	since        string
+	confirmed    bool
+	in           *inpt.Scanner
+	Out          io.Writer
+	Rules        RuleTable
+	Transactions Table
+}
+
+func newRules(c *CLI) *rules {
+	result := &rules{}
+	result.in = c.in
+	result.Out = c.Out
+	result.Rules = c.Rules
+	result.Transactions = c.Transactions
+	return result
+}
+
+func (r rules) Name() string {
+	return "rules"
+}
+
+//go:embed rulesUsage.txt
+var rulesUsage string
+
+func (r rules) Usage() string {
+	return rulesUsage
+}
+
+func (r rules) Run(cmdArgs []string) error {
+	fs := getFlagset(r.Name())
+	if err := fs.Parse(cmdArgs); err != nil {
+		return err
+	}
+	args := fs.Args()
+	if len(args) == 0 {
+		return r.list()
+	}
+
+	subArgs := args[1:]
+	switch args[0] {
+	case "add":
+		if len(subArgs) != 0 {
+			return fmt.Errorf("%s add takes no arguments", r.Name())
+		}
+		return r.add()
+	case "remove":
+		if len(subArgs) != 1 {
+			return fmt.Errorf("%s remove takes one argument", r.Name())
+		}
+		ruleID, err := strconv.Atoi(subArgs[0])
+		if err != nil {
+			return fmt.Errorf(
+				"%s remove takes a numerical ID. try `budgeter %s` to see some IDs.",
+				r.Name(),
+				r.Name(),
+			)
+		}
+		if err := r.Rules.Remove(ruleID); err != nil {
+			return fmt.Errorf("could not remove rule #%d: %v", ruleID, err)
+		}
+		return nil
+	case "apply":
+		fs := getFlagset(r.Name() + " apply")
+		fs.StringVar(&r.since, "since", "", "")
+		fs.BoolVar(&r.confirmed, "y", false, "")
+		if err := fs.Parse(subArgs); err != nil {
+			return err
+		}
+		if len(fs.Args()) != 0 {
+			return fmt.Errorf("%s apply takes no arguments", r.Name())
+		}
+		return r.apply()
+	default:
+		return fmt.Errorf("%s has no subcommand \"%s\"", r.Name(), args[0])
+	}
+}
+
+// list prints all of the rules in the order they're applied.
+func (r rules) list() error {
+	all, err := r.Rules.All()
+	if err != nil {
+		return err
+	}
+	tab := tabby.NewCustom(newTabWriter(r.Out))
+	tab.AddHeader("ID", "Rule")
+	for _, rl := range all {
+		tab.AddLine(rl.ID, rl)
+	}
+	tab.Print()
+	return nil
+}
+
+// add interactively adds a new rule.
+func (r rules) add() error {
+	getAmount := func(field string) (*transaction.Cent, error) {
+		response, err := prompt(r.Out, r.in, field, "")
+		if err != nil || response == "" {
+			return nil, err
+		}
+		amount, err := transaction.GetCents(response)
+		if err != nil {
+			return nil, err
+		}
+		return &amount, nil
+	}
+
+	fmt.Fprintln(r.Out, "Leave a field empty to match any transaction.")
+	var err error
+	rl := rule.Rule{}
+	rl.Entity, err = prompt(r.Out, r.in, "Entity pattern", "")
+	if err != nil {
+		return err
+	}
+	rl.Note, err = prompt(r.Out, r.in, "Note pattern", "")
+	if err != nil {
+		return err
+	}
+	rl.Min, err = getAmount("Minimum amount")
+	if err != nil {
+		return err
+	}
+	rl.Max, err = getAmount("Maximum amount")
+	if err != nil {
+		return err
+	}
+	rl.Account, err = prompt(r.Out, r.in, transaction.AccountCol, "")
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(r.Out, "\nLeave a field empty to leave it unchanged.")
+	rl.SetCategory, err = prompt(r.Out, r.in, "Set "+transaction.CategoryCol, "")
+	if err != nil {
+		return err
+	}
+	tags, err := prompt(r.Out, r.in, "Add "+transaction.TagsCol, "")
+	if err != nil {
+		return err
+	}
+	rl.SetTags = transaction.ParseTags(tags)
+	rl.SetEntity, err = prompt(r.Out, r.in, "Set "+transaction.EntityCol, "")
+	if err != nil {
+		return err
+	}
+	rl.SetNote, err = prompt(r.Out, r.in, "Set "+transaction.NoteCol, "")
+	if err != nil {
+		return err
+	}
+	return r.Rules.Insert(rl)
+}
+
+// apply applies the rules to the transactions that happened on or after
+// r.since, after showing the user what will change.
+func (r rules) apply() error {
+	if r.since == "" {
+		return fmt.Errorf("%s apply requires -since", r.Name())
+	}
+	since, err := transaction.Unix(r.since)
+	if err != nil {
+		return err
+	}
+	engine, err := loadRules(r.Rules)
+	if err != nil {
+		return err
+	}
+	rows, err := r.Transactions.Range(time.Unix(since, 0).UTC(), time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC), -1)
+	if err != nil {
+		return err
+	}
+	transactions, err := rows.ScanSet()
+	if err != nil {
+		return err
+	}
+
+	// Reconciled transactions can't be changed, so they're left out.
+	var changed []transaction.Transaction
+	var locked []int
+	tab := tabby.NewCustom(newTabWriter(r.Out))
+	tab.AddHeader("ID", "Date", "Field", "Before", "After")
+	for _, tx := range transactions {
+		result := engine.Apply(tx)
+		diffs := diffTransactions(tx, result)
+		if len(diffs) == 0 {
+			continue
+		}
+		if tx.Status == transaction.Reconciled {
+			locked = append(locked, tx.ID)
+			continue
+		}
+		changed = append(changed, result)
+		for _, d := range diffs {
+			tab.AddLine(tx.ID, tx.DateString(), d.field, d.before, d.after)
+		}
+	}
+	if len(changed) == 0 {
+		fmt.Fprintln(r.Out, "No transactions would be changed.")
+		printLocked(r.Out, locked)
+		return nil
+	}
+	tab.Print()
+	printLocked(r.Out, locked)
+
+	if !r.confirmed {
+		fmt.Fprintf(r.Out, "\nUpdate %d transactions? (y/[n]) ", len(changed))
+		r.confirmed, err = r.in.Confirm()
+		if err != nil {
+			return err
+		}
+		if !r.confirmed {
+			fmt.Fprintln(r.Out, "No transactions changed.")
+			return nil
+		}
+	}
+	if err := r.Transactions.UpdateAll(changed, nil); err != nil {
+		return err
+	}
+	fmt.Fprintf(r.Out, "Updated %d transactions.\n", len(changed))
+	return nil
+}
+
+type fieldDiff struct {
+	field, before, after string
+}
+
+// diffTransactions returns the fields that differ between "before" and
+// "after".
+func diffTransactions(before, after transaction.Transaction) []fieldDiff {
+	var result []fieldDiff
+	add := func(field, b, a string) {
+		if b != a {
+			result = append(result, fieldDiff{field, b, a})
+		}
+	}
+	add(transaction.DateCol, before.DateString(), after.DateString())
+	add(transaction.EntityCol, before.Entity, after.Entity)
+	add(transaction.AmountCol, before.AmountString(), after.AmountString())
+	add(transaction.NoteCol, before.Note, after.Note)
+	add(transaction.CategoryCol, before.Category, after.Category)
+	add(
+		transaction.TagsCol,
+		strings.Join(before.Tags, transaction.TagSeparator),
+		strings.Join(after.Tags, transaction.TagSeparator),
+	)
+	add(transaction.AccountCol, before.Account, after.Account)
+	return result
+}
@@ -0,0 +1,694 @@
+package budgeter
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/model/currency"
+	"github.com/Anthony-Fiddes/budgeter/model/envelope"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+const (
+	// apiTokenKey is the config key for the token that API requests must
+	// provide.
+	apiTokenKey = "api_token"
+	// defaultServeAddr is the address that the API is served on by default.
+	// It's only reachable from the user's own machine.
+	defaultServeAddr = "localhost:8080"
+	// apiDateLayout is the format of dates in the API.
+	apiDateLayout = "2006-01-02"
+	// apiMonthLayout is the format of months in the API.
+	apiMonthLayout = "2006-01"
+	// defaultAPILimit is the number of transactions that are listed if the
+	// request doesn't give a limit.
+	defaultAPILimit = 100
+	// maxRequestSize is the largest request body that the API accepts, in
+	// bytes.
+	maxRequestSize = 1 << 20
+)
+
+type serve struct {
+	addr         string
+	link         bool
+	token        string
+	Config       Store
+	Envelopes    EnvelopeTable
+	Out          io.Writer
+	Payees       PayeeTable
+	Rates        RateTable
+	Rules        RuleTable
+	Transactions Table
+	// changes makes requests that change transactions run one at a time, so
+	// that each is its own operation in the journal.
+	changes *sync.Mutex
+}
+
+func newServe(c *CLI) *serve {
+	result := &serve{}
+	result.Config = c.Config
+	result.Envelopes = c.Envelopes
+	result.Out = c.Out
+	result.Payees = c.Payees
+	result.Rates = c.Rates
+	result.Rules = c.Rules
+	result.Transactions = c.Transactions
+	return result
+}
+
+func (s serve) Name() string {
+	return "serve"
+}
+
+//go:embed serveUsage.txt
+var serveUsage string
+
+func (s serve) Usage() string {
+	return serveUsage
+}
+
+// serve runs an HTTP server with a JSON API for the user's transactions until
+// it's interrupted.
+func (s serve) Run(cmdArgs []string) error {
+	fs := getFlagset(s.Name())
+	fs.StringVar(&s.addr, "addr", defaultServeAddr, "")
+	fs.BoolVar(&s.link, "link", false, "")
+	if err := fs.Parse(cmdArgs); err != nil {
+		return err
+	}
+	if len(fs.Args()) > 0 {
+		return fmt.Errorf("%s takes no arguments", s.Name())
+	}
+
+	token, generated, err := s.loadToken()
+	if err != nil {
+		return err
+	}
+	s.token = token
+	if !isLoopback(s.addr) {
+		fmt.Fprintf(s.Out, "Warning: %s may be reachable from other machines.\n", s.addr)
+	}
+
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("could not listen on %s: %w", s.addr, err)
+	}
+	server := &http.Server{Handler: s.handler()}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Fprintf(s.Out, "Serving on http://%s (press Ctrl+C to stop)\n", listener.Addr())
+	// The link has the token in it, so it's only shown when the web UI
+	// hasn't been signed into yet or the user asks for it.
+	if generated || s.link {
+		fmt.Fprintf(s.Out, "Web UI: http://%s/#token=%s\n", listener.Addr(), s.token)
+	} else {
+		fmt.Fprintf(s.Out, "Web UI: http://%s/ (use -link for a link that signs you in)\n", listener.Addr())
+	}
+	if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// loadToken returns the API token in the config, generating one if there
+// isn't one yet. It also returns whether the token was generated.
+func (s serve) loadToken() (string, bool, error) {
+	token, err := s.Config.Get(apiTokenKey)
+	if err != nil || token != "" {
+		return token, false, err
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", false, fmt.Errorf("could not generate an API token: %w", err)
+	}
+	token = hex.EncodeToString(b)
+	if err := s.Config.Put(apiTokenKey, token); err != nil {
+		return "", false, err
+	}
+	fmt.Fprintf(s.Out, "Generated an API token and saved it to your config: %s\n", token)
+	return token, true, nil
+}
+
+// isLoopback reports whether "addr" can only be reached from this machine.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// handler returns the handler for every API endpoint and the web UI. Only the
+// web UI's files can be requested without the API token.
+func (s serve) handler() http.Handler {
+	s.changes = &sync.Mutex{}
+	mux := http.NewServeMux()
+	mux.Handle("/transactions", s.authorize(s.transactions))
+	mux.Handle("/transactions/", s.authorize(s.transaction))
+	mux.Handle("/totals", s.authorize(s.totals))
+	mux.Handle("/budgets", s.authorize(s.budgets))
+	mux.Handle("/budgets/", s.authorize(s.budget))
+	mux.Handle("/ui/entry", s.authorize(s.entry))
+	mux.Handle("/ui/months", s.authorize(s.months))
+	mux.Handle("/ui/upload", s.authorize(s.upload))
+	mux.Handle("/", webHandler())
+	return mux
+}
+
+// authorize only lets requests with the API token through to "next".
+func (s serve) authorize(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		const prefix = "Bearer "
+		auth := r.Header.Get("Authorization")
+		given := strings.TrimPrefix(auth, prefix)
+		if s.token == "" || !strings.HasPrefix(auth, prefix) ||
+			subtle.ConstantTimeCompare([]byte(given), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid API token"))
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			s.changes.Lock()
+			defer s.changes.Unlock()
+			beginOperation(s.Transactions, fmt.Sprintf("%s %s %s", s.Name(), r.Method, r.URL.Path))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// apiTransaction is how transactions are represented in the API. Amounts are
+// whole numbers of the currency's minor units, e.g. cents.
+type apiTransaction struct {
+	ID       int      `json:"id"`
+	Date     string   `json:"date"`
+	Entity   string   `json:"entity"`
+	Amount   int64    `json:"amount"`
+	Currency string   `json:"currency"`
+	Note     string   `json:"note"`
+	Category string   `json:"category"`
+	Tags     []string `json:"tags"`
+	Account  string   `json:"account"`
+	// Kind is the kind that the transaction was classified as, or "auto" if
+	// it's classified by its amount. Replaced transactions keep their kind if
+	// it's left out.
+	Kind string `json:"kind"`
+	// Display is the amount formatted in the user's locale. It's ignored in
+	// requests.
+	Display string `json:"display"`
+	// Status is where the transaction is in its life, e.g. "reconciled". It's
+	// ignored in requests.
+	Status string `json:"status"`
+}
+
+func toAPI(tx transaction.Transaction) apiTransaction {
+	tags := tx.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return apiTransaction{
+		ID:       tx.ID,
+		Date:     time.Unix(tx.Date, 0).UTC().Format(apiDateLayout),
+		Entity:   tx.Entity,
+		Amount:   int64(tx.Amount),
+		Currency: tx.CurrencyInfo().Code,
+		Note:     tx.Note,
+		Category: tx.Category,
+		Tags:     tags,
+		Account:  tx.Account,
+		Kind:     apiKind(tx.Kind),
+		Display:  tx.AmountString(),
+		Status:   string(tx.Status),
+	}
+}
+
+// transaction validates "a" and converts it to a transaction.
+func (a apiTransaction) transaction() (transaction.Transaction, error) {
+	date, err := time.Parse(apiDateLayout, a.Date)
+	if err != nil {
+		return transaction.Transaction{}, fmt.Errorf("date \"%s\" must be in YYYY-MM-DD format", a.Date)
+	}
+	if strings.TrimSpace(a.Entity) == "" {
+		return transaction.Transaction{}, errors.New("entity must not be empty")
+	}
+	cur, err := lookupCurrency(a.Currency)
+	if err != nil {
+		return transaction.Transaction{}, err
+	}
+	var kind transaction.Kind
+	if a.Kind != "" && a.Kind != autoKind {
+		if kind, err = transaction.ParseKind(a.Kind); err != nil {
+			return transaction.Transaction{}, err
+		}
+	}
+	return transaction.Transaction{
+		ID:       a.ID,
+		Date:     transaction.DateOf(date),
+		Entity:   a.Entity,
+		Amount:   transaction.Cent(a.Amount),
+		Currency: cur.Code,
+		Note:     a.Note,
+		Category: a.Category,
+		Tags:     transaction.ParseTags(strings.Join(a.Tags, transaction.TagSeparator)),
+		Account:  a.Account,
+		Kind:     kind,
+	}, nil
+}
+
+// apiKind returns how "k" is represented in the API.
+func apiKind(k transaction.Kind) string {
+	if k == "" {
+		return autoKind
+	}
+	return string(k)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// writeTableError writes "err", which came from one of the tables, with the
+// status that matches it.
+func writeTableError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, transaction.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, transaction.ErrDuplicate), errors.Is(err, transaction.ErrLocked):
+		status = http.StatusConflict
+	case errors.Is(err, currency.ErrNoRate):
+		status = http.StatusUnprocessableEntity
+	}
+	writeError(w, status, err)
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+}
+
+// readTransaction decodes a transaction from the body of "r". keepKind is true
+// if the request left out the transaction's kind.
+func readTransaction(w http.ResponseWriter, r *http.Request) (tx transaction.Transaction, keepKind bool, err error) {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	decoder.DisallowUnknownFields()
+	var a apiTransaction
+	if err := decoder.Decode(&a); err != nil {
+		return transaction.Transaction{}, false, fmt.Errorf("invalid transaction: %w", err)
+	}
+	tx, err = a.transaction()
+	return tx, a.Kind == "", err
+}
+
+// dateRange returns the range given by the "from" and "to" query parameters of
+// "r", which are both optional. ok is false if neither was given.
+func dateRange(r *http.Request) (start, end time.Time, ok bool, err error) {
+	start = time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+	for _, p := range []struct {
+		name string
+		date *time.Time
+	}{{"from", &start}, {"to", &end}} {
+		value := r.URL.Query().Get(p.name)
+		if value == "" {
+			continue
+		}
+		*p.date, err = time.Parse(apiDateLayout, value)
+		if err != nil {
+			return start, end, false, fmt.Errorf("%s must be in YYYY-MM-DD format", p.name)
+		}
+		ok = true
+	}
+	// Include the whole of the last day.
+	end = end.Add(24*time.Hour - time.Second)
+	return start, end, ok, nil
+}
+
+// transactions lists transactions or creates one.
+func (s serve) transactions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.list(w, r)
+	case http.MethodPost:
+		tx, _, err := readTransaction(w, r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		id, err := s.Transactions.Insert(tx)
+		if err != nil {
+			writeTableError(w, err)
+			return
+		}
+		tx, err = s.Transactions.Get(id)
+		if err != nil {
+			writeTableError(w, err)
+			return
+		}
+		w.Header().Set("Location", fmt.Sprintf("/transactions/%d", id))
+		writeJSON(w, http.StatusCreated, toAPI(tx))
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+// list writes the transactions that match the query parameters "q", "from"
+// and "to", most recent first. "limit" caps how many are written.
+func (s serve) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit := defaultAPILimit
+	if l := query.Get("limit"); l != "" {
+		var err error
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 0 {
+			writeError(w, http.StatusBadRequest, errors.New("limit must be a positive number"))
+			return
+		}
+	}
+	start, end, ranged, err := dateRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	search := query.Get("q")
+	var rows *transaction.Rows
+	if ranged {
+		rows, err = s.Transactions.Range(start, end, -1)
+	} else {
+		rows, err = s.Transactions.Search(search, limit)
+	}
+	if err != nil {
+		writeTableError(w, err)
+		return
+	}
+	found, err := rows.ScanSet()
+	if err != nil {
+		writeTableError(w, err)
+		return
+	}
+
+	result := []apiTransaction{}
+	if ranged {
+		// Range returns the oldest transactions first.
+		search = strings.ToLower(search)
+		for i := len(found) - 1; i >= 0 && len(result) < limit; i-- {
+			tx := found[i]
+			if strings.Contains(strings.ToLower(tx.Entity), search) ||
+				strings.Contains(strings.ToLower(tx.Note), search) {
+				result = append(result, toAPI(tx))
+			}
+		}
+	} else {
+		for _, tx := range found {
+			result = append(result, toAPI(tx))
+		}
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// transaction gets, updates or deletes the transaction with the ID in the
+// path.
+func (s serve) transaction(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/transactions/"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, errors.New("transaction IDs must be numbers"))
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		tx, err := s.Transactions.Get(id)
+		if err != nil {
+			writeTableError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, toAPI(tx))
+	case http.MethodPut:
+		tx, keepKind, err := readTransaction(w, r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		tx.ID = id
+		if keepKind {
+			existing, err := s.Transactions.Get(id)
+			if err != nil {
+				writeTableError(w, err)
+				return
+			}
+			tx.Kind = existing.Kind
+		}
+		if err := s.Transactions.Update(tx); err != nil {
+			writeTableError(w, err)
+			return
+		}
+		tx, err = s.Transactions.Get(id)
+		if err != nil {
+			writeTableError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, toAPI(tx))
+	case http.MethodDelete:
+		if err := s.Transactions.Remove(id); err != nil {
+			writeTableError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPut, http.MethodDelete)
+	}
+}
+
+// apiTotals is how totals are represented in the API.
+type apiTotals struct {
+	// Totals are the totals in each currency, keyed by currency code.
+	Totals map[string]int64 `json:"totals"`
+	// Home is the code of the home currency.
+	Home string `json:"home"`
+	// Total is the sum of Totals in the home currency.
+	Total int64 `json:"total"`
+}
+
+// totals writes the totals of the transactions between the "from" and "to"
+// query parameters, or of every transaction if neither is given.
+func (s serve) totals(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	start, end, ranged, err := dateRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var totals map[string]transaction.Cent
+	if ranged {
+		totals, err = s.Transactions.RangeTotals(start, end)
+	} else {
+		totals, err = s.Transactions.Totals()
+	}
+	if err != nil {
+		writeTableError(w, err)
+		return
+	}
+	date := today()
+	if end.Before(date) {
+		date = end
+	}
+	total, err := totalToHome(s.Rates, totals, date)
+	if err != nil {
+		writeTableError(w, err)
+		return
+	}
+
+	result := apiTotals{Totals: map[string]int64{}, Home: transaction.Home().Code, Total: int64(total)}
+	for code, amount := range totals {
+		result.Totals[code] = int64(amount)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// apiEnvelope is how envelopes are represented in the API. Amounts are in
+// cents of the home currency.
+type apiEnvelope struct {
+	Category string `json:"category"`
+	// Carried is the balance that rolled over from the months before.
+	Carried  int64 `json:"carried"`
+	Assigned int64 `json:"assigned"`
+	Activity int64 `json:"activity"`
+	// Available is what's left in the envelope at the end of the month.
+	Available int64 `json:"available"`
+}
+
+// apiBudget is how envelope budgets are represented in the API.
+type apiBudget struct {
+	// Month is in apiMonthLayout.
+	Month string `json:"month"`
+	// Home is the code of the home currency.
+	Home         string        `json:"home"`
+	ToBeBudgeted int64         `json:"to_be_budgeted"`
+	Envelopes    []apiEnvelope `json:"envelopes"`
+}
+
+// apiAssignment is the body of a request to budget an envelope.
+type apiAssignment struct {
+	// Assigned is how much the envelope should have assigned to it in the
+	// month, in cents of the home currency.
+	Assigned int64 `json:"assigned"`
+}
+
+// budgetMonth returns the month given by the "month" query parameter of "r",
+// or the current month if it isn't given.
+func budgetMonth(r *http.Request) (int64, error) {
+	value := r.URL.Query().Get("month")
+	if value == "" {
+		return envelope.MonthOf(today().Unix()), nil
+	}
+	m, err := time.Parse(apiMonthLayout, value)
+	if err != nil {
+		return 0, fmt.Errorf("month \"%s\" must be in YYYY-MM format", value)
+	}
+	return m.Unix(), nil
+}
+
+// budgeting writes an error and returns false if envelope budgeting is off.
+func (s serve) budgeting(w http.ResponseWriter) bool {
+	_, ok, err := envelopeStart(s.Config)
+	if err != nil {
+		writeTableError(w, err)
+		return false
+	}
+	if !ok {
+		err = fmt.Errorf("envelope budgeting is off. try `budgeter %s start`", envelopes{}.Name())
+		writeError(w, http.StatusConflict, err)
+	}
+	return ok
+}
+
+// writeBudget writes the envelope budget for the month "m".
+func (s serve) writeBudget(w http.ResponseWriter, m int64) {
+	budget, err := envelopeBudget(s.Config, s.Envelopes, s.Rates, s.Transactions, m)
+	if err != nil {
+		writeTableError(w, err)
+		return
+	}
+	result := apiBudget{
+		Month:        time.Unix(m, 0).UTC().Format(apiMonthLayout),
+		Home:         transaction.Home().Code,
+		ToBeBudgeted: int64(budget.ToBeBudgeted),
+		Envelopes:    []apiEnvelope{},
+	}
+	for _, env := range budget.Envelopes {
+		result.Envelopes = append(result.Envelopes, apiEnvelope{
+			Category:  env.Category,
+			Carried:   int64(env.Carried),
+			Assigned:  int64(env.Assigned),
+			Activity:  int64(env.Activity),
+			Available: int64(env.Balance),
+		})
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// budgets writes the envelope budget for the month in the "month" query
+// parameter.
+func (s serve) budgets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	m, err := budgetMonth(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if s.budgeting(w) {
+		s.writeBudget(w, m)
+	}
+}
+
+// budget sets how much is assigned to the envelope of the category in the
+// path for the month in the "month" query parameter, and writes the month's
+// envelope budget.
+func (s serve) budget(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		methodNotAllowed(w, http.MethodPut)
+		return
+	}
+	category := strings.TrimSpace(strings.TrimPrefix(r.URL.Path, "/budgets/"))
+	if category == "" {
+		writeError(w, http.StatusNotFound, errors.New("a category must be given"))
+		return
+	}
+	m, err := budgetMonth(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	decoder.DisallowUnknownFields()
+	var a apiAssignment
+	if err := decoder.Decode(&a); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid assignment: %w", err))
+		return
+	}
+	if !s.budgeting(w) {
+		return
+	}
+
+	// Assignments add up, so only the difference is assigned.
+	var assigned transaction.Cent
+	all, err := s.Envelopes.All()
+	if err != nil {
+		writeTableError(w, err)
+		return
+	}
+	for _, existing := range all {
+		if existing.Month == m && strings.EqualFold(existing.Category, category) {
+			assigned = existing.Amount
+		}
+	}
+	change, err := transaction.Cent(a.Assigned).Sub(assigned)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if change != 0 {
+		if err := s.Envelopes.Assign(m, category, change); err != nil {
+			writeTableError(w, err)
+			return
+		}
+	}
+	s.writeBudget(w, m)
+}
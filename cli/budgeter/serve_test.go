@@ -0,0 +1,401 @@
+package budgeter
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Anthony-Fiddes/budgeter/model/currency"
+	"github.com/Anthony-Fiddes/budgeter/model/envelope"
+	"github.com/Anthony-Fiddes/budgeter/model/payee"
+	"github.com/Anthony-Fiddes/budgeter/model/rule"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const testToken = "secret"
+
+// testStore is a Store kept in memory.
+type testStore map[string]string
+
+func (s testStore) Put(key, value string) error {
+	s[key] = value
+	return nil
+}
+
+func (s testStore) Get(key string) (string, error) {
+	return s[key], nil
+}
+
+// newTestDB returns an in-memory database that's closed when the test ends.
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection to ":memory:" gets its own database.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// newTestServer returns a server for the API backed by an in-memory database
+// and "config".
+func newTestServer(t *testing.T, config testStore) *httptest.Server {
+	t.Helper()
+	db := newTestDB(t)
+	transactions := &transaction.Table{DB: db}
+	if err := transactions.Init(); err != nil {
+		t.Fatal(err)
+	}
+	rates := &currency.Table{DB: db}
+	if err := rates.Init(); err != nil {
+		t.Fatal(err)
+	}
+	payees := &payee.Table{DB: db}
+	if err := payees.Init(); err != nil {
+		t.Fatal(err)
+	}
+	rules := &rule.Table{DB: db}
+	if err := rules.Init(); err != nil {
+		t.Fatal(err)
+	}
+	envelopes := &envelope.Table{DB: db}
+	if err := envelopes.Init(); err != nil {
+		t.Fatal(err)
+	}
+	s := serve{
+		token: testToken, Config: config, Envelopes: envelopes, Payees: payees, Rates: rates, Rules: rules,
+		Transactions: transactions,
+	}
+	server := httptest.NewServer(s.handler())
+	t.Cleanup(server.Close)
+	return server
+}
+
+// request sends a request to the API and decodes the response into "result",
+// if it's not nil. It returns the response's status code.
+func request(t *testing.T, server *httptest.Server, method, path string, body, result interface{}) int {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req, err := http.NewRequest(method, server.URL+path, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if result != nil {
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+			t.Fatalf("could not decode the response to %s %s: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestServeAuthorization(t *testing.T) {
+	server := newTestServer(t, testStore{})
+	for _, auth := range []string{"", "Bearer", "Bearer wrong", testToken, "Basic " + testToken} {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/transactions", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Authorization %q returned %d instead of %d", auth, resp.StatusCode, http.StatusUnauthorized)
+		}
+	}
+
+	if status := request(t, server, http.MethodGet, "/transactions", nil, nil); status != http.StatusOK {
+		t.Errorf("the correct token returned %d instead of %d", status, http.StatusOK)
+	}
+}
+
+func TestServeTransactions(t *testing.T) {
+	server := newTestServer(t, testStore{})
+
+	// Create
+	falafel := apiTransaction{
+		Date: "2021-01-09", Entity: "Falafel King", Amount: -599,
+		Note: "Shawarma with friends!", Tags: []string{"friends"},
+	}
+	var created apiTransaction
+	if status := request(t, server, http.MethodPost, "/transactions", falafel, &created); status != http.StatusCreated {
+		t.Fatalf("creating a transaction returned %d", status)
+	}
+	if created.ID == 0 || created.Entity != falafel.Entity || created.Amount != falafel.Amount ||
+		created.Date != falafel.Date || created.Currency != "USD" || len(created.Tags) != 1 {
+		t.Fatalf("created %+v from %+v", created, falafel)
+	}
+	if status := request(t, server, http.MethodPost, "/transactions", falafel, nil); status != http.StatusConflict {
+		t.Errorf("creating a duplicate transaction returned %d", status)
+	}
+	invalid := falafel
+	invalid.Date = "1/9/2021"
+	if status := request(t, server, http.MethodPost, "/transactions", invalid, nil); status != http.StatusBadRequest {
+		t.Errorf("creating a transaction with an invalid date returned %d", status)
+	}
+	yen := apiTransaction{Date: "2021-02-01", Entity: "Ramen", Amount: -1200, Currency: "JPY"}
+	if status := request(t, server, http.MethodPost, "/transactions", yen, &yen); status != http.StatusCreated {
+		t.Fatalf("creating a transaction in yen returned %d", status)
+	}
+
+	// Get
+	path := "/transactions/" + strconv.Itoa(created.ID)
+	var got apiTransaction
+	if status := request(t, server, http.MethodGet, path, nil, &got); status != http.StatusOK || got.ID != created.ID {
+		t.Errorf("getting %s returned %d and %+v", path, status, got)
+	}
+	if status := request(t, server, http.MethodGet, "/transactions/12345", nil, nil); status != http.StatusNotFound {
+		t.Errorf("getting a missing transaction returned %d", status)
+	}
+
+	// List
+	var listed []apiTransaction
+	if status := request(t, server, http.MethodGet, "/transactions", nil, &listed); status != http.StatusOK || len(listed) != 2 {
+		t.Fatalf("listing transactions returned %d and %+v", status, listed)
+	}
+	if listed[0].ID != yen.ID {
+		t.Errorf("listed %+v, but the most recent transaction should be first", listed)
+	}
+	listed = nil
+	request(t, server, http.MethodGet, "/transactions?q=falafel", nil, &listed)
+	if len(listed) != 1 || listed[0].ID != created.ID {
+		t.Errorf("searching for falafel returned %+v", listed)
+	}
+	listed = nil
+	request(t, server, http.MethodGet, "/transactions?from=2021-02-01&to=2021-02-01", nil, &listed)
+	if len(listed) != 1 || listed[0].ID != yen.ID {
+		t.Errorf("listing February's transactions returned %+v", listed)
+	}
+	listed = nil
+	request(t, server, http.MethodGet, "/transactions?limit=1", nil, &listed)
+	if len(listed) != 1 {
+		t.Errorf("listing one transaction returned %+v", listed)
+	}
+
+	// Update
+	update := created
+	update.Category = "Food"
+	var updated apiTransaction
+	if status := request(t, server, http.MethodPut, path, update, &updated); status != http.StatusOK || updated.Category != "Food" {
+		t.Errorf("updating %s returned %d and %+v", path, status, updated)
+	}
+	if updated.Kind != "auto" {
+		t.Errorf("%s has kind %q, but it was never classified", path, updated.Kind)
+	}
+	update.Kind = "transfer"
+	if status := request(t, server, http.MethodPut, path, update, &updated); status != http.StatusOK || updated.Kind != "transfer" {
+		t.Errorf("classifying %s as a transfer returned %d and %+v", path, status, updated)
+	}
+	update.Kind = ""
+	update.Note = "Paid back by a friend"
+	if status := request(t, server, http.MethodPut, path, update, &updated); status != http.StatusOK || updated.Kind != "transfer" {
+		t.Errorf("replacing %s without a kind returned %d and %+v, but it should still be a transfer", path, status, updated)
+	}
+	update.Kind = "auto"
+	if status := request(t, server, http.MethodPut, path, update, &updated); status != http.StatusOK || updated.Kind != "auto" {
+		t.Errorf("classifying %s by its amount again returned %d and %+v", path, status, updated)
+	}
+	update.Kind = "gift"
+	if status := request(t, server, http.MethodPut, path, update, nil); status != http.StatusBadRequest {
+		t.Errorf("updating %s with an unknown kind returned %d", path, status)
+	}
+	update.Kind = ""
+	if status := request(t, server, http.MethodPut, "/transactions/12345", update, nil); status != http.StatusNotFound {
+		t.Errorf("updating a missing transaction returned %d", status)
+	}
+
+	// Delete
+	if status := request(t, server, http.MethodDelete, path, nil, nil); status != http.StatusNoContent {
+		t.Errorf("deleting %s returned %d", path, status)
+	}
+	if status := request(t, server, http.MethodGet, path, nil, nil); status != http.StatusNotFound {
+		t.Errorf("getting %s after deleting it returned %d", path, status)
+	}
+	if status := request(t, server, http.MethodPatch, path, nil, nil); status != http.StatusMethodNotAllowed {
+		t.Errorf("patching %s returned %d", path, status)
+	}
+}
+
+func TestServeTotals(t *testing.T) {
+	server := newTestServer(t, testStore{})
+	for _, tx := range []apiTransaction{
+		{Date: "2021-01-09", Entity: "Falafel King", Amount: -599},
+		{Date: "2021-02-01", Entity: "Paycheck", Amount: 100000},
+		{Date: "2021-02-02", Entity: "Ramen", Amount: -1200, Currency: "JPY"},
+	} {
+		if status := request(t, server, http.MethodPost, "/transactions", tx, nil); status != http.StatusCreated {
+			t.Fatalf("creating %+v returned %d", tx, status)
+		}
+	}
+
+	var totals apiTotals
+	status := request(t, server, http.MethodGet, "/totals?from=2021-01-01&to=2021-01-31", nil, &totals)
+	if status != http.StatusOK || totals.Total != -599 || totals.Home != "USD" || totals.Totals["USD"] != -599 {
+		t.Errorf("January's totals were %d and %+v", status, totals)
+	}
+
+	// There's no exchange rate for yen yet.
+	if status := request(t, server, http.MethodGet, "/totals", nil, nil); status != http.StatusUnprocessableEntity {
+		t.Errorf("totals without an exchange rate returned %d", status)
+	}
+}
+
+func TestServeBudgets(t *testing.T) {
+	config := testStore{}
+	server := newTestServer(t, config)
+	if status := request(t, server, http.MethodGet, "/budgets?month=2021-02", nil, nil); status != http.StatusConflict {
+		t.Errorf("getting a budget with envelope budgeting off returned %d", status)
+	}
+
+	config[envelopeStartKey] = "2/2021"
+	for _, tx := range []apiTransaction{
+		{Date: "2021-01-09", Entity: "Paycheck", Amount: 100000},
+		{Date: "2021-02-03", Entity: "Grocer", Amount: -2500, Category: "Food"},
+	} {
+		if status := request(t, server, http.MethodPost, "/transactions", tx, nil); status != http.StatusCreated {
+			t.Fatalf("creating %+v returned %d", tx, status)
+		}
+	}
+
+	var budget apiBudget
+	status := request(t, server, http.MethodPut, "/budgets/Food?month=2021-02", apiAssignment{Assigned: 30000}, &budget)
+	if status != http.StatusOK || budget.Month != "2021-02" || len(budget.Envelopes) != 1 {
+		t.Fatalf("budgeting Food returned %d and %+v", status, budget)
+	}
+	want := apiEnvelope{Category: "Food", Assigned: 30000, Activity: -2500, Available: 27500}
+	if budget.Envelopes[0] != want || budget.ToBeBudgeted != 70000 {
+		t.Errorf("budgeting Food gave %+v, want %+v with 70000 to be budgeted", budget, want)
+	}
+
+	// Putting an envelope's budget replaces what was assigned to it.
+	status = request(t, server, http.MethodPut, "/budgets/food?month=2021-02", apiAssignment{Assigned: 20000}, &budget)
+	if status != http.StatusOK || len(budget.Envelopes) != 1 || budget.Envelopes[0].Assigned != 20000 ||
+		budget.ToBeBudgeted != 80000 {
+		t.Errorf("budgeting food again returned %d and %+v", status, budget)
+	}
+
+	budget = apiBudget{}
+	status = request(t, server, http.MethodGet, "/budgets?month=2021-03", nil, &budget)
+	if status != http.StatusOK || len(budget.Envelopes) != 1 || budget.Envelopes[0].Carried != 17500 {
+		t.Errorf("getting March's budget returned %d and %+v", status, budget)
+	}
+	if status := request(t, server, http.MethodGet, "/budgets?month=3/2021", nil, nil); status != http.StatusBadRequest {
+		t.Errorf("getting a budget with an invalid month returned %d", status)
+	}
+	if status := request(t, server, http.MethodPut, "/budgets/", apiAssignment{Assigned: 1}, nil); status != http.StatusNotFound {
+		t.Errorf("budgeting without a category returned %d", status)
+	}
+}
+
+func TestServeWebUI(t *testing.T) {
+	server := newTestServer(t, testStore{})
+
+	// The page itself doesn't need the token.
+	resp, err := server.Client().Get(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("getting the web UI returned %d and %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	// Entries are validated like the add command's arguments.
+	entry := webEntry{Date: "1/9/2021", Entity: "Falafel King", Amount: "-$5.99", Category: "Food"}
+	var created apiTransaction
+	if status := request(t, server, http.MethodPost, "/ui/entry", entry, &created); status != http.StatusCreated {
+		t.Fatalf("adding %+v returned %d", entry, status)
+	}
+	if created.Amount != -599 || created.Date != "2021-01-09" || created.Category != "Food" || created.Display != "-$5.99" {
+		t.Errorf("added %+v from %+v", created, entry)
+	}
+	for _, invalid := range []webEntry{
+		{Date: "2021-01-09", Entity: "Falafel King", Amount: "-5.99"},
+		{Date: "1/9/2021", Entity: "Falafel King", Amount: "five dollars"},
+	} {
+		if status := request(t, server, http.MethodPost, "/ui/entry", invalid, nil); status != http.StatusBadRequest {
+			t.Errorf("adding %+v returned %d", invalid, status)
+		}
+	}
+	// Edits keep the transaction's kind, which the form doesn't have.
+	path := "/transactions/" + strconv.Itoa(created.ID)
+	created.Kind = "transfer"
+	if status := request(t, server, http.MethodPut, path, created, nil); status != http.StatusOK {
+		t.Fatalf("classifying %s as a transfer returned %d", path, status)
+	}
+	edit := entry
+	edit.ID = created.ID
+	edit.Amount = "-6.99"
+	var edited apiTransaction
+	if status := request(t, server, http.MethodPost, "/ui/entry", edit, &edited); status != http.StatusOK || edited.Amount != -699 || edited.ID != created.ID {
+		t.Errorf("editing %+v returned %d and %+v", edit, status, edited)
+	}
+	if edited.Kind != "transfer" {
+		t.Errorf("editing %+v changed its kind to %q", edit, edited.Kind)
+	}
+	edit.ID = 12345
+	if status := request(t, server, http.MethodPost, "/ui/entry", edit, nil); status != http.StatusNotFound {
+		t.Errorf("editing a missing transaction returned %d", status)
+	}
+
+	// Uploads go through the same path as the ingest command.
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, err := form.CreateFormFile("file", "bank.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Write([]byte("1/10/2021,Ramen,-$12.00,\n1/11/2021,Paycheck,\"$1,000.00\",\n"))
+	form.WriteField("account", "Checking")
+	form.Close()
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/ui/upload", &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	resp, err = server.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var uploaded struct{ Added int }
+	err = json.NewDecoder(resp.Body).Decode(&uploaded)
+	resp.Body.Close()
+	if err != nil || resp.StatusCode != http.StatusOK || uploaded.Added != 2 {
+		t.Errorf("uploading a CSV file returned %d, %+v and %v", resp.StatusCode, uploaded, err)
+	}
+	var listed []apiTransaction
+	request(t, server, http.MethodGet, "/transactions?q=ramen", nil, &listed)
+	if len(listed) != 1 || listed[0].Account != "Checking" {
+		t.Errorf("searching for the uploaded ramen returned %+v", listed)
+	}
+
+	var months []webMonth
+	if status := request(t, server, http.MethodGet, "/ui/months?n=3", nil, &months); status != http.StatusOK || len(months) != 3 {
+		t.Errorf("getting 3 months of totals returned %d and %+v", status, months)
+	}
+}
@@ -0,0 +1,137 @@
+package budgeter
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+type settle struct {
+	account       string
+	currency      string
+	date          string
+	transactionID int
+	Out           io.Writer
+	People        PeopleTable
+	Transactions  Table
+}
+
+func newSettle(c *CLI) *settle {
+	result := &settle{}
+	result.Out = c.Out
+	result.People = c.People
+	result.Transactions = c.Transactions
+	return result
+}
+
+func (s settle) Name() string {
+	return "settle"
+}
+
+//go:embed settleUsage.txt
+var settleUsage string
+
+func (s settle) Usage() string {
+	return settleUsage
+}
+
+// settle records someone paying the user back, or the user paying someone
+// back. A repayment is a transaction whose whole amount is shared with the
+// person, so it evens out their balance without counting as spending.
+func (s settle) Run(cmdArgs []string) error {
+	fs := getFlagset(s.Name())
+	fs.StringVar(&s.account, "account", "", "")
+	fs.StringVar(&s.currency, "currency", "", "")
+	fs.StringVar(&s.date, "date", "", "")
+	fs.IntVar(&s.transactionID, "id", 0, "")
+	if err := fs.Parse(cmdArgs); err != nil {
+		return err
+	}
+	args := fs.Args()
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("%s takes a person and an optional amount", s.Name())
+	}
+	p, err := s.People.Find(args[0])
+	if err != nil {
+		return err
+	}
+
+	if s.transactionID != 0 {
+		if len(args) > 1 || s.account != "" || s.currency != "" || s.date != "" {
+			return fmt.Errorf("%s -id only takes a person", s.Name())
+		}
+		tx, err := s.Transactions.Get(s.transactionID)
+		if err != nil {
+			return err
+		}
+		err = s.Transactions.SetShares(tx.ID, []transaction.Share{{Person: p.ID, Amount: tx.Amount}})
+		if err != nil {
+			return err
+		}
+		return s.describe(p.Name, tx)
+	}
+
+	cur, err := lookupCurrency(s.currency)
+	if err != nil {
+		return err
+	}
+	balances, err := s.Transactions.Balances()
+	if err != nil {
+		return err
+	}
+	balance := balances[p.ID][cur.Code]
+	if balance == 0 {
+		return fmt.Errorf("%s and you are already settled up in %s", p.Name, cur.Code)
+	}
+	amount := balance
+	if len(args) == 2 {
+		if amount, err = transaction.ParseAmount(args[1], cur); err != nil {
+			return err
+		}
+		if amount <= 0 {
+			return fmt.Errorf("the amount paid back must be more than 0")
+		}
+		if balance < 0 {
+			amount = -amount
+		}
+	}
+	date := today()
+	if s.date != "" {
+		unix, err := transaction.Unix(s.date)
+		if err != nil {
+			return err
+		}
+		date = time.Unix(unix, 0).UTC()
+	}
+
+	// If they owe the user, the repayment is money coming in, and it's money
+	// going out if the user owes them.
+	tx := transaction.Transaction{
+		Entity:   p.Name,
+		Amount:   amount,
+		Currency: cur.Code,
+		Date:     date.Unix(),
+		Note:     "Settled up",
+		Account:  s.account,
+		Shares:   []transaction.Share{{Person: p.ID, Amount: amount}},
+	}
+	if tx.ID, err = s.Transactions.Insert(tx); err != nil {
+		return err
+	}
+	return s.describe(p.Name, tx)
+}
+
+// describe prints what the repayment "tx" was.
+func (s settle) describe(name string, tx transaction.Transaction) error {
+	amount := tx.Amount.Format(tx.CurrencyInfo())
+	if tx.Amount < 0 {
+		amount = (-tx.Amount).Format(tx.CurrencyInfo())
+		fmt.Fprintf(s.Out, "Transaction #%d is you paying %s back %s.\n", tx.ID, name, amount)
+		return nil
+	}
+	fmt.Fprintf(s.Out, "Transaction #%d is %s paying you back %s.\n", tx.ID, name, amount)
+	return nil
+}
@@ -0,0 +1,177 @@
+package budgeter
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Anthony-Fiddes/budgeter/internal/money"
+	"github.com/Anthony-Fiddes/budgeter/model/currency"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+	"github.com/cheynewallace/tabby"
+)
+
+type share struct {
+	clear        bool
+	Out          io.Writer
+	People       PeopleTable
+	Transactions Table
+}
+
+func newShare(c *CLI) *share {
+	result := &share{}
+	result.Out = c.Out
+	result.People = c.People
+	result.Transactions = c.Transactions
+	return result
+}
+
+func (s share) Name() string {
+	return "share"
+}
+
+//go:embed shareUsage.txt
+var shareUsage string
+
+func (s share) Usage() string {
+	return shareUsage
+}
+
+// share marks the parts of a transaction that belong to other people.
+func (s share) Run(cmdArgs []string) error {
+	fs := getFlagset(s.Name())
+	fs.BoolVar(&s.clear, "c", false, "")
+	if err := fs.Parse(cmdArgs); err != nil {
+		return err
+	}
+	args := fs.Args()
+	if len(args) == 0 {
+		return fmt.Errorf("%s takes a transaction ID", s.Name())
+	}
+	transactionID, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf(
+			"\"%s\" isn't a numerical ID. try `budgeter %s` to see some IDs.", args[0], recent{}.Name(),
+		)
+	}
+	tx, err := s.Transactions.Get(transactionID)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case s.clear:
+		if len(args) > 1 {
+			return fmt.Errorf("%s -c only takes a transaction ID", s.Name())
+		}
+		return s.Transactions.SetShares(transactionID, nil)
+	case len(args) == 1:
+		return s.print(tx)
+	}
+	shares, err := s.split(tx, args[1:])
+	if err != nil {
+		return err
+	}
+	if err := s.Transactions.SetShares(transactionID, shares); err != nil {
+		return err
+	}
+	tx.Shares = shares
+	return s.print(tx)
+}
+
+// split returns the shares of "tx" that "args" describe. Each one is a
+// person's name, optionally followed by "=" and an amount or a percentage of
+// the transaction. The people without an amount split whatever's left evenly
+// with the user.
+func (s share) split(tx transaction.Transaction, args []string) ([]transaction.Share, error) {
+	cur := tx.CurrencyInfo()
+	result := make([]transaction.Share, len(args))
+	var even []int
+	left := tx.Amount
+	for i, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		p, err := s.People.Find(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("%w. try `budgeter %s add`", err, people{}.Name())
+		}
+		result[i].Person = p.ID
+		if len(parts) == 1 {
+			even = append(even, i)
+			continue
+		}
+		amount, err := shareAmount(tx.Amount, parts[1], cur)
+		if err != nil {
+			return nil, err
+		}
+		result[i].Amount = amount
+		if left, err = left.Sub(amount); err != nil {
+			return nil, err
+		}
+	}
+	if len(even) > 0 {
+		// The user's part comes first, so they pay any odd minor units.
+		parts, err := left.Allocate(len(even) + 1)
+		if err != nil {
+			return nil, err
+		}
+		for i, j := range even {
+			result[j].Amount = parts[i+1]
+		}
+	}
+	return result, nil
+}
+
+// shareAmount parses a share of a transaction with the given amount. "s" is
+// either an amount of "cur" or a whole percentage like "50%". The share has
+// the same sign as the transaction.
+func shareAmount(amount transaction.Cent, s string, cur currency.Currency) (transaction.Cent, error) {
+	if strings.HasSuffix(s, "%") {
+		percent, err := strconv.Atoi(strings.TrimSuffix(s, "%"))
+		if err != nil || percent <= 0 || percent > 100 {
+			return 0, fmt.Errorf("\"%s\" must be a whole percentage from 1%% to 100%%", s)
+		}
+		result, err := amount.Mul(int64(percent))
+		if err != nil {
+			return 0, err
+		}
+		return result.Div(100, money.HalfEven)
+	}
+	result, err := transaction.ParseAmount(s, cur)
+	if err != nil {
+		return 0, err
+	}
+	if result < 0 {
+		return 0, fmt.Errorf("a share of \"%s\" can't be negative", s)
+	}
+	if amount < 0 {
+		result = -result
+	}
+	return result, nil
+}
+
+// print prints the shares of "tx" and the part of it that's the user's.
+func (s share) print(tx transaction.Transaction) error {
+	if len(tx.Shares) == 0 {
+		fmt.Fprintf(s.Out, "Transaction #%d isn't shared with anyone.\n", tx.ID)
+		return nil
+	}
+	names, err := personNames(s.People)
+	if err != nil {
+		return err
+	}
+	cur := tx.CurrencyInfo()
+	tab := tabby.NewCustom(newTabWriter(s.Out))
+	tab.AddHeader("Person", "Share")
+	own := tx.Amount
+	for _, sh := range tx.Shares {
+		tab.AddLine(personName(names, sh.Person), align(sh.Amount.Format(cur), sh.Amount < 0))
+		if own, err = own.Sub(sh.Amount); err != nil {
+			return err
+		}
+	}
+	tab.AddLine("You", align(own.Format(cur), own < 0))
+	tab.Print()
+	return nil
+}
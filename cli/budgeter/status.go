@@ -0,0 +1,59 @@
+package budgeter
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+type status struct {
+	Out          io.Writer
+	Transactions Table
+}
+
+func newStatus(c *CLI) *status {
+	return &status{Out: c.Out, Transactions: c.Transactions}
+}
+
+func (s status) Name() string {
+	return "status"
+}
+
+//go:embed statusUsage.txt
+var statusUsage string
+
+func (s status) Usage() string {
+	return statusUsage
+}
+
+// status changes the status of a transaction, e.g. to void it.
+func (s status) Run(cmdArgs []string) error {
+	fs := getFlagset(s.Name())
+	if err := fs.Parse(cmdArgs); err != nil {
+		return err
+	}
+	args := fs.Args()
+	if len(args) != 2 {
+		return fmt.Errorf("%s takes two arguments", s.Name())
+	}
+	txID, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf(
+			"%s takes a numerical ID. try `budgeter %s` to see some IDs.",
+			s.Name(),
+			recent{}.Name(),
+		)
+	}
+	next, err := transaction.ParseStatus(args[1])
+	if err != nil {
+		return err
+	}
+	if err := s.Transactions.SetStatus(txID, next); err != nil {
+		return fmt.Errorf("could not change the status of transaction #%d: %w", txID, err)
+	}
+	fmt.Fprintf(s.Out, "Transaction #%d is %s.\n", txID, next)
+	return nil
+}
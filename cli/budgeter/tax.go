@@ -0,0 +1,341 @@
+package budgeter
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/internal/month"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+	"github.com/cheynewallace/tabby"
+)
+
+const (
+	// taxTagsKey is the config key for the categories and tags that mark
+	// transactions as tax relevant, separated by transaction.TagSeparator.
+	taxTagsKey = "tax_tags"
+	// defaultTaxTags are the categories and tags that mark transactions as tax
+	// relevant when the user hasn't chosen their own.
+	defaultTaxTags = "Charity,Medical,Business"
+)
+
+// taxItem is a tax relevant transaction in a tax report.
+type taxItem struct {
+	tx transaction.Transaction
+	// group is the tax category or tag that the transaction was counted
+	// under.
+	group string
+	// paid is how much the user paid for an expense, in the transaction's
+	// currency. It's their part of it, made positive, so refunds are
+	// negative.
+	paid transaction.Cent
+	// received is how much the user earned, e.g. business income, in the
+	// transaction's currency. It's their part of it.
+	received transaction.Cent
+	// income is whether the transaction is income, so that what the user
+	// received is shown instead of what they paid.
+	income bool
+	// files is how many files are attached to the transaction.
+	files int
+}
+
+// amounts returns what was paid and received in the item's currency, padded to
+// line up in a table. Only one of them is set.
+func (item taxItem) amounts() (paid, received string) {
+	cur := item.tx.CurrencyInfo()
+	if item.income {
+		return "", align(item.received.Format(cur), item.received < 0)
+	}
+	return align(item.paid.Format(cur), item.paid < 0), ""
+}
+
+// taxGroup is the summary of one tax category or tag in a tax report.
+type taxGroup struct {
+	name  string
+	count int
+	// paid and received are in the home currency.
+	paid     transaction.Cent
+	received transaction.Cent
+	// missing is how many of the transactions have no files attached, e.g.
+	// no receipt.
+	missing int
+}
+
+// taxGroupOf returns the first of "tags" that "tx" has as its category or as
+// one of its tags, or "" if it has none of them.
+func taxGroupOf(tx transaction.Transaction, tags []string) string {
+	for _, tag := range tags {
+		if strings.EqualFold(tx.Category, tag) || tx.HasTag(tag) {
+			return tag
+		}
+	}
+	return ""
+}
+
+// loadTaxTags returns the categories and tags that mark transactions as tax
+// relevant.
+func loadTaxTags(config Store) ([]string, error) {
+	tags, err := config.Get(taxTagsKey)
+	if err != nil {
+		return nil, err
+	}
+	if tags == "" {
+		tags = defaultTaxTags
+	}
+	return transaction.ParseTags(tags), nil
+}
+
+// saveTaxTags shows the categories and tags that mark transactions as tax
+// relevant or, if "list" isn't empty, replaces them.
+func (r report) saveTaxTags(list string) error {
+	if list == "" {
+		tags, err := loadTaxTags(r.Config)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(r.Out, strings.Join(tags, ", "))
+		return nil
+	}
+	tags := transaction.ParseTags(list)
+	if len(tags) == 0 {
+		return fmt.Errorf("%s tax tags must name at least one category or tag", r.Name())
+	}
+	return r.Config.Put(taxTagsKey, strings.Join(tags, transaction.TagSeparator))
+}
+
+// tax totals the user's tax relevant transactions in a year, lists each of
+// them with whether it has files attached, and exports them to CSV if asked.
+func (r report) tax(args []string) error {
+	fs := getFlagset(r.Name() + " tax")
+	fs.IntVar(&r.year, "year", today().Year(), "")
+	fs.StringVar(&r.taxTags, "tags", "", "")
+	fs.StringVar(&r.output, "o", "", "")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 && fs.Arg(0) == "tags" {
+		if fs.NArg() > 2 {
+			return fmt.Errorf("%s tax tags takes one argument", r.Name())
+		}
+		return r.saveTaxTags(fs.Arg(1))
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("%s tax has no subcommand \"%s\"", r.Name(), fs.Arg(0))
+	}
+	if r.output != "" && strings.ToLower(filepath.Ext(r.output)) != extCSV {
+		return fmt.Errorf("%s tax can only export to %s files", r.Name(), extCSV)
+	}
+	var tags []string
+	if r.taxTags != "" {
+		// The tags given for one report aren't saved. See saveTaxTags.
+		if tags = transaction.ParseTags(r.taxTags); len(tags) == 0 {
+			return fmt.Errorf("-tags must name at least one category or tag")
+		}
+	} else {
+		var err error
+		if tags, err = loadTaxTags(r.Config); err != nil {
+			return err
+		}
+	}
+
+	items, groups, err := r.taxItems(tags)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(r.Out, "Tax report for %d: %s\n\n", r.year, strings.Join(tags, ", "))
+	if len(items) == 0 {
+		fmt.Fprintln(r.Out, "There are no tax relevant transactions.")
+		return nil
+	}
+	tab := tabby.NewCustom(newTabWriter(r.Out))
+	tab.AddHeader("ID", "Date", "Entity", "Paid", "Received", "Note", "Group", "Files")
+	for _, item := range items {
+		files := ""
+		if item.files > 0 {
+			files = strconv.Itoa(item.files)
+		}
+		paid, received := item.amounts()
+		tab.AddLine(item.tx.ID, item.tx.DateString(), item.tx.Entity, paid, received, item.tx.Note, item.group, files)
+	}
+	tab.Print()
+	fmt.Fprintln(r.Out)
+
+	total, err := taxTotal(groups)
+	if err != nil {
+		return err
+	}
+	groups = append(groups, total)
+	tab = tabby.NewCustom(newTabWriter(r.Out))
+	tab.AddHeader("Group", "Transactions", "Paid", "Received", "Missing Files")
+	for _, g := range groups {
+		tab.AddLine(g.name, g.count, alignAmount(g.paid), alignAmount(g.received), g.missing)
+	}
+	tab.Print()
+
+	if r.output == "" {
+		return nil
+	}
+	summaryPath := strings.TrimSuffix(r.output, filepath.Ext(r.output)) + "-summary" + extCSV
+	if err := writeCSVFile(r.output, func(w io.Writer) error { return writeTaxItems(w, items) }); err != nil {
+		return err
+	}
+	if err := writeCSVFile(summaryPath, func(w io.Writer) error { return writeTaxGroups(w, groups) }); err != nil {
+		return err
+	}
+	fmt.Fprintf(r.Out, "\nExported the transactions to %s and the summary to %s\n", r.output, summaryPath)
+	return nil
+}
+
+// taxItems returns the tax relevant transactions of the report's year in
+// chronological order, and a summary of each of "tags" in the same order.
+// Void transactions and transfers between the user's own accounts aren't
+// counted.
+func (r report) taxItems(tags []string) ([]taxItem, []taxGroup, error) {
+	start := time.Date(r.year, time.January, 1, 0, 0, 0, 0, timezone)
+	end := month.End(time.Date(r.year, time.December, 1, 0, 0, 0, 0, timezone))
+	rows, err := r.Transactions.Range(start, end, -1)
+	if err != nil {
+		return nil, nil, err
+	}
+	transactions, err := rows.ScanSet()
+	if err != nil {
+		return nil, nil, err
+	}
+	attached, err := r.Attachments.Counts()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	groups := make([]taxGroup, len(tags))
+	for i, tag := range tags {
+		groups[i].name = tag
+	}
+	var items []taxItem
+	for _, tx := range transactions {
+		group := taxGroupOf(tx, tags)
+		if group == "" || tx.Status == transaction.Void || tx.Classify() == transaction.Transfer {
+			continue
+		}
+		// Only the user's part of a shared transaction is theirs to claim.
+		if tx, err = r.Transactions.Get(tx.ID); err != nil {
+			return nil, nil, err
+		}
+		own, err := tx.OwnAmount()
+		if err != nil {
+			return nil, nil, err
+		}
+		item := taxItem{tx: tx, group: group, files: attached[tx.ID]}
+		if tx.Classify() == transaction.Income {
+			item.income = true
+			item.received = own
+		} else {
+			item.paid = -own
+		}
+		items = append(items, item)
+
+		date := time.Unix(tx.Date, 0)
+		paid, err := toHome(r.Rates, item.paid, tx.Currency, date)
+		if err != nil {
+			return nil, nil, err
+		}
+		received, err := toHome(r.Rates, item.received, tx.Currency, date)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i := range groups {
+			g := &groups[i]
+			if g.name != group {
+				continue
+			}
+			g.count++
+			if g.paid, err = g.paid.Add(paid); err != nil {
+				return nil, nil, err
+			}
+			if g.received, err = g.received.Add(received); err != nil {
+				return nil, nil, err
+			}
+			if item.files == 0 {
+				g.missing++
+			}
+		}
+	}
+	return items, groups, nil
+}
+
+// taxTotal returns the total of the groups in a tax report.
+func taxTotal(groups []taxGroup) (taxGroup, error) {
+	result := taxGroup{name: "Total"}
+	for _, g := range groups {
+		var err error
+		if result.paid, err = result.paid.Add(g.paid); err != nil {
+			return taxGroup{}, err
+		}
+		if result.received, err = result.received.Add(g.received); err != nil {
+			return taxGroup{}, err
+		}
+		result.count += g.count
+		result.missing += g.missing
+	}
+	return result, nil
+}
+
+// writeCSVFile creates or overwrites the file at "path" with what "write"
+// writes to it.
+func writeCSVFile(path string, write func(w io.Writer) error) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("could not write \"%s\": %w", path, err)
+	}
+	return f.Close()
+}
+
+// writeTaxItems writes the transactions of a tax report as CSV with a heading.
+func writeTaxItems(w io.Writer, items []taxItem) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"ID", "Date", "Entity", "Paid", "Received", "Currency", "Note", "Category", "Tags", "Group", "Files"})
+	for _, item := range items {
+		tx := item.tx
+		paid, received := "", ""
+		if item.income {
+			received = item.received.Format(tx.CurrencyInfo())
+		} else {
+			paid = item.paid.Format(tx.CurrencyInfo())
+		}
+		cw.Write([]string{
+			strconv.Itoa(tx.ID),
+			tx.DateString(),
+			tx.Entity,
+			paid,
+			received,
+			tx.CurrencyInfo().Code,
+			tx.Note,
+			tx.Category,
+			strings.Join(tx.Tags, transaction.TagSeparator),
+			item.group,
+			strconv.Itoa(item.files),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeTaxGroups writes the summary of a tax report as CSV with a heading.
+// Amounts are in the home currency.
+func writeTaxGroups(w io.Writer, groups []taxGroup) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"Group", "Transactions", "Paid", "Received", "Missing Files"})
+	for _, g := range groups {
+		cw.Write([]string{g.name, strconv.Itoa(g.count), g.paid.String(), g.received.String(), strconv.Itoa(g.missing)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
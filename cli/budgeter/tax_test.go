@@ -0,0 +1,298 @@
+package budgeter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/model/attachment"
+	"github.com/Anthony-Fiddes/budgeter/model/currency"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+// newTestReport returns a report on 2021 backed by an in-memory database with
+// the given transactions in it and a rate of 0.01 dollars per yen. The first
+// transaction has a receipt attached.
+func newTestReport(t *testing.T, transactions ...transaction.Transaction) report {
+	t.Helper()
+	db := newTestDB(t)
+	table := &transaction.Table{DB: db}
+	if err := table.Init(); err != nil {
+		t.Fatal(err)
+	}
+	rates := &currency.Table{DB: db}
+	if err := rates.Init(); err != nil {
+		t.Fatal(err)
+	}
+	rate := currency.Rate{Date: date(t, "1/1/2021"), From: "JPY", To: "USD", Rate: 0.01}
+	if err := rates.Put(rate); err != nil {
+		t.Fatal(err)
+	}
+	attachments := &attachment.Table{DB: db}
+	if err := attachments.Init(); err != nil {
+		t.Fatal(err)
+	}
+	for i, tx := range transactions {
+		id, err := table.Insert(tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i > 0 {
+			continue
+		}
+		if _, err := attachments.Add(id, "receipt.pdf", []byte("receipt"), time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return report{
+		year:         2021,
+		Attachments:  attachments,
+		Config:       testStore{},
+		Rates:        rates,
+		Transactions: table,
+	}
+}
+
+// date returns the Unix time of a date in M/D/YYYY format.
+func date(t *testing.T, s string) int64 {
+	t.Helper()
+	result, err := transaction.Unix(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestTaxGroupOf(t *testing.T) {
+	tags := []string{"Charity", "Medical"}
+	tests := []struct {
+		name string
+		tx   transaction.Transaction
+		want string
+	}{
+		{name: "category", tx: transaction.Transaction{Category: "charity"}, want: "Charity"},
+		{name: "tag", tx: transaction.Transaction{Tags: []string{"Vacation", "medical"}}, want: "Medical"},
+		{
+			name: "first of both",
+			tx:   transaction.Transaction{Category: "Medical", Tags: []string{"Charity"}},
+			want: "Charity",
+		},
+		{name: "neither", tx: transaction.Transaction{Category: "Groceries", Tags: []string{"Vacation"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := taxGroupOf(test.tx, tags); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTaxItems(t *testing.T) {
+	type item struct {
+		entity   string
+		group    string
+		paid     transaction.Cent
+		received transaction.Cent
+		files    int
+	}
+	tests := []struct {
+		name         string
+		transactions []transaction.Transaction
+		items        []item
+		groups       []taxGroup
+	}{
+		{
+			name: "expenses and refunds",
+			transactions: []transaction.Transaction{
+				{Date: date(t, "2/1/2021"), Entity: "Red Cross", Amount: -5000, Category: "Charity"},
+				{Date: date(t, "3/1/2021"), Entity: "Pharmacy", Amount: -2000, Tags: []string{"Medical"}},
+				{Date: date(t, "3/2/2021"), Entity: "Pharmacy", Amount: 500, Kind: transaction.Expense, Category: "Medical"},
+				{Date: date(t, "3/3/2021"), Entity: "Grocer", Amount: -4000, Category: "Groceries"},
+			},
+			items: []item{
+				{entity: "Red Cross", group: "Charity", paid: 5000, files: 1},
+				{entity: "Pharmacy", group: "Medical", paid: 2000},
+				{entity: "Pharmacy", group: "Medical", paid: -500},
+			},
+			groups: []taxGroup{
+				{name: "Charity", count: 1, paid: 5000},
+				{name: "Medical", count: 2, paid: 1500, missing: 2},
+				{name: "Business"},
+			},
+		},
+		{
+			name: "income and transfers",
+			transactions: []transaction.Transaction{
+				{Date: date(t, "2/1/2021"), Entity: "Client", Amount: 100000, Category: "Business"},
+				{Date: date(t, "2/2/2021"), Entity: "Printer", Amount: -3000, Category: "Business"},
+				{
+					Date: date(t, "2/3/2021"), Entity: "Business Savings", Amount: -50000, Category: "Business",
+					Kind: transaction.Transfer,
+				},
+			},
+			items: []item{
+				{entity: "Client", group: "Business", received: 100000, files: 1},
+				{entity: "Printer", group: "Business", paid: 3000},
+			},
+			groups: []taxGroup{
+				{name: "Charity"},
+				{name: "Medical"},
+				{name: "Business", count: 2, paid: 3000, received: 100000, missing: 1},
+			},
+		},
+		{
+			name: "shares, void transactions and other years",
+			transactions: []transaction.Transaction{
+				{
+					Date: date(t, "4/1/2021"), Entity: "Hospital", Amount: -9000, Category: "Medical",
+					Shares: []transaction.Share{{Person: 1, Amount: -3000}},
+				},
+				{Date: date(t, "4/2/2021"), Entity: "Hospital", Amount: -1000, Category: "Medical", Status: transaction.Void},
+				{Date: date(t, "1/1/2022"), Entity: "Red Cross", Amount: -5000, Category: "Charity"},
+			},
+			items: []item{{entity: "Hospital", group: "Medical", paid: 6000, files: 1}},
+			groups: []taxGroup{
+				{name: "Charity"},
+				{name: "Medical", count: 1, paid: 6000},
+				{name: "Business"},
+			},
+		},
+		{
+			name: "other currencies",
+			transactions: []transaction.Transaction{
+				{Date: date(t, "5/1/2021"), Entity: "Temple", Amount: -3000, Currency: "JPY", Category: "Charity"},
+				{Date: date(t, "5/2/2021"), Entity: "Red Cross", Amount: -5000, Category: "Charity"},
+			},
+			items: []item{
+				{entity: "Temple", group: "Charity", paid: 3000, files: 1},
+				{entity: "Red Cross", group: "Charity", paid: 5000},
+			},
+			groups: []taxGroup{
+				{name: "Charity", count: 2, paid: 8000, missing: 1},
+				{name: "Medical"},
+				{name: "Business"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := newTestReport(t, test.transactions...)
+			items, groups, err := r.taxItems([]string{"Charity", "Medical", "Business"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []item
+			for _, i := range items {
+				got = append(got, item{i.tx.Entity, i.group, i.paid, i.received, i.files})
+			}
+			if len(got) != len(test.items) {
+				t.Fatalf("got items %+v, want %+v", got, test.items)
+			}
+			for i := range got {
+				if got[i] != test.items[i] {
+					t.Errorf("got items %+v, want %+v", got, test.items)
+					break
+				}
+			}
+			if len(groups) != len(test.groups) {
+				t.Fatalf("got groups %+v, want %+v", groups, test.groups)
+			}
+			for i := range groups {
+				if groups[i] != test.groups[i] {
+					t.Errorf("got groups %+v, want %+v", groups, test.groups)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestWriteTaxItems(t *testing.T) {
+	items := []taxItem{
+		{
+			tx: transaction.Transaction{
+				ID: 1, Date: date(t, "2/1/2021"), Entity: "Red Cross", Amount: -5000,
+				Category: "Charity", Tags: []string{"Donation", "Yearly"},
+			},
+			group: "Charity",
+			paid:  5000,
+			files: 1,
+		},
+		{
+			tx:       transaction.Transaction{ID: 2, Date: date(t, "2/2/2021"), Entity: "Client", Amount: 100000, Note: "Invoice #4"},
+			group:    "Business",
+			received: 100000,
+			income:   true,
+		},
+		{
+			tx: transaction.Transaction{
+				ID: 3, Date: date(t, "2/3/2021"), Entity: "Temple", Amount: 3000, Currency: "JPY", Kind: transaction.Expense,
+			},
+			group: "Charity",
+			paid:  -3000,
+		},
+	}
+	want := "ID,Date,Entity,Paid,Received,Currency,Note,Category,Tags,Group,Files\n" +
+		"1,2/1/2021,Red Cross,$50.00,,USD,,Charity,\"Donation,Yearly\",Charity,1\n" +
+		"2,2/2/2021,Client,,\"$1,000.00\",USD,Invoice #4,,,Business,0\n" +
+		"3,2/3/2021,Temple,\"-¥3,000\",,JPY,,,,Charity,0\n"
+	var got strings.Builder
+	if err := writeTaxItems(&got, items); err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got.String(), want)
+	}
+}
+
+func TestWriteTaxGroups(t *testing.T) {
+	groups := []taxGroup{
+		{name: "Charity", count: 2, paid: 8000, missing: 1},
+		{name: "Business", count: 2, paid: 3000, received: 100000},
+		{name: "Total", count: 4, paid: 11000, received: 100000, missing: 1},
+	}
+	want := "Group,Transactions,Paid,Received,Missing Files\n" +
+		"Charity,2,$80.00,$0.00,1\n" +
+		"Business,2,$30.00,\"$1,000.00\",0\n" +
+		"Total,4,$110.00,\"$1,000.00\",1\n"
+	var got strings.Builder
+	if err := writeTaxGroups(&got, groups); err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got.String(), want)
+	}
+}
+
+func TestTaxTags(t *testing.T) {
+	r := newTestReport(t, transaction.Transaction{Date: date(t, "2/1/2021"), Entity: "Gym", Amount: -3000, Category: "Fitness"})
+	config := r.Config.(testStore)
+	var out strings.Builder
+	r.Out = &out
+
+	// Tags given for one report aren't saved.
+	if err := r.tax([]string{"-year", "2021", "-tags", "Fitness"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Gym") {
+		t.Errorf("expected the report to include the gym but got:\n%s", out.String())
+	}
+	if tags, ok := config[taxTagsKey]; ok {
+		t.Errorf("expected -tags not to be saved but %q was", tags)
+	}
+
+	if err := r.tax([]string{"tags", "Fitness, Medical"}); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := r.tax([]string{"tags"}); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "Fitness, Medical\n" {
+		t.Errorf("expected the saved tags but got %q", out.String())
+	}
+	if err := r.tax([]string{"tags", ","}); err == nil {
+		t.Error("expected an error saving no tags")
+	}
+}
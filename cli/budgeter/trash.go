@@ -0,0 +1,198 @@
+package budgeter
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/internal/inpt"
+	"github.com/cheynewallace/tabby"
+)
+
+const (
+	// trashRetentionKey is the config key for the number of days that
+	// transactions are kept in the trash before they're purged.
+	trashRetentionKey = "trash_retention"
+	// defaultTrashRetention is the number of days that transactions are kept
+	// in the trash if the config doesn't say.
+	defaultTrashRetention = 30
+)
+
+// trashRetention returns the number of days that transactions are kept in the
+// trash, or 0 if they're kept until they're purged by hand.
+func trashRetention(config Store) (int, error) {
+	value, err := config.Get(trashRetentionKey)
+	if err != nil || value == "" {
+		return defaultTrashRetention, err
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 0 {
+		return 0, fmt.Errorf("\"%s\" in your config must be a number of days", trashRetentionKey)
+	}
+	return days, nil
+}
+
+// purgeTrash purges the transactions that have been in c's trash for longer
+// than the retention period, along with their attachments.
+func purgeTrash(c *CLI) error {
+	days, err := trashRetention(c.Config)
+	if err != nil || days == 0 {
+		return err
+	}
+	n, err := c.Transactions.Purge(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return nil
+	}
+	fmt.Fprintf(c.Out, "Purged %d transactions that were in the trash for more than %d days.\n", n, days)
+	_, err = c.Attachments.Prune()
+	return err
+}
+
+type trash struct {
+	limit        int
+	confirmed    bool
+	in           *inpt.Scanner
+	Attachments  AttachmentTable
+	Config       Store
+	Out          io.Writer
+	Transactions Table
+}
+
+func newTrash(c *CLI) *trash {
+	result := &trash{}
+	result.in = c.in
+	result.Attachments = c.Attachments
+	result.Config = c.Config
+	result.Out = c.Out
+	result.Transactions = c.Transactions
+	return result
+}
+
+func (t trash) Name() string {
+	return "trash"
+}
+
+//go:embed trashUsage.txt
+var trashUsage string
+
+func (t trash) Usage() string {
+	return trashUsage
+}
+
+// trash lists, restores and purges removed transactions.
+func (t trash) Run(cmdArgs []string) error {
+	const defaultTrashLimit = 20
+
+	fs := getFlagset(t.Name())
+	fs.IntVar(&t.limit, "l", defaultTrashLimit, "")
+	if err := fs.Parse(cmdArgs); err != nil {
+		return err
+	}
+	args := fs.Args()
+	if len(args) == 0 {
+		return t.list()
+	}
+
+	subArgs := args[1:]
+	switch args[0] {
+	case "restore":
+		if len(subArgs) == 0 {
+			return fmt.Errorf("%s restore takes at least one ID", t.Name())
+		}
+		for _, arg := range subArgs {
+			txID, err := strconv.Atoi(arg)
+			if err != nil {
+				return fmt.Errorf(
+					"%s restore takes numerical IDs. try `budgeter %s` to see some IDs.",
+					t.Name(),
+					t.Name(),
+				)
+			}
+			if err := t.Transactions.Recover(txID); err != nil {
+				return fmt.Errorf("could not restore transaction #%d: %w", txID, err)
+			}
+			fmt.Fprintf(t.Out, "Restored transaction #%d.\n", txID)
+		}
+		return nil
+	case "purge":
+		fs := getFlagset(t.Name() + " purge")
+		fs.BoolVar(&t.confirmed, "y", false, "")
+		if err := fs.Parse(subArgs); err != nil {
+			return err
+		}
+		if len(fs.Args()) != 0 {
+			return fmt.Errorf("%s purge takes no arguments", t.Name())
+		}
+		return t.purge()
+	case "retention":
+		if len(subArgs) > 1 {
+			return fmt.Errorf("%s retention takes at most one argument", t.Name())
+		}
+		if len(subArgs) == 0 {
+			days, err := trashRetention(t.Config)
+			if err != nil {
+				return err
+			}
+			if days == 0 {
+				fmt.Fprintln(t.Out, "Transactions are kept in the trash until it's purged.")
+			} else {
+				fmt.Fprintf(t.Out, "Transactions are kept in the trash for %d days.\n", days)
+			}
+			return nil
+		}
+		days, err := strconv.Atoi(subArgs[0])
+		if err != nil || days < 0 {
+			return fmt.Errorf("%s retention takes a number of days", t.Name())
+		}
+		return t.Config.Put(trashRetentionKey, strconv.Itoa(days))
+	default:
+		return fmt.Errorf("%s has no subcommand \"%s\"", t.Name(), args[0])
+	}
+}
+
+// list prints the most recently removed transactions.
+func (t trash) list() error {
+	rows, err := t.Transactions.Trash(t.limit)
+	if err != nil {
+		return err
+	}
+	trashed, err := rows.ScanSet()
+	if err != nil {
+		return err
+	}
+	if len(trashed) == 0 {
+		fmt.Fprintln(t.Out, "The trash is empty.")
+		return nil
+	}
+	tab := tabby.NewCustom(newTabWriter(t.Out))
+	tab.AddHeader("ID", "Removed", "Date", "Entity", "Amount", "Note")
+	for _, tx := range trashed {
+		removed := time.Unix(tx.Deleted, 0).In(timezone).Format("2006-01-02 15:04")
+		tab.AddLine(tx.ID, removed, tx.DateString(), tx.Entity, alignTxAmount(tx), tx.Note)
+	}
+	tab.Print()
+	return nil
+}
+
+// purge deletes everything in the trash for good, along with its attachments.
+func (t trash) purge() error {
+	if !t.confirmed {
+		fmt.Fprint(t.Out, "This will delete everything in the trash for good. Continue? (y/[n]) ")
+		ok, err := t.in.Confirm()
+		if err != nil || !ok {
+			return err
+		}
+	}
+	n, err := t.Transactions.Purge(time.Now().Add(time.Second))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(t.Out, "Purged %d transactions.\n", n)
+	_, err = t.Attachments.Prune()
+	return err
+}
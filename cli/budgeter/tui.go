@@ -0,0 +1,697 @@
+package budgeter
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Anthony-Fiddes/budgeter/internal/term"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+type tui struct {
+	search       string
+	in           io.Reader
+	Out          io.Writer
+	Rates        RateTable
+	Transactions Table
+}
+
+func newTUI(c *CLI) *tui {
+	result := &tui{}
+	result.in = c.In
+	result.Out = c.Out
+	result.Rates = c.Rates
+	result.Transactions = c.Transactions
+	return result
+}
+
+func (t tui) Name() string {
+	return "tui"
+}
+
+//go:embed tuiUsage.txt
+var tuiUsage string
+
+func (t tui) Usage() string {
+	return tuiUsage
+}
+
+// tui runs a full-screen interface for browsing and editing transactions
+// until the user quits.
+func (t tui) Run(cmdArgs []string) error {
+	fs := getFlagset(t.Name())
+	fs.StringVar(&t.search, "s", "", "")
+	if err := fs.Parse(cmdArgs); err != nil {
+		return err
+	}
+	if len(fs.Args()) > 0 {
+		return fmt.Errorf("%s takes no arguments", t.Name())
+	}
+	f, ok := t.in.(*os.File)
+	if !ok {
+		return fmt.Errorf("%s must be run in a terminal", t.Name())
+	}
+
+	m := newTUIModel(t.Rates, t.Transactions)
+	m.filter = t.search
+	if err := m.load(); err != nil {
+		return err
+	}
+	restore, err := term.MakeRaw(f)
+	if err != nil {
+		return fmt.Errorf("%s must be run in a terminal: %w", t.Name(), err)
+	}
+	defer restore()
+	fmt.Fprint(t.Out, term.AltScreen+term.HideCursor)
+	defer fmt.Fprint(t.Out, term.ShowCursor+term.MainScreen)
+
+	keys := bufio.NewReader(f)
+	for {
+		if width, height, err := term.Size(f); err == nil {
+			m.resize(width, height)
+		}
+		t.draw(m.view())
+		key, err := term.ReadKey(keys)
+		if err != nil {
+			return err
+		}
+		if m.handleKey(key) {
+			return nil
+		}
+	}
+}
+
+// draw writes the lines of the screen over the last ones. Raw mode doesn't
+// turn "\n" into "\r\n", so each line returns the cursor itself.
+func (t tui) draw(lines []string) {
+	var screen strings.Builder
+	screen.WriteString(term.Home)
+	for i, line := range lines {
+		if i > 0 {
+			screen.WriteString("\r\n")
+		}
+		screen.WriteString(line)
+		screen.WriteString(term.ClearLine)
+	}
+	screen.WriteString(term.ClearDown)
+	fmt.Fprint(t.Out, screen.String())
+}
+
+// tuiField is a column of the tui's transaction list.
+type tuiField struct {
+	name string
+	// width is the column's width, or 0 if it shares the space that the
+	// other columns leave.
+	width int
+	// right is whether the column is aligned to the right.
+	right bool
+	get   func(transaction.Transaction) string
+	// set parses a value typed by the user into the field. It's nil if the
+	// field can't be edited.
+	set func(*transaction.Transaction, string) error
+}
+
+var tuiFields = []tuiField{
+	{
+		name:  transaction.IDCol,
+		width: 6,
+		right: true,
+		get:   func(tx transaction.Transaction) string { return strconv.Itoa(tx.ID) },
+	},
+	{
+		name:  transaction.DateCol,
+		width: 10,
+		get:   transaction.Transaction.DateString,
+		set: func(tx *transaction.Transaction, value string) (err error) {
+			tx.Date, err = transaction.Unix(value)
+			return err
+		},
+	},
+	{
+		name: transaction.EntityCol,
+		get:  func(tx transaction.Transaction) string { return tx.Entity },
+		set: func(tx *transaction.Transaction, value string) error {
+			if value == "" {
+				return errors.New("the entity can't be empty")
+			}
+			tx.Entity = value
+			return nil
+		},
+	},
+	{
+		name:  transaction.AmountCol,
+		width: 13,
+		right: true,
+		get:   alignTxAmount,
+		set: func(tx *transaction.Transaction, value string) (err error) {
+			tx.Amount, tx.Currency, err = parseAmount(value, tx.Currency)
+			return err
+		},
+	},
+	{
+		name:  transaction.CategoryCol,
+		width: 14,
+		get:   func(tx transaction.Transaction) string { return tx.Category },
+		set: func(tx *transaction.Transaction, value string) error {
+			tx.Category = value
+			return nil
+		},
+	},
+	{
+		name:  transaction.AccountCol,
+		width: 12,
+		get:   func(tx transaction.Transaction) string { return tx.Account },
+		set: func(tx *transaction.Transaction, value string) error {
+			tx.Account = value
+			return nil
+		},
+	},
+	{
+		name: transaction.NoteCol,
+		get:  func(tx transaction.Transaction) string { return tx.Note },
+		set: func(tx *transaction.Transaction, value string) error {
+			tx.Note = value
+			return nil
+		},
+	},
+}
+
+// tuiMode is what the tui's keys currently do.
+type tuiMode int
+
+const (
+	// tuiBrowse moves around the list and selects transactions.
+	tuiBrowse tuiMode = iota
+	// tuiFilter types the search that filters the list.
+	tuiFilter
+	// tuiEdit types a new value for a field of the current transaction.
+	tuiEdit
+	// tuiCategorize types a category for the selected transactions.
+	tuiCategorize
+	// tuiConfirmDelete asks whether to delete the selected transactions.
+	tuiConfirmDelete
+)
+
+const (
+	// tuiMarkWidth is the width of the column that marks selected
+	// transactions.
+	tuiMarkWidth = 2
+	// tuiChrome is how many lines of the screen aren't part of the list: the
+	// header, the status line and the prompt line.
+	tuiChrome = 3
+	tuiHelp   = "↑↓ move  ←→ field  enter edit  space select  a all  / filter  c categorize  d delete  q quit"
+)
+
+// tuiModel is the state of the tui, separate from the terminal so that it can
+// be driven by key presses in tests.
+type tuiModel struct {
+	Rates        RateTable
+	Transactions Table
+
+	width, height int
+	// filter is the search that the list is filtered by.
+	filter       string
+	transactions []transaction.Transaction
+	// cursor is the index of the current transaction, and offset is the index
+	// of the first one on screen.
+	cursor, offset int
+	// column is the index in tuiFields of the current field.
+	column int
+	// selected holds the IDs of the selected transactions.
+	selected map[int]bool
+	mode     tuiMode
+	// input is the text being typed in the filter, edit and categorize modes.
+	input []rune
+	// message is shown on the last line until the next key press.
+	message string
+	// total is the total of the filtered transactions in the home currency.
+	total string
+}
+
+func newTUIModel(rates RateTable, transactions Table) *tuiModel {
+	return &tuiModel{
+		Rates:        rates,
+		Transactions: transactions,
+		width:        80,
+		height:       24,
+		column:       tuiColumn(transaction.CategoryCol),
+		selected:     map[int]bool{},
+	}
+}
+
+// tuiColumn returns the index of the field with the given name.
+func tuiColumn(name string) int {
+	for i, f := range tuiFields {
+		if f.name == name {
+			return i
+		}
+	}
+	panic("budgeter: no tui field named " + name)
+}
+
+// load reloads the transactions that match the filter, keeping the cursor and
+// selection where they can be.
+func (m *tuiModel) load() error {
+	rows, err := m.Transactions.Search(m.filter, -1)
+	if err != nil {
+		return err
+	}
+	m.transactions, err = rows.ScanSet()
+	if err != nil {
+		return err
+	}
+
+	present := map[int]bool{}
+	for _, tx := range m.transactions {
+		present[tx.ID] = true
+	}
+	for id := range m.selected {
+		if !present[id] {
+			delete(m.selected, id)
+		}
+	}
+	m.moveTo(m.cursor)
+
+	total, err := m.sum(func(transaction.Transaction) bool { return true })
+	if err != nil {
+		m.total = "unknown (" + err.Error() + ")"
+	} else {
+		m.total = total.String()
+	}
+	return nil
+}
+
+// sum totals the transactions in the list that "include" returns true for,
+// in the home currency.
+func (m *tuiModel) sum(include func(transaction.Transaction) bool) (transaction.Cent, error) {
+	totals := map[string]transaction.Cent{}
+	for _, tx := range m.transactions {
+		if !include(tx) {
+			continue
+		}
+		var err error
+		totals[tx.Currency], err = totals[tx.Currency].Add(tx.Amount)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return totalToHome(m.Rates, totals, now())
+}
+
+// resize sets the size of the screen.
+func (m *tuiModel) resize(width, height int) {
+	m.width, m.height = width, height
+	m.moveTo(m.cursor)
+}
+
+// listHeight returns how many transactions fit on screen.
+func (m *tuiModel) listHeight() int {
+	if m.height <= tuiChrome {
+		return 1
+	}
+	return m.height - tuiChrome
+}
+
+// moveTo moves the cursor to the transaction at index "i", within the bounds
+// of the list, and scrolls so that it's on screen.
+func (m *tuiModel) moveTo(i int) {
+	if i >= len(m.transactions) {
+		i = len(m.transactions) - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	m.cursor = i
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+m.listHeight() {
+		m.offset = m.cursor - m.listHeight() + 1
+	}
+}
+
+// current returns the transaction under the cursor.
+func (m *tuiModel) current() (transaction.Transaction, bool) {
+	if len(m.transactions) == 0 {
+		return transaction.Transaction{}, false
+	}
+	return m.transactions[m.cursor], true
+}
+
+// targets returns the transactions that bulk actions apply to: the selected
+// ones, or the current one if none are selected.
+func (m *tuiModel) targets() []transaction.Transaction {
+	var result []transaction.Transaction
+	for _, tx := range m.transactions {
+		if m.selected[tx.ID] {
+			result = append(result, tx)
+		}
+	}
+	if len(result) == 0 {
+		if tx, ok := m.current(); ok {
+			result = append(result, tx)
+		}
+	}
+	return result
+}
+
+// handleKey updates the model after a key press. It returns true when the
+// user quits.
+func (m *tuiModel) handleKey(key string) bool {
+	m.message = ""
+	switch m.mode {
+	case tuiFilter:
+		m.handleFilter(key)
+	case tuiEdit:
+		m.handleInput(key, m.edit)
+	case tuiCategorize:
+		m.handleInput(key, m.categorize)
+	case tuiConfirmDelete:
+		m.mode = tuiBrowse
+		if key == "y" || key == "Y" {
+			m.remove()
+		} else {
+			m.message = "Nothing was deleted."
+		}
+	default:
+		return m.handleBrowse(key)
+	}
+	return false
+}
+
+func (m *tuiModel) handleBrowse(key string) bool {
+	page := m.listHeight()
+	switch key {
+	case "q", term.KeyCtrlC:
+		return true
+	case term.KeyUp, "k":
+		m.moveTo(m.cursor - 1)
+	case term.KeyDown, "j":
+		m.moveTo(m.cursor + 1)
+	case term.KeyPageUp:
+		m.moveTo(m.cursor - page)
+	case term.KeyPageDown:
+		m.moveTo(m.cursor + page)
+	case term.KeyHome, "g":
+		m.moveTo(0)
+	case term.KeyEnd, "G":
+		m.moveTo(len(m.transactions) - 1)
+	case term.KeyLeft, "h":
+		if m.column > 0 {
+			m.column--
+		}
+	case term.KeyRight, "l", term.KeyTab:
+		if m.column < len(tuiFields)-1 {
+			m.column++
+		}
+	case " ":
+		if tx, ok := m.current(); ok {
+			if m.selected[tx.ID] {
+				delete(m.selected, tx.ID)
+			} else {
+				m.selected[tx.ID] = true
+			}
+			m.moveTo(m.cursor + 1)
+		}
+	case "a":
+		if len(m.selected) == len(m.transactions) {
+			m.selected = map[int]bool{}
+		} else {
+			for _, tx := range m.transactions {
+				m.selected[tx.ID] = true
+			}
+		}
+	case term.KeyEscape:
+		if len(m.selected) > 0 {
+			m.selected = map[int]bool{}
+		} else if m.filter != "" {
+			m.setFilter("")
+		}
+	case "/":
+		m.mode = tuiFilter
+		m.input = []rune(m.filter)
+	case term.KeyEnter, "e":
+		tx, ok := m.current()
+		field := tuiFields[m.column]
+		if !ok {
+			break
+		}
+		if field.set == nil {
+			m.message = fmt.Sprintf("The %s can't be edited.", field.name)
+			break
+		}
+		m.mode = tuiEdit
+		m.input = []rune(strings.TrimSpace(field.get(tx)))
+	case "c":
+		if len(m.targets()) > 0 {
+			m.mode = tuiCategorize
+			m.input = nil
+		}
+	case "d", term.KeyDelete:
+		if len(m.targets()) > 0 {
+			m.mode = tuiConfirmDelete
+		}
+	case "r", term.KeyCtrlL:
+		m.reload()
+	}
+	return false
+}
+
+// handleFilter filters the list as the user types.
+func (m *tuiModel) handleFilter(key string) {
+	switch key {
+	case term.KeyEnter:
+		m.mode = tuiBrowse
+		return
+	case term.KeyEscape:
+		m.mode = tuiBrowse
+		m.input = nil
+	default:
+		if !m.typeKey(key) {
+			return
+		}
+	}
+	m.setFilter(string(m.input))
+}
+
+// handleInput handles typing in the edit and categorize modes. "submit" is
+// called with the text when the user presses enter, and the mode stays the
+// same if it fails so that the user can fix their typo.
+func (m *tuiModel) handleInput(key string, submit func(string) error) {
+	switch key {
+	case term.KeyEnter:
+		if err := submit(strings.TrimSpace(string(m.input))); err != nil {
+			m.message = err.Error()
+			return
+		}
+		m.mode = tuiBrowse
+		m.reload()
+	case term.KeyEscape:
+		m.mode = tuiBrowse
+	default:
+		m.typeKey(key)
+	}
+}
+
+// typeKey adds the key to the input if it's a character, or deletes the last
+// one for backspace. It returns whether the input changed.
+func (m *tuiModel) typeKey(key string) bool {
+	if key == term.KeyBackspace {
+		if len(m.input) == 0 {
+			return false
+		}
+		m.input = m.input[:len(m.input)-1]
+		return true
+	}
+	if term.IsKey(key) {
+		return false
+	}
+	m.input = append(m.input, []rune(key)...)
+	return true
+}
+
+func (m *tuiModel) setFilter(filter string) {
+	m.filter = filter
+	m.cursor, m.offset = 0, 0
+	m.reload()
+}
+
+// reload loads the list again, showing any error in the message line.
+func (m *tuiModel) reload() {
+	if err := m.load(); err != nil {
+		m.message = err.Error()
+	}
+}
+
+// edit sets the current field of the current transaction to "value".
+func (m *tuiModel) edit(value string) error {
+	tx, ok := m.current()
+	if !ok {
+		return nil
+	}
+	field := tuiFields[m.column]
+	if err := field.set(&tx, value); err != nil {
+		return err
+	}
+	beginOperation(m.Transactions, tui{}.Name()+" edit")
+	if err := m.Transactions.Update(tx); err != nil {
+		return err
+	}
+	m.message = fmt.Sprintf("Updated the %s of #%d.", strings.ToLower(field.name), tx.ID)
+	return nil
+}
+
+// categorize sets the category of the targeted transactions.
+func (m *tuiModel) categorize(category string) error {
+	targets := m.targets()
+	beginOperation(m.Transactions, tui{}.Name()+" categorize")
+	for i, tx := range targets {
+		tx.Category = category
+		if err := m.Transactions.Update(tx); err != nil {
+			m.reload()
+			return fmt.Errorf("categorized %d transactions, then: %w", i, err)
+		}
+	}
+	m.selected = map[int]bool{}
+	m.message = fmt.Sprintf("Categorized %d transactions.", len(targets))
+	return nil
+}
+
+// remove deletes the targeted transactions.
+func (m *tuiModel) remove() {
+	targets := m.targets()
+	beginOperation(m.Transactions, tui{}.Name()+" remove")
+	for i, tx := range targets {
+		if err := m.Transactions.Remove(tx.ID); err != nil {
+			m.message = fmt.Sprintf("Deleted %d transactions, then: %v", i, err)
+			m.reload()
+			return
+		}
+	}
+	m.selected = map[int]bool{}
+	m.message = fmt.Sprintf("Deleted %d transactions.", len(targets))
+	m.reload()
+}
+
+// widths returns the width of each column so that the list fills the screen.
+func (m *tuiModel) widths() []int {
+	const minFlexible = 8
+	result := make([]int, len(tuiFields))
+	remaining := m.width - tuiMarkWidth
+	flexible := 0
+	for i, f := range tuiFields {
+		remaining -= f.width + 1
+		result[i] = f.width
+		if f.width == 0 {
+			flexible++
+		}
+	}
+	for i, f := range tuiFields {
+		if f.width == 0 {
+			result[i] = remaining / flexible
+			if result[i] < minFlexible {
+				result[i] = minFlexible
+			}
+		}
+	}
+	return result
+}
+
+// view returns the lines of the screen.
+func (m *tuiModel) view() []string {
+	widths := m.widths()
+	lines := make([]string, 0, m.height)
+
+	cells := make([]string, len(tuiFields))
+	for i, f := range tuiFields {
+		cells[i] = m.cell(f.name, widths[i], f.right)
+	}
+	lines = append(lines, term.Bold+strings.Repeat(" ", tuiMarkWidth)+strings.Join(cells, " ")+term.Reset)
+
+	end := m.offset + m.listHeight()
+	for i := m.offset; i < end; i++ {
+		if i >= len(m.transactions) {
+			lines = append(lines, "")
+			continue
+		}
+		lines = append(lines, m.row(i, widths))
+	}
+
+	status := fmt.Sprintf("%d transactions  Total: %s", len(m.transactions), m.total)
+	if len(m.selected) > 0 {
+		selected, err := m.sum(func(tx transaction.Transaction) bool { return m.selected[tx.ID] })
+		if err != nil {
+			status += fmt.Sprintf("  %d selected", len(m.selected))
+		} else {
+			status += fmt.Sprintf("  %d selected: %s", len(m.selected), selected)
+		}
+	}
+	if m.filter != "" {
+		status += fmt.Sprintf("  Filter: %q", m.filter)
+	}
+	lines = append(lines, term.Reverse+term.Pad(status, m.width)+term.Reset)
+	lines = append(lines, term.Truncate(m.prompt(), m.width))
+	return lines
+}
+
+// row returns the line of the screen for the transaction at index "i".
+func (m *tuiModel) row(i int, widths []int) string {
+	tx := m.transactions[i]
+	mark := strings.Repeat(" ", tuiMarkWidth)
+	if m.selected[tx.ID] {
+		mark = term.Pad("*", tuiMarkWidth)
+	}
+	cells := make([]string, len(tuiFields))
+	for j, f := range tuiFields {
+		cells[j] = m.cell(f.get(tx), widths[j], f.right)
+		if i == m.cursor && j == m.column {
+			cells[j] = term.Underline + cells[j] + term.Reset + term.Reverse
+		}
+	}
+	line := mark + strings.Join(cells, " ")
+	if i == m.cursor {
+		return term.Reverse + line + term.Reset
+	}
+	return line
+}
+
+func (m *tuiModel) cell(s string, width int, right bool) string {
+	if right {
+		return term.PadLeft(s, width)
+	}
+	return term.Pad(s, width)
+}
+
+// prompt returns the last line of the screen, which shows what the user is
+// typing or being asked.
+func (m *tuiModel) prompt() string {
+	input := string(m.input) + "_"
+	switch m.mode {
+	case tuiFilter:
+		return "/" + input
+	case tuiEdit:
+		prompt := tuiFields[m.column].name + ": " + input
+		if m.message != "" {
+			prompt += "  " + m.message
+		}
+		return prompt
+	case tuiCategorize:
+		prompt := fmt.Sprintf("Category for %d transactions: %s", len(m.targets()), input)
+		if m.message != "" {
+			prompt += "  " + m.message
+		}
+		return prompt
+	case tuiConfirmDelete:
+		return fmt.Sprintf("Delete %d transactions? (y/N)", len(m.targets()))
+	}
+	if m.message != "" {
+		return m.message
+	}
+	return tuiHelp
+}
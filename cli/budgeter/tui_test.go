@@ -0,0 +1,210 @@
+package budgeter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Anthony-Fiddes/budgeter/internal/term"
+	"github.com/Anthony-Fiddes/budgeter/model/currency"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+// newTestModel returns a tui backed by an in-memory database with the given
+// transactions in it.
+func newTestModel(t *testing.T, transactions ...transaction.Transaction) *tuiModel {
+	t.Helper()
+	db := newTestDB(t)
+	table := &transaction.Table{DB: db}
+	if err := table.Init(); err != nil {
+		t.Fatal(err)
+	}
+	rates := &currency.Table{DB: db}
+	if err := rates.Init(); err != nil {
+		t.Fatal(err)
+	}
+	for _, tx := range transactions {
+		if _, err := table.Insert(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := newTUIModel(rates, table)
+	if err := m.load(); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// press sends each of the keys to the tui. Strings that aren't special keys
+// are typed one character at a time.
+func press(t *testing.T, m *tuiModel, keys ...string) {
+	t.Helper()
+	for _, key := range keys {
+		if !term.IsKey(key) {
+			m.handleKey(key)
+			continue
+		}
+		switch key {
+		case term.KeyUp, term.KeyDown, term.KeyLeft, term.KeyRight, term.KeyHome, term.KeyEnd,
+			term.KeyPageUp, term.KeyPageDown, term.KeyDelete, term.KeyEnter, term.KeyEscape,
+			term.KeyBackspace, term.KeyTab:
+			m.handleKey(key)
+		default:
+			for _, c := range key {
+				m.handleKey(string(c))
+			}
+		}
+	}
+}
+
+// ids returns the IDs of the transactions in the tui's list.
+func ids(m *tuiModel) []int {
+	var result []int
+	for _, tx := range m.transactions {
+		result = append(result, tx.ID)
+	}
+	return result
+}
+
+func testTransactions() []transaction.Transaction {
+	return []transaction.Transaction{
+		{Date: 1610150400, Entity: "Falafel King", Amount: -599, Note: "Shawarma"},
+		{Date: 1610236800, Entity: "Ramen House", Amount: -1250},
+		{Date: 1610323200, Entity: "Paycheck", Amount: 100000, Account: "Checking"},
+		{Date: 1610409600, Entity: "Ramen Express", Amount: -900},
+	}
+}
+
+func TestTUIFilter(t *testing.T) {
+	m := newTestModel(t, testTransactions()...)
+	if len(m.transactions) != 4 || m.total != "$972.51" {
+		t.Fatalf("started with %d transactions and a total of %s", len(m.transactions), m.total)
+	}
+
+	press(t, m, "/", "ramen")
+	if m.mode != tuiFilter || len(m.transactions) != 2 || m.total != "-$21.50" {
+		t.Errorf("filtering by %q showed %v with a total of %s", m.filter, ids(m), m.total)
+	}
+	press(t, m, term.KeyBackspace, term.KeyBackspace, term.KeyBackspace, term.KeyBackspace, "x")
+	if m.filter != "rx" || len(m.transactions) != 0 {
+		t.Errorf("filtering by %q showed %v", m.filter, ids(m))
+	}
+	press(t, m, term.KeyBackspace, "amen e", term.KeyEnter)
+	if m.mode != tuiBrowse || len(m.transactions) != 1 || m.transactions[0].Entity != "Ramen Express" {
+		t.Errorf("filtering by %q showed %v", m.filter, ids(m))
+	}
+	press(t, m, term.KeyEscape)
+	if m.filter != "" || len(m.transactions) != 4 {
+		t.Errorf("clearing the filter left %q and showed %v", m.filter, ids(m))
+	}
+}
+
+func TestTUINavigation(t *testing.T) {
+	var transactions []transaction.Transaction
+	for i := 0; i < 30; i++ {
+		transactions = append(transactions, transaction.Transaction{
+			Date: int64(1610150400 + i*86400), Entity: "Coffee", Amount: -300,
+		})
+	}
+	m := newTestModel(t, transactions...)
+	m.resize(80, 10)
+
+	press(t, m, term.KeyUp)
+	if m.cursor != 0 {
+		t.Errorf("moving up from the top moved to %d", m.cursor)
+	}
+	press(t, m, term.KeyPageDown, "j")
+	if m.cursor != 8 || m.offset != 2 {
+		t.Errorf("cursor was at %d and offset was %d after moving down", m.cursor, m.offset)
+	}
+	press(t, m, term.KeyEnd)
+	if m.cursor != 29 || m.offset != 23 {
+		t.Errorf("cursor was at %d and offset was %d after moving to the end", m.cursor, m.offset)
+	}
+	lines := m.view()
+	if len(lines) != 10 || !strings.Contains(lines[8], "30 transactions") {
+		t.Errorf("the screen was %q", lines)
+	}
+	press(t, m, "g")
+	if m.cursor != 0 || m.offset != 0 {
+		t.Errorf("cursor was at %d and offset was %d after moving to the start", m.cursor, m.offset)
+	}
+	if quit := m.handleKey("q"); !quit {
+		t.Error("q didn't quit")
+	}
+}
+
+func TestTUIEdit(t *testing.T) {
+	m := newTestModel(t, testTransactions()...)
+	// The most recent transaction is first.
+	id := m.transactions[0].ID
+
+	press(t, m, term.KeyEnter, "Food", term.KeyEnter)
+	tx, err := m.Transactions.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.mode != tuiBrowse || tx.Category != "Food" {
+		t.Errorf("editing the category of #%d left it as %q", id, tx.Category)
+	}
+
+	press(t, m, term.KeyLeft, "e")
+	if m.mode != tuiEdit || string(m.input) != "-$9.00" {
+		t.Fatalf("editing the amount started with %q", string(m.input))
+	}
+	press(t, m, term.KeyBackspace, term.KeyBackspace, term.KeyBackspace, term.KeyBackspace, "five", term.KeyEnter)
+	if m.mode != tuiEdit || m.message == "" {
+		t.Errorf("an invalid amount wasn't rejected")
+	}
+	for range "-$five" {
+		press(t, m, term.KeyBackspace)
+	}
+	press(t, m, "-12.34", term.KeyEnter)
+	tx, err = m.Transactions.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.mode != tuiBrowse || tx.Amount != -1234 || tx.Category != "Food" {
+		t.Errorf("editing the amount of #%d left it as %+v", id, tx)
+	}
+
+	press(t, m, term.KeyHome, "h", "h", "h", term.KeyEnter)
+	if m.mode != tuiBrowse || m.message == "" {
+		t.Errorf("the ID could be edited")
+	}
+	press(t, m, "l", term.KeyEnter, term.KeyEscape)
+	if m.mode != tuiBrowse {
+		t.Errorf("escape didn't cancel editing")
+	}
+}
+
+func TestTUIBulk(t *testing.T) {
+	m := newTestModel(t, testTransactions()...)
+
+	press(t, m, "/", "ramen", term.KeyEnter, "a")
+	if len(m.selected) != 2 || !strings.Contains(m.view()[m.height-2], "2 selected: -$21.50") {
+		t.Fatalf("selecting every ramen transaction selected %v and showed %q", m.selected, m.view()[m.height-2])
+	}
+	press(t, m, "c", "Food", term.KeyEnter, term.KeyEscape)
+	press(t, m, term.KeyDown, " ", " ")
+	if len(m.selected) != 2 || m.cursor != 3 {
+		t.Fatalf("selected %v with the cursor at %d", m.selected, m.cursor)
+	}
+	var food int
+	for _, tx := range m.transactions {
+		if tx.Category == "Food" {
+			food++
+		}
+	}
+	if food != 2 {
+		t.Errorf("categorized %d transactions instead of 2", food)
+	}
+
+	press(t, m, "d", "n")
+	if len(m.transactions) != 4 {
+		t.Errorf("declining to delete left %v", ids(m))
+	}
+	press(t, m, "d", "y")
+	if len(m.transactions) != 2 || len(m.selected) != 0 || m.total != "-$14.99" {
+		t.Errorf("deleting 2 transactions left %v with a total of %s", ids(m), m.total)
+	}
+}
@@ -0,0 +1,71 @@
+package budgeter
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/Anthony-Fiddes/budgeter/model/journal"
+)
+
+type undo struct {
+	Journal JournalTable
+	Out     io.Writer
+}
+
+func newUndo(c *CLI) *undo {
+	return &undo{Journal: c.Journal, Out: c.Out}
+}
+
+func (u undo) Name() string {
+	return "undo"
+}
+
+//go:embed undoUsage.txt
+var undoUsage string
+
+func (u undo) Usage() string {
+	return undoUsage
+}
+
+// undo reverts the last operation that changed transactions, or the one with
+// the given ID.
+func (u undo) Run(cmdArgs []string) error {
+	fs := getFlagset(u.Name())
+	if err := fs.Parse(cmdArgs); err != nil {
+		return err
+	}
+	args := fs.Args()
+	if len(args) > 1 {
+		return fmt.Errorf("%s takes at most one argument", u.Name())
+	}
+	opID := 0
+	if len(args) == 1 {
+		var err error
+		opID, err = strconv.Atoi(args[0])
+		if err != nil || opID < 1 {
+			return fmt.Errorf(
+				"%s takes a numerical operation ID. try `budgeter %s` to see some IDs.",
+				u.Name(),
+				history{}.Name(),
+			)
+		}
+	}
+	op, err := u.Journal.Undo(opID)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(u.Out, "Undid %s.\n", describeOperation(op))
+	return nil
+}
+
+// describeOperation returns a short description of an operation, e.g.
+// "operation #3 (add, 1 change)".
+func describeOperation(op journal.Operation) string {
+	changes := "changes"
+	if op.Changes == 1 {
+		changes = "change"
+	}
+	return fmt.Sprintf("operation #%d (%s, %d %s)", op.ID, op.Command, op.Changes, changes)
+}
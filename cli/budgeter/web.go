@@ -0,0 +1,230 @@
+package budgeter
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Anthony-Fiddes/budgeter/internal/month"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+const (
+	// defaultChartMonths is how many months the web UI's chart shows by
+	// default.
+	defaultChartMonths = 12
+	// maxUploadSize is the largest CSV file that can be uploaded through the
+	// web UI, in bytes.
+	maxUploadSize = 32 << 20
+)
+
+//go:embed web
+var webFiles embed.FS
+
+// webHandler serves the files of the web UI.
+func webHandler() http.Handler {
+	files, err := fs.Sub(webFiles, "web")
+	if err != nil {
+		// The web directory is embedded, so this can't happen.
+		panic(err)
+	}
+	return http.FileServer(http.FS(files))
+}
+
+// webEntry is a transaction entered through the web UI's form. Its fields are
+// written the same way as they're typed into the add command.
+type webEntry struct {
+	// ID is the transaction being edited, or 0 for a new one.
+	ID       int    `json:"id"`
+	Date     string `json:"date"`
+	Entity   string `json:"entity"`
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+	Note     string `json:"note"`
+	Category string `json:"category"`
+	Account  string `json:"account"`
+}
+
+// entry adds or edits a transaction entered through the web UI's form.
+func (s serve) entry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	decoder.DisallowUnknownFields()
+	var e webEntry
+	if err := decoder.Decode(&e); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid entry: %w", err))
+		return
+	}
+	tx, err := s.entryTransaction(e)
+	if err != nil {
+		if errors.Is(err, transaction.ErrNotFound) {
+			writeTableError(w, err)
+		} else {
+			writeError(w, http.StatusBadRequest, err)
+		}
+		return
+	}
+
+	status := http.StatusOK
+	if e.ID == 0 {
+		tx.ID, err = s.Transactions.Insert(tx)
+		status = http.StatusCreated
+	} else {
+		err = s.Transactions.Update(tx)
+	}
+	if err != nil {
+		writeTableError(w, err)
+		return
+	}
+	tx, err = s.Transactions.Get(tx.ID)
+	if err != nil {
+		writeTableError(w, err)
+		return
+	}
+	writeJSON(w, status, toAPI(tx))
+}
+
+// entryTransaction validates "e" like the add command does. New transactions
+// have the user's payee aliases and rules applied, and get the default
+// category if they don't have one. Edited transactions keep their tags and
+// kind.
+func (s serve) entryTransaction(e webEntry) (transaction.Transaction, error) {
+	tx := transaction.Transaction{ID: e.ID, Entity: e.Entity, Note: e.Note, Account: e.Account}
+	var err error
+	if strings.TrimSpace(e.Date) == "" {
+		tx.Date = today().Unix()
+	} else {
+		tx.Date, err = transaction.Unix(strings.TrimSpace(e.Date))
+		if err != nil {
+			return transaction.Transaction{}, err
+		}
+	}
+	tx.Amount, tx.Currency, err = parseAmount(e.Amount, e.Currency)
+	if err != nil {
+		return transaction.Transaction{}, err
+	}
+
+	if e.ID != 0 {
+		existing, err := s.Transactions.Get(e.ID)
+		if err != nil {
+			return transaction.Transaction{}, err
+		}
+		tx.Tags = existing.Tags
+		tx.Kind = existing.Kind
+		tx.Category = e.Category
+		return tx, nil
+	}
+
+	payees, err := loadPayees(s.Payees)
+	if err != nil {
+		return transaction.Transaction{}, err
+	}
+	rules, err := loadRules(s.Rules)
+	if err != nil {
+		return transaction.Transaction{}, err
+	}
+	classifier, err := trainClassifier(s.Transactions)
+	if err != nil {
+		return transaction.Transaction{}, err
+	}
+	tx, def := autofill(tx, payees, rules, classifier)
+	tx.Category = e.Category
+	if tx.Category == "" {
+		tx.Category = def
+	}
+	return tx, nil
+}
+
+// webMonth is the total of a month's transactions in the home currency.
+type webMonth struct {
+	// Month is the month's name and year, e.g. "January 2021".
+	Month   string `json:"month"`
+	Total   int64  `json:"total"`
+	Display string `json:"display"`
+}
+
+// months writes the totals of the last few months, oldest first. The "n"
+// query parameter sets how many.
+func (s serve) months(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	n := defaultChartMonths
+	if value := r.URL.Query().Get("n"); value != "" {
+		var err error
+		n, err = strconv.Atoi(value)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, errors.New("n must be a positive number"))
+			return
+		}
+	}
+
+	result := []webMonth{}
+	start := month.Add(month.Start(now()), -n+1)
+	for i := 0; i < n; i++ {
+		end := month.End(start)
+		totals, err := s.Transactions.RangeTotals(start, end)
+		var total transaction.Cent
+		if err == nil {
+			total, err = totalToHome(s.Rates, totals, end)
+		}
+		if err != nil {
+			writeTableError(w, err)
+			return
+		}
+		result = append(result, webMonth{
+			Month:   start.Format("January 2006"),
+			Total:   int64(total),
+			Display: total.String(),
+		})
+		start = month.Add(start, 1)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// upload ingests a CSV file uploaded through the web UI, the same way as the
+// ingest command. The form's "account", "currency" and "pending" fields work
+// like the ingest command's flags.
+func (s serve) upload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("could not read the uploaded file: %w", err))
+		return
+	}
+	defer file.Close()
+
+	i := ingest{
+		account:      r.FormValue("account"),
+		currency:     r.FormValue("currency"),
+		pending:      r.FormValue("pending") != "",
+		Payees:       s.Payees,
+		Rules:        s.Rules,
+		Transactions: s.Transactions,
+	}
+	result, err := i.readCSV(file)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, transaction.ErrDuplicate) {
+			status = http.StatusConflict
+		}
+		writeJSON(w, status, map[string]interface{}{
+			"error": err.Error(), "added": result.Added, "cleared": result.Cleared,
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
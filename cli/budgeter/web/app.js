@@ -0,0 +1,205 @@
+"use strict";
+
+// The API token is passed in the URL's fragment by "budgeter serve" so that
+// it's never sent to the server in a request line or logged.
+const tokenKey = "budgeter-token";
+if (location.hash.startsWith("#token=")) {
+	localStorage.setItem(tokenKey, decodeURIComponent(location.hash.slice("#token=".length)));
+	history.replaceState(null, "", location.pathname);
+}
+
+const $ = (id) => document.getElementById(id);
+
+function showMessage(text, isError) {
+	$("message").textContent = text;
+	$("message").className = isError ? "error" : "";
+}
+
+async function api(method, path, body) {
+	const options = {
+		method: method,
+		headers: { "Authorization": "Bearer " + localStorage.getItem(tokenKey) },
+	};
+	if (body instanceof FormData) {
+		options.body = body;
+	} else if (body !== undefined) {
+		options.headers["Content-Type"] = "application/json";
+		options.body = JSON.stringify(body);
+	}
+	const resp = await fetch(path, options);
+	if (resp.status === 401) {
+		localStorage.removeItem(tokenKey);
+		start();
+		throw new Error("The API token is wrong.");
+	}
+	const result = resp.status === 204 ? null : await resp.json();
+	if (!resp.ok) {
+		throw new Error(result && result.error ? result.error : resp.statusText);
+	}
+	return result;
+}
+
+// formatDate turns an API date (YYYY-MM-DD) into the M/D/YYYY format that the
+// form uses.
+function formatDate(date) {
+	const [year, month, day] = date.split("-");
+	return Number(month) + "/" + Number(day) + "/" + year;
+}
+
+let transactions = [];
+
+async function loadTransactions() {
+	const q = $("search").value.trim();
+	const path = "/transactions" + (q ? "?q=" + encodeURIComponent(q) : "");
+	transactions = await api("GET", path);
+	const body = $("transactions");
+	body.replaceChildren();
+	for (const tx of transactions) {
+		const row = body.insertRow();
+		const cells = [formatDate(tx.date), tx.entity, tx.display, tx.category, tx.account, tx.note];
+		cells.forEach((text, i) => {
+			const cell = row.insertCell();
+			cell.textContent = text || "";
+			if (i === 2) {
+				cell.className = tx.amount < 0 ? "amount negative" : "amount";
+			}
+		});
+		const actions = row.insertCell();
+		const edit = document.createElement("button");
+		edit.textContent = "Edit";
+		edit.onclick = () => editTransaction(tx);
+		const remove = document.createElement("button");
+		remove.textContent = "Delete";
+		remove.onclick = () => removeTransaction(tx);
+		actions.append(edit, " ", remove);
+	}
+}
+
+async function loadChart() {
+	const months = await api("GET", "/ui/months");
+	const chart = $("chart");
+	const width = 600, height = 200, labelHeight = 16;
+	chart.setAttribute("viewBox", "0 0 " + width + " " + height);
+	chart.replaceChildren();
+	const largest = Math.max(1, ...months.map((m) => Math.abs(m.total)));
+	const slot = width / months.length;
+	const middle = (height - labelHeight) / 2;
+	const svg = "http://www.w3.org/2000/svg";
+	months.forEach((m, i) => {
+		const barHeight = Math.abs(m.total) / largest * (middle - 1);
+		const bar = document.createElementNS(svg, "rect");
+		bar.setAttribute("x", i * slot + slot * 0.15);
+		bar.setAttribute("width", slot * 0.7);
+		bar.setAttribute("y", m.total < 0 ? middle : middle - barHeight);
+		bar.setAttribute("height", barHeight);
+		if (m.total < 0) {
+			bar.setAttribute("class", "negative");
+		}
+		const title = document.createElementNS(svg, "title");
+		title.textContent = m.month + ": " + m.display;
+		bar.append(title);
+		const label = document.createElementNS(svg, "text");
+		label.setAttribute("x", i * slot + slot / 2);
+		label.setAttribute("y", height - 4);
+		const [name, year] = m.month.split(" ");
+		label.textContent = name.slice(0, 3) + " " + year.slice(2);
+		chart.append(bar, label);
+	});
+}
+
+async function refresh() {
+	try {
+		await Promise.all([loadTransactions(), loadChart()]);
+	} catch (err) {
+		showMessage(err.message, true);
+	}
+}
+
+function resetEntry() {
+	const form = $("entry");
+	form.reset();
+	form.elements.id.value = "0";
+	$("entry-title").textContent = "Add a transaction";
+	$("entry-cancel").hidden = true;
+}
+
+function editTransaction(tx) {
+	const form = $("entry").elements;
+	form.id.value = tx.id;
+	form.date.value = formatDate(tx.date);
+	form.entity.value = tx.entity;
+	form.amount.value = tx.display;
+	form.currency.value = tx.currency;
+	form.category.value = tx.category || "";
+	form.account.value = tx.account || "";
+	form.note.value = tx.note || "";
+	$("entry-title").textContent = "Edit a transaction";
+	$("entry-cancel").hidden = false;
+	$("entry").scrollIntoView();
+}
+
+async function removeTransaction(tx) {
+	if (!confirm("Delete " + tx.entity + " (" + tx.display + ") on " + formatDate(tx.date) + "?")) {
+		return;
+	}
+	try {
+		await api("DELETE", "/transactions/" + tx.id);
+		showMessage("Deleted " + tx.entity + ".");
+		await refresh();
+	} catch (err) {
+		showMessage(err.message, true);
+	}
+}
+
+$("entry").onsubmit = async (event) => {
+	event.preventDefault();
+	const entry = Object.fromEntries(new FormData(event.target));
+	entry.id = Number(entry.id);
+	try {
+		const tx = await api("POST", "/ui/entry", entry);
+		showMessage("Saved " + tx.entity + " (" + tx.display + ").");
+		resetEntry();
+		await refresh();
+	} catch (err) {
+		showMessage(err.message, true);
+	}
+};
+
+$("entry-cancel").onclick = resetEntry;
+
+$("upload").onsubmit = async (event) => {
+	event.preventDefault();
+	try {
+		const result = await api("POST", "/ui/upload", new FormData(event.target));
+		showMessage("Added " + result.added + " transactions and cleared " + result.cleared + " pending ones.");
+		event.target.reset();
+	} catch (err) {
+		showMessage(err.message, true);
+	}
+	await refresh();
+};
+
+let searchTimer;
+$("search").oninput = () => {
+	clearTimeout(searchTimer);
+	searchTimer = setTimeout(refresh, 200);
+};
+
+$("token-form").onsubmit = (event) => {
+	event.preventDefault();
+	localStorage.setItem(tokenKey, $("token").value);
+	$("token").value = "";
+	start();
+};
+
+function start() {
+	const signedIn = localStorage.getItem(tokenKey) !== null;
+	$("token-form").hidden = signedIn;
+	$("app").hidden = !signedIn;
+	if (signedIn) {
+		showMessage("");
+		refresh();
+	}
+}
+
+start();
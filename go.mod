@@ -1,6 +1,6 @@
 module github.com/Anthony-Fiddes/budgeter
 
-go 1.16
+go 1.18
 
 require (
 	github.com/cheynewallace/tabby v1.1.1
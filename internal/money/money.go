@@ -0,0 +1,143 @@
+// money formats and parses amounts of money following the conventions of a
+// locale, e.g. "$1,234.56" in the US or "1.234,56 €" in Germany.
+package money
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Anthony-Fiddes/budgeter/model/currency"
+)
+
+// Locale describes how amounts of money are written.
+type Locale struct {
+	// Name identifies the locale, e.g. "en-US".
+	Name string
+	// Decimal separates the major and minor units of an amount, e.g. ".".
+	Decimal string
+	// Thousands separates each group of three digits in the major units of
+	// an amount, e.g. ",".
+	Thousands string
+	// SymbolAfter is whether the currency symbol is written after the number
+	// instead of before it.
+	SymbolAfter bool
+	// Space is whether there's a space between the currency symbol and the
+	// number.
+	Space bool
+	// Accounting is whether negative amounts are written in parentheses, e.g.
+	// "($12.50)", instead of with a minus sign.
+	Accounting bool
+	// Rounding determines what happens to parsed amounts that have more
+	// digits after the decimal point than their currency allows.
+	Rounding Rounding
+}
+
+// Default is the locale that's used when no other one is given.
+var Default = Locale{Name: "en-US", Decimal: ".", Thousands: ","}
+
+// Plain writes amounts as plain numbers, e.g. "-1234.56", when the currency's
+// symbol is left out. Files that budgeter reads back are written in it, so
+// that they mean the same thing in every locale. It isn't one of the locales
+// that Lookup knows about.
+var Plain = Locale{Name: "plain", Decimal: "."}
+
+var locales = map[string]Locale{}
+
+func init() {
+	for _, l := range []Locale{
+		Default,
+		{Name: "de-CH", Decimal: ".", Thousands: "'", Space: true},
+		{Name: "de-DE", Decimal: ",", Thousands: ".", SymbolAfter: true, Space: true},
+		{Name: "en-AU", Decimal: ".", Thousands: ","},
+		{Name: "en-CA", Decimal: ".", Thousands: ","},
+		{Name: "en-GB", Decimal: ".", Thousands: ","},
+		{Name: "es-ES", Decimal: ",", Thousands: ".", SymbolAfter: true, Space: true},
+		{Name: "fr-CA", Decimal: ",", Thousands: " ", SymbolAfter: true, Space: true},
+		{Name: "fr-FR", Decimal: ",", Thousands: " ", SymbolAfter: true, Space: true},
+		{Name: "it-IT", Decimal: ",", Thousands: ".", SymbolAfter: true, Space: true},
+		{Name: "ja-JP", Decimal: ".", Thousands: ","},
+		{Name: "nl-NL", Decimal: ",", Thousands: ".", Space: true},
+		{Name: "pl-PL", Decimal: ",", Thousands: " ", SymbolAfter: true, Space: true},
+		{Name: "pt-BR", Decimal: ",", Thousands: ".", Space: true},
+		{Name: "sv-SE", Decimal: ",", Thousands: " ", SymbolAfter: true, Space: true},
+	} {
+		locales[strings.ToLower(l.Name)] = l
+	}
+}
+
+// Lookup returns the locale with the given name, e.g. "de-DE". Names are not
+// case sensitive.
+func Lookup(name string) (Locale, error) {
+	l, ok := locales[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return Locale{}, fmt.Errorf("money: unknown locale \"%s\"", name)
+	}
+	return l, nil
+}
+
+// Names returns the names of every locale that money knows about, sorted.
+func Names() []string {
+	var result []string
+	for _, l := range locales {
+		result = append(result, l.Name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// pow10 returns 10 to the power of "n".
+func pow10(n int) int64 {
+	result := int64(1)
+	for i := 0; i < n; i++ {
+		result *= 10
+	}
+	return result
+}
+
+// group writes "digits" with l.Thousands between each group of three.
+func (l Locale) group(digits string) string {
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteString(l.Thousands)
+		}
+		b.WriteRune(d)
+	}
+	return b.String()
+}
+
+// Format returns a string that represents "amount" minor units of "cur", e.g.
+// "-$1,234.56" or "-1.234,56 €".
+func (l Locale) Format(amount int64, cur currency.Currency) string {
+	negative := amount < 0
+	// Work with the digits as a string so that the most negative amount
+	// doesn't overflow when its sign is dropped.
+	digits := strconv.FormatInt(amount, 10)
+	digits = strings.TrimPrefix(digits, "-")
+	if len(digits) <= cur.Exponent {
+		digits = strings.Repeat("0", cur.Exponent-len(digits)+1) + digits
+	}
+	split := len(digits) - cur.Exponent
+	number := l.group(digits[:split])
+	if cur.Exponent > 0 {
+		number += l.Decimal + digits[split:]
+	}
+
+	space := ""
+	if l.Space {
+		space = " "
+	}
+	result := cur.Symbol + space + number
+	if l.SymbolAfter {
+		result = number + space + cur.Symbol
+	}
+	if negative {
+		if l.Accounting {
+			return "(" + result + ")"
+		}
+		return "-" + result
+	}
+	return result
+}
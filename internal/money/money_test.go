@@ -0,0 +1,192 @@
+package money_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Anthony-Fiddes/budgeter/internal/money"
+	"github.com/Anthony-Fiddes/budgeter/model/currency"
+)
+
+func TestFormat(t *testing.T) {
+	accounting := money.Default
+	accounting.Accounting = true
+	tests := []struct {
+		locale   string
+		amount   int64
+		code     string
+		expected string
+	}{
+		{locale: "en-US", amount: 123456, code: "USD", expected: "$1,234.56"},
+		{locale: "en-US", amount: -5, code: "USD", expected: "-$0.05"},
+		{locale: "en-US", amount: 1234567, code: "JPY", expected: "¥1,234,567"},
+		{locale: "de-DE", amount: -123456, code: "EUR", expected: "-1.234,56 €"},
+		{locale: "fr-FR", amount: 100000000, code: "EUR", expected: "1 000 000,00 €"},
+		{locale: "de-CH", amount: 123456, code: "CHF", expected: "CHF 1'234.56"},
+		{locale: "nl-NL", amount: 99, code: "EUR", expected: "€ 0,99"},
+	}
+	for _, test := range tests {
+		l, err := money.Lookup(test.locale)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cur, err := currency.Lookup(test.code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result := l.Format(test.amount, cur); result != test.expected {
+			t.Errorf("%s: received %q but expected %q", test.locale, result, test.expected)
+		}
+	}
+	if result := accounting.Format(-1250, currency.USD); result != "($12.50)" {
+		t.Errorf("received %q but expected \"($12.50)\"", result)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		locale   string
+		input    string
+		code     string
+		expected int64
+	}{
+		{locale: "en-US", input: "1,234,567.89", code: "USD", expected: 123456789},
+		{locale: "en-US", input: "($12.50)", code: "USD", expected: -1250},
+		{locale: "en-US", input: "$-12.50", code: "USD", expected: -1250},
+		{locale: "en-US", input: ".5", code: "USD", expected: 50},
+		{locale: "de-DE", input: "-1.234,56 €", code: "EUR", expected: -123456},
+		{locale: "de-DE", input: "1.500", code: "EUR", expected: 150000},
+		{locale: "fr-FR", input: "1 000,5", code: "EUR", expected: 100050},
+		{locale: "de-CH", input: "CHF 1'234.56", code: "CHF", expected: 123456},
+		{locale: "en-US", input: "12.50 DR", code: "USD", expected: -1250},
+		{locale: "en-US", input: "12.50cr", code: "USD", expected: 1250},
+		{locale: "en-US", input: "+$5", code: "USD", expected: 500},
+		{locale: "en-US", input: "EUR 5", code: "EUR", expected: 500},
+		{locale: "en-US", input: "5.", code: "USD", expected: 500},
+	}
+	for _, test := range tests {
+		l, err := money.Lookup(test.locale)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cur, err := currency.Lookup(test.code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := l.Parse(test.input, cur)
+		if err != nil {
+			t.Errorf("%s: could not parse %q: %v", test.locale, test.input, err)
+		} else if result != test.expected {
+			t.Errorf("%s: parsed %q as %d but expected %d", test.locale, test.input, result, test.expected)
+		}
+	}
+
+	invalid := []struct{ locale, input string }{
+		{locale: "en-US", input: "1,23.45"},
+		{locale: "en-US", input: "--5"},
+		{locale: "en-US", input: "(-5)"},
+		{locale: "en-US", input: "5.123"},
+		{locale: "en-US", input: "$"},
+		{locale: "de-DE", input: "1.5"},
+		{locale: "en-US", input: "99999999999999999999"},
+	}
+	for _, test := range invalid {
+		l, err := money.Lookup(test.locale)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := l.Parse(test.input, currency.USD); err == nil {
+			t.Errorf("%s: expected an error parsing %q", test.locale, test.input)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	amounts := []int64{0, 1, -1, 99, 100, -123456, 100000000, 1<<62 + 7}
+	for _, name := range money.Names() {
+		l, err := money.Lookup(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, accounting := range []bool{false, true} {
+			l.Accounting = accounting
+			for _, amount := range amounts {
+				s := l.Format(amount, currency.USD)
+				result, err := l.Parse(s, currency.USD)
+				if err != nil || result != amount {
+					t.Errorf("%s: %d was formatted as %q, which parsed as %d (%v)", name, amount, s, result, err)
+				}
+			}
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		column int
+	}{
+		{input: "1-2", column: 2},
+		{input: "--5", column: 2},
+		{input: "(5", column: 3},
+		{input: "(-5)", column: 2},
+		{input: "-5 DR", column: 4},
+		{input: "1,23.45", column: 5},
+		{input: "1234,567", column: 5},
+		{input: "5.123", column: 5},
+		{input: "$", column: 2},
+		{input: "€5", column: 1},
+		{input: "$$5", column: 2},
+		{input: "5 $ $", column: 5},
+		{input: "", column: 1},
+	}
+	for _, test := range tests {
+		_, err := money.Default.Parse(test.input, currency.USD)
+		var parseErr *money.ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("parsing %q returned %v, not a *ParseError", test.input, err)
+			continue
+		}
+		if parseErr.Column != test.column {
+			t.Errorf("parsing %q: error %q is at column %d but expected %d", test.input, err, parseErr.Column, test.column)
+		}
+	}
+}
+
+func TestRounding(t *testing.T) {
+	tests := []struct {
+		input    string
+		rounding money.Rounding
+		expected int64
+	}{
+		{input: "5.125", rounding: money.HalfEven, expected: 512},
+		{input: "5.135", rounding: money.HalfEven, expected: 514},
+		{input: "5.1251", rounding: money.HalfEven, expected: 513},
+		{input: "-5.125", rounding: money.HalfEven, expected: -512},
+		{input: "5.125", rounding: money.HalfUp, expected: 513},
+		{input: "-5.125", rounding: money.HalfUp, expected: -513},
+		{input: "5.124", rounding: money.HalfUp, expected: 512},
+		{input: "9.999", rounding: money.HalfUp, expected: 1000},
+		{input: "5.129", rounding: money.Down, expected: 512},
+		{input: "-5.129", rounding: money.Down, expected: -512},
+	}
+	for _, test := range tests {
+		l := money.Default
+		l.Rounding = test.rounding
+		result, err := l.Parse(test.input, currency.USD)
+		if err != nil {
+			t.Errorf("%s: could not parse %q: %v", test.rounding, test.input, err)
+		} else if result != test.expected {
+			t.Errorf("%s: parsed %q as %d but expected %d", test.rounding, test.input, result, test.expected)
+		}
+	}
+
+	for _, name := range []string{"reject", "half-even", "Half-Up", "down"} {
+		if _, err := money.ParseRounding(name); err != nil {
+			t.Error(err)
+		}
+	}
+	if _, err := money.ParseRounding("banker's"); err == nil {
+		t.Error("expected an error for an unknown rounding")
+	}
+}
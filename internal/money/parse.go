@@ -0,0 +1,333 @@
+package money
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Anthony-Fiddes/budgeter/model/currency"
+)
+
+// Rounding determines what happens to parsed amounts that have more digits
+// after the decimal point than their currency allows, e.g. "$5.125".
+type Rounding int
+
+const (
+	// Reject makes amounts with too many digits an error.
+	Reject Rounding = iota
+	// HalfEven rounds to the nearest minor unit, and halves to the nearest
+	// even one, e.g. "$5.125" is 512 cents and "$5.135" is 514 cents.
+	HalfEven
+	// HalfUp rounds to the nearest minor unit, and halves away from zero.
+	HalfUp
+	// Down drops the extra digits.
+	Down
+)
+
+var roundingNames = []string{"reject", "half-even", "half-up", "down"}
+
+func (r Rounding) String() string {
+	if r < 0 || int(r) >= len(roundingNames) {
+		return fmt.Sprintf("Rounding(%d)", int(r))
+	}
+	return roundingNames[r]
+}
+
+// ParseRounding returns the rounding with the given name, e.g. "half-even".
+func ParseRounding(name string) (Rounding, error) {
+	for i, n := range roundingNames {
+		if strings.EqualFold(n, strings.TrimSpace(name)) {
+			return Rounding(i), nil
+		}
+	}
+	return 0, fmt.Errorf(
+		"money: unknown rounding \"%s\" (must be one of %s)",
+		name, strings.Join(roundingNames, ", "),
+	)
+}
+
+// ParseError describes why an amount could not be parsed and where.
+type ParseError struct {
+	// Input is the amount that could not be parsed.
+	Input string
+	// Column is the position of the offending character in Input, counting
+	// characters from 1. It's one past the last character if the amount ended
+	// too early.
+	Column int
+	// Msg describes the problem.
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("money: \"%s\" is not a valid amount: %s at column %d", e.Input, e.Msg, e.Column)
+}
+
+// Parse returns the number of minor units of "cur" that "amount" represents.
+// Amounts are read according to this grammar, where ws is any amount of
+// whitespace and the separators and symbol are the locale's and currency's:
+//
+//	amount   = ws ( "(" ws inner ws ")" | inner ) ws [ mark ws ] .
+//	inner    = [ sign ws ] [ symbol ws ] [ sign ws ] number [ ws symbol ] .
+//	mark     = "CR" | "DR" .
+//	sign     = "-" | "+" .
+//	symbol   = cur.Symbol | cur.Code .
+//	number   = whole [ decimal { digit } ] | decimal digit { digit } .
+//	whole    = digit { digit } | group { thousands digit digit digit } .
+//	group    = digit [ digit [ digit ] ] .
+//
+// The symbol may only appear once, and an amount may only have one of a sign,
+// parentheses or a mark. Parentheses, "-" and "DR" (debit) make the amount
+// negative, while "CR" (credit) doesn't. Marks are not case sensitive.
+//
+// If there are more digits after the decimal point than cur.Exponent, the
+// amount is rounded according to l.Rounding. If the amount can't be parsed,
+// the error is a *ParseError.
+func (l Locale) Parse(amount string, cur currency.Currency) (int64, error) {
+	p := parser{input: amount, l: l, cur: cur}
+	return p.parse()
+}
+
+// parser reads a single amount. pos is the byte offset of the next character
+// to be read.
+type parser struct {
+	input string
+	pos   int
+	l     Locale
+	cur   currency.Currency
+}
+
+// errorf returns a *ParseError for the character at the byte offset "pos".
+func (p *parser) errorf(pos int, format string, args ...interface{}) error {
+	return &ParseError{
+		Input:  p.input,
+		Column: utf8.RuneCountInString(p.input[:pos]) + 1,
+		Msg:    fmt.Sprintf(format, args...),
+	}
+}
+
+// unexpected returns an error for the character at p.pos.
+func (p *parser) unexpected(expected string) error {
+	if p.pos >= len(p.input) {
+		return p.errorf(p.pos, "expected %s but the amount ended", expected)
+	}
+	r, _ := utf8.DecodeRuneInString(p.input[p.pos:])
+	return p.errorf(p.pos, "expected %s but found %q", expected, r)
+}
+
+func (p *parser) rest() string {
+	return p.input[p.pos:]
+}
+
+func (p *parser) skipSpace() {
+	trimmed := strings.TrimLeft(p.rest(), " \t\u00a0\u202f")
+	p.pos = len(p.input) - len(trimmed)
+}
+
+// accept reads "s" if it's next.
+func (p *parser) accept(s string) bool {
+	if s == "" || !strings.HasPrefix(p.rest(), s) {
+		return false
+	}
+	p.pos += len(s)
+	return true
+}
+
+// acceptFold reads "s" if it's next, ignoring case.
+func (p *parser) acceptFold(s string) bool {
+	if len(p.rest()) < len(s) || !strings.EqualFold(p.rest()[:len(s)], s) {
+		return false
+	}
+	p.pos += len(s)
+	return true
+}
+
+// symbol reads the currency's symbol or code if one is next. The longer of the
+// two is tried first so that e.g. "CHF" isn't read as "CH" and "F".
+func (p *parser) symbol() bool {
+	first, second := p.cur.Code, p.cur.Symbol
+	if len(second) > len(first) {
+		first, second = second, first
+	}
+	return p.accept(first) || p.accept(second)
+}
+
+// digits reads as many digits as possible.
+func (p *parser) digits() string {
+	start := p.pos
+	for p.pos < len(p.input) && p.input[p.pos] >= '0' && p.input[p.pos] <= '9' {
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+// thousands reports whether a thousands separator followed by a digit is next.
+func (p *parser) thousands() bool {
+	sep := p.l.Thousands
+	if sep == "" || sep == p.l.Decimal || !strings.HasPrefix(p.rest(), sep) {
+		return false
+	}
+	next := p.pos + len(sep)
+	return next < len(p.input) && p.input[next] >= '0' && p.input[next] <= '9'
+}
+
+func (p *parser) parse() (int64, error) {
+	negative := false
+	signed := -1
+	sign := func() error {
+		start := p.pos
+		var r byte
+		if p.accept("-") {
+			r = '-'
+		} else if p.accept("+") {
+			r = '+'
+		} else {
+			return nil
+		}
+		if signed >= 0 {
+			return p.errorf(start, "the amount already has a sign at column %d", signed)
+		}
+		signed = utf8.RuneCountInString(p.input[:start]) + 1
+		negative = r == '-'
+		p.skipSpace()
+		return nil
+	}
+
+	p.skipSpace()
+	open := p.pos
+	paren := p.accept("(")
+	if paren {
+		signed = utf8.RuneCountInString(p.input[:open]) + 1
+		negative = true
+		p.skipSpace()
+	}
+	if err := sign(); err != nil {
+		return 0, err
+	}
+	symbol := p.symbol()
+	if symbol {
+		p.skipSpace()
+		if err := sign(); err != nil {
+			return 0, err
+		}
+	}
+
+	start := p.pos
+	digits, err := p.number()
+	if err != nil {
+		return 0, err
+	}
+	p.skipSpace()
+	if !symbol {
+		if p.symbol() {
+			p.skipSpace()
+		}
+	}
+	if paren {
+		if !p.accept(")") {
+			return 0, p.unexpected(fmt.Sprintf("\")\" to match column %d", signed))
+		}
+		p.skipSpace()
+	}
+	markStart := p.pos
+	if p.acceptFold("CR") || p.acceptFold("DR") {
+		if signed >= 0 {
+			return 0, p.errorf(markStart, "the amount already has a sign at column %d", signed)
+		}
+		negative = strings.EqualFold(p.input[markStart:p.pos], "DR")
+		p.skipSpace()
+	}
+	if p.pos < len(p.input) {
+		return 0, p.unexpected("the end of the amount")
+	}
+
+	if negative {
+		digits = "-" + digits
+	}
+	result, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, p.errorf(start, "the amount is too large")
+	}
+	return result, nil
+}
+
+// number reads a number and returns its digits as a whole number of minor
+// units, rounded according to p.l.Rounding.
+func (p *parser) number() (string, error) {
+	start := p.pos
+	whole := p.digits()
+	if whole != "" && p.thousands() {
+		if len(whole) > 3 {
+			return "", p.errorf(p.pos, "a thousands separator can't come after more than three digits")
+		}
+		for p.thousands() {
+			p.accept(p.l.Thousands)
+			groupStart := p.pos
+			group := p.digits()
+			if len(group) != 3 {
+				return "", p.errorf(groupStart+len(group), "expected three digits after the thousands separator")
+			}
+			whole += group
+		}
+	}
+
+	fraction := ""
+	hasDecimal := p.accept(p.l.Decimal)
+	if hasDecimal {
+		fraction = p.digits()
+	}
+	if whole == "" && fraction == "" {
+		p.pos = start
+		if hasDecimal {
+			p.pos += len(p.l.Decimal)
+		}
+		return "", p.unexpected("a digit")
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	exp := p.cur.Exponent
+	if len(fraction) <= exp {
+		return whole + fraction + strings.Repeat("0", exp-len(fraction)), nil
+	}
+	kept, extra := fraction[:exp], fraction[exp:]
+	digits := whole + kept
+	up := false
+	switch p.l.Rounding {
+	case Reject:
+		extraStart := p.pos - len(extra)
+		if exp == 0 {
+			return "", p.errorf(extraStart, "%s amounts can't have digits after the decimal point", p.cur.Code)
+		}
+		return "", p.errorf(extraStart, "%s amounts can only have %d digits after the decimal point", p.cur.Code, exp)
+	case HalfUp:
+		up = extra[0] >= '5'
+	case HalfEven:
+		rest := strings.TrimRight(extra[1:], "0")
+		switch {
+		case extra[0] > '5' || (extra[0] == '5' && rest != ""):
+			up = true
+		case extra[0] == '5':
+			last := digits[len(digits)-1]
+			up = (last-'0')%2 == 1
+		}
+	}
+	if up {
+		digits = increment(digits)
+	}
+	return digits, nil
+}
+
+// increment adds one to a string of decimal digits.
+func increment(digits string) string {
+	b := []byte(digits)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < '9' {
+			b[i]++
+			return string(b)
+		}
+		b[i] = '0'
+	}
+	return "1" + string(b)
+}
@@ -17,6 +17,19 @@ func End(t time.Time) time.Time {
 	return result
 }
 
+// Add returns the inputted time, but the given number of months later (or
+// earlier, if "months" is negative). Unlike time.Time.AddDate, days that don't
+// exist in the resulting month become its last day instead of spilling into
+// the next month, so Jan 31st plus one month is the end of February.
 func Add(t time.Time, months int) time.Time {
-	return t.AddDate(0, months, 0)
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	day := t.Day()
+	if last := End(first).Day(); day > last {
+		day = last
+	}
+	return time.Date(
+		first.Year(), first.Month(), day,
+		t.Hour(), t.Minute(), t.Second(), t.Nanosecond(),
+		t.Location(),
+	)
 }
@@ -0,0 +1,119 @@
+package month_test
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/Anthony-Fiddes/budgeter/internal/month"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestStartEnd(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+	sydney := mustLoad(t, "Australia/Sydney")
+	tests := []struct {
+		name  string
+		input time.Time
+		start time.Time
+		end   time.Time
+	}{
+		{
+			name:  "leap year",
+			input: time.Date(2024, time.February, 29, 12, 0, 0, 0, time.UTC),
+			start: time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+			end:   time.Date(2024, time.February, 29, 23, 59, 59, 999999999, time.UTC),
+		},
+		{
+			name:  "new year's eve",
+			input: time.Date(2021, time.December, 31, 23, 59, 59, 0, newYork),
+			start: time.Date(2021, time.December, 1, 0, 0, 0, 0, newYork),
+			end:   time.Date(2021, time.December, 31, 23, 59, 59, 999999999, newYork),
+		},
+		{
+			name:  "spring forward",
+			input: time.Date(2021, time.March, 14, 3, 30, 0, 0, newYork),
+			start: time.Date(2021, time.March, 1, 0, 0, 0, 0, newYork),
+			end:   time.Date(2021, time.March, 31, 23, 59, 59, 999999999, newYork),
+		},
+		{
+			name:  "fall back",
+			input: time.Date(2021, time.November, 7, 1, 30, 0, 0, newYork),
+			start: time.Date(2021, time.November, 1, 0, 0, 0, 0, newYork),
+			end:   time.Date(2021, time.November, 30, 23, 59, 59, 999999999, newYork),
+		},
+		{
+			// Daylight saving time ends on the first Sunday of April in
+			// Sydney, so the month starts in daylight time and ends in
+			// standard time.
+			name:  "southern hemisphere",
+			input: time.Date(2021, time.April, 4, 2, 30, 0, 0, sydney),
+			start: time.Date(2021, time.April, 1, 0, 0, 0, 0, sydney),
+			end:   time.Date(2021, time.April, 30, 23, 59, 59, 999999999, sydney),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if result := month.Start(test.input); !result.Equal(test.start) {
+				t.Errorf("Start(%s) = %s but expected %s", test.input, result, test.start)
+			}
+			result := month.End(test.input)
+			if !result.Equal(test.end) {
+				t.Errorf("End(%s) = %s but expected %s", test.input, result, test.end)
+			}
+			if result.Location() != test.input.Location() {
+				t.Errorf("End(%s) is in %s instead of %s", test.input, result.Location(), test.input.Location())
+			}
+		})
+	}
+}
+
+func TestAdd(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+	tests := []struct {
+		input    time.Time
+		months   int
+		expected time.Time
+	}{
+		{
+			input:    time.Date(2021, time.January, 31, 0, 0, 0, 0, time.UTC),
+			months:   1,
+			expected: time.Date(2021, time.February, 28, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			input:    time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC),
+			months:   -1,
+			expected: time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			input:    time.Date(2021, time.November, 15, 12, 0, 0, 0, time.UTC),
+			months:   3,
+			expected: time.Date(2022, time.February, 15, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			input:    time.Date(2021, time.March, 1, 0, 0, 0, 0, newYork),
+			months:   -5,
+			expected: time.Date(2020, time.October, 1, 0, 0, 0, 0, newYork),
+		},
+		{
+			// Midnight stays midnight across a change to daylight time.
+			input:    time.Date(2021, time.February, 1, 0, 0, 0, 0, newYork),
+			months:   2,
+			expected: time.Date(2021, time.April, 1, 0, 0, 0, 0, newYork),
+		},
+	}
+	for _, test := range tests {
+		result := month.Add(test.input, test.months)
+		if !result.Equal(test.expected) {
+			t.Errorf("Add(%s, %d) = %s but expected %s", test.input, test.months, result, test.expected)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+// term provides the bare minimum needed to draw a full-screen interface in a
+// terminal: switching it to raw mode, finding its size, reading key presses
+// and a few ANSI escape sequences.
+//
+// It relies on the stty command instead of system calls, so it works on any
+// Unix-like system without any dependencies.
+package term
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"unicode/utf8"
+)
+
+// ANSI escape sequences for drawing the screen.
+const (
+	// AltScreen switches to the terminal's alternate screen, which leaves the
+	// user's scrollback alone.
+	AltScreen = "\x1b[?1049h"
+	// MainScreen switches back from the alternate screen.
+	MainScreen = "\x1b[?1049l"
+	HideCursor = "\x1b[?25l"
+	ShowCursor = "\x1b[?25h"
+	// Home moves the cursor to the top left of the screen.
+	Home = "\x1b[H"
+	// ClearLine clears the rest of the line that the cursor is on.
+	ClearLine = "\x1b[K"
+	// ClearDown clears the screen below the cursor.
+	ClearDown = "\x1b[J"
+	Reverse   = "\x1b[7m"
+	Underline = "\x1b[4m"
+	Bold      = "\x1b[1m"
+	Reset     = "\x1b[0m"
+)
+
+// The keys that aren't printable characters. Every other key is returned by
+// ReadKey as the character it types.
+const (
+	KeyUp        = "up"
+	KeyDown      = "down"
+	KeyLeft      = "left"
+	KeyRight     = "right"
+	KeyHome      = "home"
+	KeyEnd       = "end"
+	KeyPageUp    = "pgup"
+	KeyPageDown  = "pgdown"
+	KeyDelete    = "delete"
+	KeyEnter     = "enter"
+	KeyEscape    = "esc"
+	KeyBackspace = "backspace"
+	KeyTab       = "tab"
+	KeyCtrlC     = "ctrl+c"
+	KeyCtrlL     = "ctrl+l"
+	// KeyUnknown is returned for escape sequences and control characters
+	// that term doesn't know.
+	KeyUnknown = "unknown"
+)
+
+// sequences maps the escape sequences that terminals send for special keys,
+// without the leading escape, to the keys.
+var sequences = map[string]string{
+	"[A": KeyUp, "[B": KeyDown, "[C": KeyRight, "[D": KeyLeft,
+	"OA": KeyUp, "OB": KeyDown, "OC": KeyRight, "OD": KeyLeft,
+	"[H": KeyHome, "[F": KeyEnd, "OH": KeyHome, "OF": KeyEnd,
+	"[1~": KeyHome, "[4~": KeyEnd, "[7~": KeyHome, "[8~": KeyEnd,
+	"[3~": KeyDelete, "[5~": KeyPageUp, "[6~": KeyPageDown,
+}
+
+// IsKey returns whether "key" is one of the special keys rather than a
+// character.
+func IsKey(key string) bool {
+	return utf8.RuneCountInString(key) != 1
+}
+
+// ReadKey reads a single key press from a terminal in raw mode. A lone escape
+// is told apart from the start of an escape sequence by whether anything else
+// has arrived with it, since terminals send a sequence in one write.
+func ReadKey(r *bufio.Reader) (string, error) {
+	c, _, err := r.ReadRune()
+	if err != nil {
+		return "", err
+	}
+	switch c {
+	case '\r', '\n':
+		return KeyEnter, nil
+	case '\t':
+		return KeyTab, nil
+	case 0x7f, 0x08:
+		return KeyBackspace, nil
+	case 0x03:
+		return KeyCtrlC, nil
+	case 0x0c:
+		return KeyCtrlL, nil
+	case 0x1b:
+		if r.Buffered() == 0 {
+			return KeyEscape, nil
+		}
+		return readSequence(r)
+	}
+	if c < 0x20 {
+		return KeyUnknown, nil
+	}
+	return string(c), nil
+}
+
+// readSequence reads the rest of an escape sequence after the escape.
+func readSequence(r *bufio.Reader) (string, error) {
+	var seq strings.Builder
+	for r.Buffered() > 0 {
+		b, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		seq.WriteByte(b)
+		// A sequence ends with its first letter or "~", not counting the
+		// "[" or "O" that starts it.
+		if seq.Len() > 1 && (b == '~' || ('A' <= b && b <= 'Z') || ('a' <= b && b <= 'z')) {
+			break
+		}
+	}
+	if key, ok := sequences[seq.String()]; ok {
+		return key, nil
+	}
+	return KeyUnknown, nil
+}
+
+// stty runs the stty command on the terminal "f" and returns its output.
+func stty(f *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = f
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("term: could not run stty: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// MakeRaw puts the terminal "f" into raw mode, so that key presses can be
+// read as they're typed without being echoed. The returned function restores
+// the terminal's previous state.
+func MakeRaw(f *os.File) (restore func() error, err error) {
+	state, err := stty(f, "-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty(f, "raw", "-echo"); err != nil {
+		return nil, err
+	}
+	return func() error {
+		_, err := stty(f, state)
+		return err
+	}, nil
+}
+
+// Size returns the width and height of the terminal "f" in characters.
+func Size(f *os.File) (width, height int, err error) {
+	out, err := stty(f, "size")
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, err := fmt.Sscan(out, &height, &width); err != nil {
+		return 0, 0, fmt.Errorf("term: could not read the size %q from stty: %w", out, err)
+	}
+	return width, height, nil
+}
+
+// Truncate shortens "s" to at most "width" characters, ending it with an
+// ellipsis if anything was cut off.
+func Truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + "…"
+}
+
+// Pad truncates or pads "s" with spaces on the right so that it's exactly
+// "width" characters long.
+func Pad(s string, width int) string {
+	s = Truncate(s, width)
+	return s + strings.Repeat(" ", width-utf8.RuneCountInString(s))
+}
+
+// PadLeft truncates or pads "s" with spaces on the left so that it's exactly
+// "width" characters long.
+func PadLeft(s string, width int) string {
+	s = Truncate(s, width)
+	return strings.Repeat(" ", width-utf8.RuneCountInString(s)) + s
+}
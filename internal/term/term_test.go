@@ -0,0 +1,68 @@
+package term
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadKey(t *testing.T) {
+	tests := []struct {
+		input string
+		keys  []string
+	}{
+		{"ab", []string{"a", "b"}},
+		{"é€", []string{"é", "€"}},
+		{"\r\n\t\x7f\x03", []string{KeyEnter, KeyEnter, KeyTab, KeyBackspace, KeyCtrlC}},
+		{"\x1b[A\x1b[B\x1b[C\x1b[D", []string{KeyUp, KeyDown, KeyRight, KeyLeft}},
+		{"\x1bOA\x1b[5~\x1b[6~\x1b[3~", []string{KeyUp, KeyPageUp, KeyPageDown, KeyDelete}},
+		{"\x1b[H\x1b[F\x1b[1~\x1b[4~", []string{KeyHome, KeyEnd, KeyHome, KeyEnd}},
+		{"\x1b[1;5Cx", []string{KeyUnknown, "x"}},
+		{"\x01", []string{KeyUnknown}},
+		{"\x1b", []string{KeyEscape}},
+	}
+	for _, test := range tests {
+		r := bufio.NewReader(strings.NewReader(test.input))
+		var keys []string
+		for {
+			key, err := ReadKey(r)
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			keys = append(keys, key)
+		}
+		if !reflect.DeepEqual(keys, test.keys) {
+			t.Errorf("ReadKey(%q) read %q, expected %q", test.input, keys, test.keys)
+		}
+	}
+}
+
+func TestPad(t *testing.T) {
+	tests := []struct {
+		input    string
+		width    int
+		pad      string
+		padLeft  string
+		truncate string
+	}{
+		{"abc", 5, "abc  ", "  abc", "abc"},
+		{"abcdef", 4, "abc…", "abc…", "abc…"},
+		{"café", 4, "café", "café", "café"},
+		{"abc", 0, "", "", ""},
+	}
+	for _, test := range tests {
+		if got := Pad(test.input, test.width); got != test.pad {
+			t.Errorf("Pad(%q, %d) = %q, expected %q", test.input, test.width, got, test.pad)
+		}
+		if got := PadLeft(test.input, test.width); got != test.padLeft {
+			t.Errorf("PadLeft(%q, %d) = %q, expected %q", test.input, test.width, got, test.padLeft)
+		}
+		if got := Truncate(test.input, test.width); got != test.truncate {
+			t.Errorf("Truncate(%q, %d) = %q, expected %q", test.input, test.width, got, test.truncate)
+		}
+	}
+}
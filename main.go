@@ -2,12 +2,23 @@ package main
 
 import (
 	"database/sql"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	_ "time/tzdata"
 
 	"github.com/Anthony-Fiddes/budgeter/cli/budgeter"
 	"github.com/Anthony-Fiddes/budgeter/internal/conf"
+	"github.com/Anthony-Fiddes/budgeter/model/attachment"
+	"github.com/Anthony-Fiddes/budgeter/model/currency"
+	"github.com/Anthony-Fiddes/budgeter/model/envelope"
+	"github.com/Anthony-Fiddes/budgeter/model/goal"
+	"github.com/Anthony-Fiddes/budgeter/model/journal"
+	"github.com/Anthony-Fiddes/budgeter/model/payee"
+	"github.com/Anthony-Fiddes/budgeter/model/person"
+	"github.com/Anthony-Fiddes/budgeter/model/recurring"
+	"github.com/Anthony-Fiddes/budgeter/model/rule"
 	"github.com/Anthony-Fiddes/budgeter/model/transaction"
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -28,8 +39,13 @@ func getConfigPath() string {
 	return filepath.Join(config, "budgeter_config.json")
 }
 
+// busyTimeout is how long, in milliseconds, a connection waits for another one
+// that's writing to the database before giving up, e.g. while serve is
+// running and another command is run.
+const busyTimeout = 5000
+
 func initDB(dbPath string) *sql.DB {
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := sql.Open("sqlite3", fmt.Sprintf("%s?_busy_timeout=%d", dbPath, busyTimeout))
 	if err != nil {
 		log.Fatalf("error opening database: %v", err)
 	}
@@ -46,9 +62,65 @@ func main() {
 	if err != nil {
 		log.Fatalf("could not initialize database transactions table: %v\n", err)
 	}
+	payeeTable := &payee.Table{DB: db}
+	err = payeeTable.Init()
+	if err != nil {
+		log.Fatalf("could not initialize database payees table: %v\n", err)
+	}
+	rateTable := &currency.Table{DB: db}
+	err = rateTable.Init()
+	if err != nil {
+		log.Fatalf("could not initialize database rates table: %v\n", err)
+	}
+	recurringTable := &recurring.Table{DB: db}
+	err = recurringTable.Init()
+	if err != nil {
+		log.Fatalf("could not initialize database recurring table: %v\n", err)
+	}
+	ruleTable := &rule.Table{DB: db}
+	err = ruleTable.Init()
+	if err != nil {
+		log.Fatalf("could not initialize database rules table: %v\n", err)
+	}
+	journalTable := &journal.Table{DB: db}
+	err = journalTable.Init()
+	if err != nil {
+		log.Fatalf("could not initialize database journal tables: %v\n", err)
+	}
+	peopleTable := &person.Table{DB: db}
+	err = peopleTable.Init()
+	if err != nil {
+		log.Fatalf("could not initialize database people table: %v\n", err)
+	}
+	envelopeTable := &envelope.Table{DB: db}
+	err = envelopeTable.Init()
+	if err != nil {
+		log.Fatalf("could not initialize database envelope table: %v\n", err)
+	}
+	goalTable := &goal.Table{DB: db}
+	err = goalTable.Init()
+	if err != nil {
+		log.Fatalf("could not initialize database goal tables: %v\n", err)
+	}
+	attachmentTable := &attachment.Table{DB: db}
+	err = attachmentTable.Init()
+	if err != nil {
+		log.Fatalf("could not initialize database attachment tables: %v\n", err)
+	}
 	app := budgeter.CLI{
+		Attachments:  attachmentTable,
+		Audit:        table,
 		Config:       &conf.JSONFile{Path: configPath},
+		DB:           db,
 		DBPath:       dbPath,
+		Envelopes:    envelopeTable,
+		Goals:        goalTable,
+		Journal:      journalTable,
+		Payees:       payeeTable,
+		People:       peopleTable,
+		Rates:        rateTable,
+		Recurring:    recurringTable,
+		Rules:        ruleTable,
 		Transactions: &transaction.Table{DB: db},
 	}
 	os.Exit(app.Run(os.Args))
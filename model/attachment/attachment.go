@@ -0,0 +1,47 @@
+// attachment provides a model for files, like receipts, that are attached to
+// transactions. It also provides a simple implementation of a sqlite table for
+// storing them.
+package attachment
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+const (
+	TableName      = "attachments"
+	BlobsTableName = "attachment_blobs"
+	IDCol          = "ID"
+	TransactionCol = "TransactionID"
+	NameCol        = "Name"
+	HashCol        = "Hash"
+	SizeCol        = "Size"
+	AddedCol       = "Added"
+	DataCol        = "Data"
+)
+
+// ErrNotFound is returned when there's no attachment with the given ID.
+var ErrNotFound = errors.New("attachment: no such attachment")
+
+// Attachment is a file attached to a transaction. Its contents are stored
+// separately by their hash, so attaching the same file more than once only
+// stores it once.
+type Attachment struct {
+	ID            int
+	TransactionID int
+	// Name is the name of the file that was attached, without its directory.
+	Name string
+	// Hash is the hex encoded SHA-256 hash of the file's contents.
+	Hash string
+	// Size is the size of the file in bytes.
+	Size int64
+	// Added is when the file was attached, in Unix seconds.
+	Added int64
+}
+
+// Hash returns the hash that the contents of a file are stored by.
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
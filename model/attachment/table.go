@@ -0,0 +1,257 @@
+package attachment
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+// Table is the attachments table, and the table of their contents, in a
+// database.
+type Table struct{ DB *sql.DB }
+
+// Init creates the attachment tables if they don't exist.
+func (t *Table) Init() error {
+	_, err := t.DB.Exec(
+		fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s (%s TEXT NOT NULL PRIMARY KEY, %s BLOB NOT NULL)",
+			BlobsTableName,
+			HashCol,
+			DataCol,
+		),
+	)
+	if err != nil {
+		return fmt.Errorf("attachment: cannot create table: %w", err)
+	}
+	_, err = t.DB.Exec(
+		fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s "+
+				"(%s INTEGER NOT NULL PRIMARY KEY, %s INTEGER NOT NULL, %s TEXT NOT NULL, "+
+				"%s TEXT NOT NULL REFERENCES %s(%s), %s INTEGER NOT NULL, %s INTEGER NOT NULL)",
+			TableName,
+			IDCol,
+			TransactionCol,
+			NameCol,
+			HashCol,
+			BlobsTableName,
+			HashCol,
+			SizeCol,
+			AddedCol,
+		),
+	)
+	if err != nil {
+		return fmt.Errorf("attachment: cannot create table: %w", err)
+	}
+	return nil
+}
+
+// Add attaches a file with the given name and contents to a transaction.
+func (t *Table) Add(transactionID int, name string, data []byte, at time.Time) (Attachment, error) {
+	a := Attachment{
+		TransactionID: transactionID,
+		Name:          name,
+		Hash:          Hash(data),
+		Size:          int64(len(data)),
+		Added:         at.Unix(),
+	}
+	tx, err := t.DB.Begin()
+	if err != nil {
+		return Attachment{}, fmt.Errorf("attachment: could not begin a database transaction: %w", err)
+	}
+	defer tx.Rollback()
+	_, err = tx.Exec(
+		fmt.Sprintf("INSERT OR IGNORE INTO %s(%s, %s) VALUES (?, ?)", BlobsTableName, HashCol, DataCol),
+		a.Hash,
+		data,
+	)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("attachment: could not store \"%s\": %w", name, err)
+	}
+	result, err := tx.Exec(
+		fmt.Sprintf(
+			"INSERT INTO %s(%s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?)",
+			TableName,
+			TransactionCol,
+			NameCol,
+			HashCol,
+			SizeCol,
+			AddedCol,
+		),
+		a.TransactionID,
+		a.Name,
+		a.Hash,
+		a.Size,
+		a.Added,
+	)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("attachment: could not attach \"%s\": %w", name, err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return Attachment{}, fmt.Errorf("attachment: could not get the ID of \"%s\": %w", name, err)
+	}
+	a.ID = int(id)
+	if err := tx.Commit(); err != nil {
+		return Attachment{}, fmt.Errorf("attachment: could not commit: %w", err)
+	}
+	return a, nil
+}
+
+// selectAttachments is the start of a query for attachments that scan reads.
+var selectAttachments = fmt.Sprintf(
+	"SELECT %s, %s, %s, %s, %s, %s FROM %s",
+	IDCol,
+	TransactionCol,
+	NameCol,
+	HashCol,
+	SizeCol,
+	AddedCol,
+	TableName,
+)
+
+// scan reads attachments from rows selected with selectAttachments.
+func scan(rows *sql.Rows) ([]Attachment, error) {
+	defer rows.Close()
+	var result []Attachment
+	for rows.Next() {
+		a := Attachment{}
+		err := rows.Scan(&a.ID, &a.TransactionID, &a.Name, &a.Hash, &a.Size, &a.Added)
+		if err != nil {
+			return nil, fmt.Errorf("attachment: could not scan attachment: %w", err)
+		}
+		result = append(result, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("attachment: could not query table: %w", err)
+	}
+	return result, nil
+}
+
+// List returns the attachments of the transaction with the given ID, or of
+// every transaction if the ID is 0, in the order they were added.
+func (t *Table) List(transactionID int) ([]Attachment, error) {
+	query := selectAttachments
+	var args []interface{}
+	if transactionID != 0 {
+		query += fmt.Sprintf(" WHERE %s=?", TransactionCol)
+		args = append(args, transactionID)
+	}
+	rows, err := t.DB.Query(query+fmt.Sprintf(" ORDER BY %s ASC", IDCol), args...)
+	if err != nil {
+		return nil, fmt.Errorf("attachment: could not query table: %w", err)
+	}
+	return scan(rows)
+}
+
+// Counts returns the number of attachments that each transaction with any
+// has, keyed by transaction ID.
+func (t *Table) Counts() (map[int]int, error) {
+	rows, err := t.DB.Query(
+		fmt.Sprintf("SELECT %s, COUNT(*) FROM %s GROUP BY %s", TransactionCol, TableName, TransactionCol),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("attachment: could not query table: %w", err)
+	}
+	defer rows.Close()
+	result := make(map[int]int)
+	for rows.Next() {
+		var id, count int
+		if err := rows.Scan(&id, &count); err != nil {
+			return nil, fmt.Errorf("attachment: could not scan counts: %w", err)
+		}
+		result[id] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("attachment: could not query table: %w", err)
+	}
+	return result, nil
+}
+
+// Get returns the attachment with the given ID and its contents, or
+// ErrNotFound if there isn't one.
+func (t *Table) Get(attachmentID int) (Attachment, []byte, error) {
+	rows, err := t.DB.Query(selectAttachments+fmt.Sprintf(" WHERE %s=?", IDCol), attachmentID)
+	if err != nil {
+		return Attachment{}, nil, fmt.Errorf("attachment: could not query table: %w", err)
+	}
+	found, err := scan(rows)
+	if err != nil {
+		return Attachment{}, nil, err
+	}
+	if len(found) == 0 {
+		return Attachment{}, nil, fmt.Errorf("%w #%d", ErrNotFound, attachmentID)
+	}
+	a := found[0]
+	var data []byte
+	err = t.DB.QueryRow(
+		fmt.Sprintf("SELECT %s FROM %s WHERE %s=?", DataCol, BlobsTableName, HashCol),
+		a.Hash,
+	).Scan(&data)
+	if err != nil {
+		return Attachment{}, nil, fmt.Errorf("attachment: could not read \"%s\": %w", a.Name, err)
+	}
+	if Hash(data) != a.Hash {
+		return Attachment{}, nil, fmt.Errorf("attachment: the contents of \"%s\" are corrupted", a.Name)
+	}
+	return a, data, nil
+}
+
+// Remove detaches the attachment with the given ID. Its contents are deleted
+// if nothing else is attached with the same contents.
+func (t *Table) Remove(attachmentID int) error {
+	result, err := t.DB.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s=?", TableName, IDCol), attachmentID)
+	if err != nil {
+		return fmt.Errorf("attachment: could not remove attachment #%d: %w", attachmentID, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%w #%d", ErrNotFound, attachmentID)
+	}
+	return t.deleteUnused()
+}
+
+// Prune removes the attachments of transactions that no longer exist, e.g.
+// because they were purged from the trash, and returns how many there were.
+// Transactions in the trash keep their attachments, so that they're still
+// there if they're restored.
+func (t *Table) Prune() (int, error) {
+	result, err := t.DB.Exec(
+		fmt.Sprintf(
+			"DELETE FROM %s WHERE %s NOT IN (SELECT %s FROM %s)",
+			TableName,
+			TransactionCol,
+			transaction.IDCol,
+			transaction.TableName,
+		),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("attachment: could not prune attachments: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(n), t.deleteUnused()
+}
+
+// deleteUnused deletes the contents that no attachment refers to anymore.
+func (t *Table) deleteUnused() error {
+	_, err := t.DB.Exec(
+		fmt.Sprintf(
+			"DELETE FROM %s WHERE %s NOT IN (SELECT %s FROM %s)",
+			BlobsTableName,
+			HashCol,
+			HashCol,
+			TableName,
+		),
+	)
+	if err != nil {
+		return fmt.Errorf("attachment: could not delete unused contents: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package attachment_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/model/attachment"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestTable(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection to ":memory:" gets its own database.
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+	transactions := &transaction.Table{DB: db}
+	if err := transactions.Init(); err != nil {
+		t.Fatal(err)
+	}
+	table := &attachment.Table{DB: db}
+	if err := table.Init(); err != nil {
+		t.Fatal(err)
+	}
+	txID, err := transactions.Insert(transaction.Transaction{Entity: "Best Buy", Amount: -49999, Date: 86400})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receipt := []byte("receipt")
+	at := time.Unix(1600000000, 0)
+	first, err := table.Add(txID, "receipt.jpg", receipt, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The same file attached twice is only stored once.
+	second, err := table.Add(txID, "copy.jpg", receipt, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	warranty, err := table.Add(txID, "warranty.pdf", []byte("warranty"), at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Hash != second.Hash || first.Size != 7 || first.Added != at.Unix() {
+		t.Errorf("unexpected attachments %+v and %+v", first, second)
+	}
+	var blobs int
+	if err := db.QueryRow("SELECT COUNT(*) FROM " + attachment.BlobsTableName).Scan(&blobs); err != nil {
+		t.Fatal(err)
+	}
+	if blobs != 2 {
+		t.Errorf("expected 2 stored files but found %d", blobs)
+	}
+
+	list, err := table.List(txID)
+	if err != nil || len(list) != 3 || list[0] != first || list[2] != warranty {
+		t.Errorf("expected all 3 attachments in order but got %+v: %v", list, err)
+	}
+	counts, err := table.Counts()
+	if err != nil || len(counts) != 1 || counts[txID] != 3 {
+		t.Errorf("expected 3 attachments for #%d but got %v: %v", txID, counts, err)
+	}
+	a, data, err := table.Get(warranty.ID)
+	if err != nil || a != warranty || string(data) != "warranty" {
+		t.Errorf("expected to get the warranty but got %+v, %q: %v", a, data, err)
+	}
+
+	// The contents stay until nothing is attached with them.
+	if err := table.Remove(first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, data, err := table.Get(second.ID); err != nil || string(data) != "receipt" {
+		t.Errorf("expected the copy to still be readable but got %q: %v", data, err)
+	}
+	if err := table.Remove(first.ID); !errors.Is(err, attachment.ErrNotFound) {
+		t.Errorf("expected ErrNotFound but got %v", err)
+	}
+
+	// Attachments of purged transactions are pruned, but not those of
+	// transactions in the trash.
+	if err := transactions.Remove(txID); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := table.Prune(); err != nil || n != 0 {
+		t.Errorf("expected nothing to be pruned but got %d: %v", n, err)
+	}
+	if _, err := transactions.Purge(time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := table.Prune(); err != nil || n != 2 {
+		t.Errorf("expected 2 attachments to be pruned but got %d: %v", n, err)
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM " + attachment.BlobsTableName).Scan(&blobs); err != nil {
+		t.Fatal(err)
+	}
+	if blobs != 0 {
+		t.Errorf("expected the stored files to be deleted but found %d", blobs)
+	}
+}
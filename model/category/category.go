@@ -0,0 +1,165 @@
+// category suggests categories for transactions by learning from the ones that
+// have already been categorized. Everything is computed locally from the
+// transactions it's given.
+package category
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+// Classifier is a naive Bayes classifier that suggests a transaction's
+// Category from its Entity.
+type Classifier struct {
+	// exact counts the categories given to each normalized entity.
+	exact map[string]map[string]int
+	// tokens counts the tokens of the entities in each category.
+	tokens map[string]map[string]int
+	// tokenTotals is the total number of tokens seen in each category.
+	tokenTotals map[string]int
+	// examples is the number of transactions seen in each category.
+	examples map[string]int
+	total    int
+	vocab    map[string]bool
+}
+
+// Train returns a Classifier that has learned from every transaction in "txs"
+// that has a Category.
+func Train(txs []transaction.Transaction) *Classifier {
+	c := &Classifier{
+		exact:       make(map[string]map[string]int),
+		tokens:      make(map[string]map[string]int),
+		tokenTotals: make(map[string]int),
+		examples:    make(map[string]int),
+		vocab:       make(map[string]bool),
+	}
+	for _, tx := range txs {
+		c.Learn(tx)
+	}
+	return c
+}
+
+// tokenize splits an entity into lowercase words, leaving out numbers and
+// single characters, which are usually store numbers and noise like "#".
+func tokenize(entity string) []string {
+	words := strings.FieldsFunc(strings.ToLower(entity), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var result []string
+	for _, w := range words {
+		if len([]rune(w)) < 2 || strings.IndexFunc(w, unicode.IsLetter) == -1 {
+			continue
+		}
+		result = append(result, w)
+	}
+	return result
+}
+
+func normalize(entity string) string {
+	return strings.Join(tokenize(entity), " ")
+}
+
+// Learn teaches the classifier the Category of "tx". Transactions without a
+// Category are ignored.
+func (c *Classifier) Learn(tx transaction.Transaction) {
+	if tx.Category == "" {
+		return
+	}
+	key := normalize(tx.Entity)
+	if c.exact[key] == nil {
+		c.exact[key] = make(map[string]int)
+	}
+	c.exact[key][tx.Category]++
+
+	if c.tokens[tx.Category] == nil {
+		c.tokens[tx.Category] = make(map[string]int)
+	}
+	for _, token := range tokenize(tx.Entity) {
+		c.tokens[tx.Category][token]++
+		c.tokenTotals[tx.Category]++
+		c.vocab[token] = true
+	}
+	c.examples[tx.Category]++
+	c.total++
+}
+
+// Suggest returns the most likely Category for a transaction with the given
+// Entity and how confident the classifier is in it, from 0 to 1. If the
+// classifier has never seen anything like "entity", it returns "" and 0.
+//
+// Entities that have been categorized before are suggested the category they
+// were given most often. Otherwise, the suggestion is based on the words that
+// the entity shares with categorized ones.
+func (c *Classifier) Suggest(entity string) (string, float64) {
+	if key := normalize(entity); key != "" {
+		if counts, ok := c.exact[key]; ok {
+			return best(counts)
+		}
+	}
+
+	var known []string
+	for _, token := range tokenize(entity) {
+		if c.vocab[token] {
+			known = append(known, token)
+		}
+	}
+	if len(known) == 0 {
+		return "", 0
+	}
+
+	// Compare the log probabilities of each category, using Laplace smoothing
+	// so that tokens a category hasn't seen don't rule it out.
+	categories := c.categories()
+	scores := make([]float64, len(categories))
+	for i, category := range categories {
+		score := math.Log(float64(c.examples[category]) / float64(c.total))
+		denominator := float64(c.tokenTotals[category] + len(c.vocab))
+		for _, token := range known {
+			score += math.Log(float64(c.tokens[category][token]+1) / denominator)
+		}
+		scores[i] = score
+	}
+
+	bestIndex := 0
+	for i := range scores {
+		if scores[i] > scores[bestIndex] {
+			bestIndex = i
+		}
+	}
+	// Turn the scores back into a probability for the best category.
+	var sum float64
+	for _, score := range scores {
+		sum += math.Exp(score - scores[bestIndex])
+	}
+	return categories[bestIndex], 1 / sum
+}
+
+// categories returns every category the classifier has learned, sorted so
+// that suggestions are deterministic.
+func (c *Classifier) categories() []string {
+	var result []string
+	for category := range c.examples {
+		result = append(result, category)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// best returns the category with the highest count and the fraction of the
+// total that it makes up. Ties go to the category that sorts first.
+func best(counts map[string]int) (string, float64) {
+	var result string
+	var most, total int
+	for category, count := range counts {
+		total += count
+		if count > most || (count == most && category < result) {
+			result = category
+			most = count
+		}
+	}
+	return result, float64(most) / float64(total)
+}
@@ -0,0 +1,62 @@
+package category_test
+
+import (
+	"testing"
+
+	"github.com/Anthony-Fiddes/budgeter/model/category"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+func TestSuggest(t *testing.T) {
+	history := []transaction.Transaction{
+		{Entity: "KROGER #1234 COLUMBUS OH", Category: "Groceries"},
+		{Entity: "KROGER #5678 DUBLIN OH", Category: "Groceries"},
+		{Entity: "KROGER FUEL 567", Category: "Gas"},
+		{Entity: "SHELL OIL 5745", Category: "Gas"},
+		{Entity: "SHELL OIL 5745", Category: "Gas"},
+		{Entity: "SHELL OIL 5745", Category: "Car"},
+		{Entity: "Falafel King", Category: "Restaurants"},
+		{Entity: "Burger King", Category: "Restaurants"},
+		{Entity: "Lyft", Category: ""},
+	}
+	c := category.Train(history)
+
+	tests := []struct {
+		entity   string
+		expected string
+	}{
+		// exact matches ignore case, punctuation and numbers
+		{entity: "kroger #1234 columbus oh", expected: "Groceries"},
+		{entity: "SHELL OIL 1111", expected: "Gas"},
+		// similar entities
+		{entity: "KROGER #9999 WORTHINGTON OH", expected: "Groceries"},
+		{entity: "KROGER FUEL 99", expected: "Gas"},
+		{entity: "Chicken King", expected: "Restaurants"},
+		// never seen
+		{entity: "Lyft", expected: ""},
+		{entity: "#1234", expected: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.entity, func(t *testing.T) {
+			result, confidence := c.Suggest(test.entity)
+			if result != test.expected {
+				t.Fatalf("received %q (%.2f) but expected %q", result, confidence, test.expected)
+			}
+			if result == "" && confidence != 0 {
+				t.Fatalf("expected no confidence without a suggestion but received %.2f", confidence)
+			}
+			if result != "" && (confidence <= 0 || confidence > 1) {
+				t.Fatalf("confidence %.2f is not a probability", confidence)
+			}
+		})
+	}
+
+	if _, confidence := c.Suggest("SHELL OIL 5745"); confidence > 0.7 || confidence < 0.6 {
+		t.Fatalf("expected confidence of 2/3 for an entity categorized inconsistently but received %.2f", confidence)
+	}
+
+	c.Learn(transaction.Transaction{Entity: "Lyft", Category: "Transportation"})
+	if result, _ := c.Suggest("LYFT"); result != "Transportation" {
+		t.Fatalf("expected the classifier to learn from new transactions but it suggested %q", result)
+	}
+}
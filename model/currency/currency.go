@@ -0,0 +1,110 @@
+// currency provides the ISO 4217 currencies that budgeter knows about and how
+// many minor units (e.g. cents) each one has. It also provides a simple
+// implementation of a sqlite table for storing exchange rates between them.
+package currency
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Currency describes an ISO 4217 currency.
+type Currency struct {
+	// Code is the currency's ISO 4217 code, e.g. "USD".
+	Code string
+	// Symbol is written before amounts in the currency, e.g. "$".
+	Symbol string
+	// Exponent is the number of digits after the decimal point in amounts of
+	// the currency, e.g. 2 for USD and 0 for JPY. Amounts are stored as whole
+	// numbers of minor units, so $1.50 is stored as 150.
+	Exponent int
+}
+
+// USD is the currency that budgeter uses when no other one is given.
+var USD = Currency{"USD", "$", 2}
+
+var currencies = map[string]Currency{}
+
+func init() {
+	for _, c := range []Currency{
+		USD,
+		{"AUD", "A$", 2},
+		{"BHD", "BD", 3},
+		{"BRL", "R$", 2},
+		{"CAD", "CA$", 2},
+		{"CHF", "CHF", 2},
+		{"CLP", "CLP$", 0},
+		{"CNY", "CN¥", 2},
+		{"CZK", "Kč", 2},
+		{"DKK", "kr.", 2},
+		{"EUR", "€", 2},
+		{"GBP", "£", 2},
+		{"HKD", "HK$", 2},
+		{"HUF", "Ft", 2},
+		{"IDR", "Rp", 2},
+		{"ILS", "₪", 2},
+		{"INR", "₹", 2},
+		{"ISK", "kr", 0},
+		{"JOD", "JD", 3},
+		{"JPY", "¥", 0},
+		{"KRW", "₩", 0},
+		{"KWD", "KD", 3},
+		{"MXN", "MX$", 2},
+		{"NOK", "kr", 2},
+		{"NZD", "NZ$", 2},
+		{"OMR", "OMR", 3},
+		{"PHP", "₱", 2},
+		{"PLN", "zł", 2},
+		{"SEK", "kr", 2},
+		{"SGD", "S$", 2},
+		{"THB", "฿", 2},
+		{"TND", "DT", 3},
+		{"TRY", "₺", 2},
+		{"TWD", "NT$", 2},
+		{"VND", "₫", 0},
+		{"ZAR", "R", 2},
+	} {
+		currencies[c.Code] = c
+	}
+}
+
+// Lookup returns the currency with the given ISO 4217 code. Codes aren't case
+// sensitive.
+func Lookup(code string) (Currency, error) {
+	c, ok := currencies[strings.ToUpper(strings.TrimSpace(code))]
+	if !ok {
+		return Currency{}, fmt.Errorf("currency: unknown currency code \"%s\"", code)
+	}
+	return c, nil
+}
+
+// Codes returns the codes of every currency that Lookup knows, sorted.
+func Codes() []string {
+	var result []string
+	for code := range currencies {
+		result = append(result, code)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// ErrOutOfRange is returned when a converted amount doesn't fit in an int64.
+var ErrOutOfRange = errors.New("currency: converted amount is out of range")
+
+// Convert converts "amount" minor units of "from" into minor units of "to",
+// where one unit of "from" is worth "rate" units of "to". The result is
+// rounded to the nearest minor unit, with halves rounded away from zero. It
+// returns ErrOutOfRange if the result doesn't fit in an int64.
+func Convert(amount int64, from, to Currency, rate float64) (int64, error) {
+	scale := math.Pow10(to.Exponent - from.Exponent)
+	result := math.Round(float64(amount) * rate * scale)
+	// -2^63 is exactly representable as a float64, but 2^63-1 isn't, so the
+	// upper bound is exclusive. NaN fails both comparisons.
+	if !(result >= math.MinInt64 && result < -math.MinInt64) {
+		return 0, fmt.Errorf("%w: %d %s at %g", ErrOutOfRange, amount, from.Code, rate)
+	}
+	return int64(result), nil
+}
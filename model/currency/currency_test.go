@@ -0,0 +1,135 @@
+package currency_test
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/Anthony-Fiddes/budgeter/model/currency"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func lookup(t *testing.T, code string) currency.Currency {
+	c, err := currency.Lookup(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestLookup(t *testing.T) {
+	for code, exponent := range map[string]int{"usd": 2, "JPY": 0, " BHD ": 3} {
+		if c := lookup(t, code); c.Exponent != exponent {
+			t.Errorf("%s has exponent %d but expected %d", code, c.Exponent, exponent)
+		}
+	}
+	if _, err := currency.Lookup("XYZ"); err == nil {
+		t.Error("expected an unknown code to be rejected")
+	}
+}
+
+func TestConvert(t *testing.T) {
+	usd, jpy, bhd := lookup(t, "USD"), lookup(t, "JPY"), lookup(t, "BHD")
+	tests := []struct {
+		name     string
+		amount   int64
+		from, to currency.Currency
+		rate     float64
+		expected int64
+	}{
+		{name: "same currency", amount: 1234, from: usd, to: usd, rate: 1, expected: 1234},
+		// $12.34 at 110.5 yen to the dollar is 1363.57 yen
+		{name: "fewer minor units", amount: 1234, from: usd, to: jpy, rate: 110.5, expected: 1364},
+		// 1000 yen at 0.009 dollars to the yen is $9.00
+		{name: "more minor units", amount: 1000, from: jpy, to: usd, rate: 0.009, expected: 900},
+		// $10.00 at 0.376 dinars to the dollar is 3.760 dinars
+		{name: "three decimals", amount: 1000, from: usd, to: bhd, rate: 0.376, expected: 3760},
+		{name: "negative", amount: -1234, from: usd, to: jpy, rate: 110.5, expected: -1364},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := currency.Convert(test.amount, test.from, test.to, test.rate)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result != test.expected {
+				t.Fatalf("received %d but expected %d", result, test.expected)
+			}
+		})
+	}
+
+	for _, rate := range []float64{1e10, -1e10, math.Inf(1), math.NaN()} {
+		if _, err := currency.Convert(math.MaxInt64/2, usd, jpy, rate); !errors.Is(err, currency.ErrOutOfRange) {
+			t.Errorf("expected ErrOutOfRange converting at %g but got %v", rate, err)
+		}
+	}
+}
+
+func TestTable(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	table := &currency.Table{DB: db}
+	if err := table.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	const day = 24 * 60 * 60
+	rates := []currency.Rate{
+		{Date: 10 * day, From: "EUR", To: "USD", Rate: 1.1},
+		{Date: 20 * day, From: "EUR", To: "USD", Rate: 1.2},
+		{Date: 20 * day, From: "eur", To: "usd", Rate: 1.25},
+		{Date: 15 * day, From: "USD", To: "JPY", Rate: 100},
+	}
+	for _, r := range rates {
+		if err := table.Put(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := table.Put(currency.Rate{Date: day, From: "EUR", To: "XYZ", Rate: 1}); err == nil {
+		t.Fatal("expected a rate with an unknown currency to be rejected")
+	}
+	if err := table.Put(currency.Rate{Date: day, From: "EUR", To: "USD", Rate: 0}); err == nil {
+		t.Fatal("expected a rate of zero to be rejected")
+	}
+
+	tests := []struct {
+		name     string
+		from, to string
+		date     int64
+		expected float64
+	}{
+		{name: "same currency", from: "USD", to: "USD", date: 0, expected: 1},
+		{name: "before every rate", from: "EUR", to: "USD", date: 0, expected: 1.1},
+		{name: "exact date", from: "EUR", to: "USD", date: 10 * day, expected: 1.1},
+		{name: "latest before date", from: "EUR", to: "USD", date: 19 * day, expected: 1.1},
+		{name: "replaced rate", from: "EUR", to: "USD", date: 30 * day, expected: 1.25},
+		{name: "inverted", from: "USD", to: "EUR", date: 30 * day, expected: 1 / 1.25},
+		{name: "other pair", from: "JPY", to: "USD", date: 30 * day, expected: 0.01},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := table.Rate(test.from, test.to, test.date)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(result-test.expected) > 1e-9 {
+				t.Fatalf("received %f but expected %f", result, test.expected)
+			}
+		})
+	}
+
+	if _, err := table.Rate("EUR", "JPY", 0); !errors.Is(err, currency.ErrNoRate) {
+		t.Fatalf("expected ErrNoRate but received %v", err)
+	}
+	all, err := table.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("expected 3 rates but received %+v", all)
+	}
+}
@@ -0,0 +1,168 @@
+package currency
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+const (
+	TableName = "rates"
+	DateCol   = "Date"
+	FromCol   = "FromCurrency"
+	ToCol     = "ToCurrency"
+	RateCol   = "Rate"
+)
+
+// ErrNoRate is returned when there is no exchange rate between two currencies.
+var ErrNoRate = errors.New("currency: no exchange rate")
+
+// Rate is the exchange rate between two currencies on a certain day.
+type Rate struct {
+	// Date is the Unix time in seconds of the day the rate is for.
+	Date int64
+	// From is the code of the currency being converted from.
+	From string
+	// To is the code of the currency being converted to.
+	To string
+	// Rate is how many units of To one unit of From is worth.
+	Rate float64
+}
+
+// Table is the exchange rates table in a database
+type Table struct{ DB *sql.DB }
+
+// Init creates the exchange rates table if it doesn't exist.
+func (t *Table) Init() error {
+	_, err := t.DB.Exec(
+		fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s "+
+				"(%s INTEGER NOT NULL, %s TEXT NOT NULL, %s TEXT NOT NULL, %s REAL NOT NULL, "+
+				"PRIMARY KEY(%s,%s,%s))",
+			TableName,
+			DateCol,
+			FromCol,
+			ToCol,
+			RateCol,
+			DateCol,
+			FromCol,
+			ToCol,
+		),
+	)
+	if err != nil {
+		return fmt.Errorf(
+			"currency: cannot create table: %w", err,
+		)
+	}
+	return nil
+}
+
+// Put adds an exchange rate to the table, replacing the rate between the same
+// currencies on the same day if there is one.
+func (t *Table) Put(r Rate) error {
+	from, err := Lookup(r.From)
+	if err != nil {
+		return err
+	}
+	to, err := Lookup(r.To)
+	if err != nil {
+		return err
+	}
+	if r.Rate <= 0 {
+		return fmt.Errorf("currency: exchange rate from %s to %s must be positive", from.Code, to.Code)
+	}
+	_, err = t.DB.Exec(
+		fmt.Sprintf(
+			"INSERT OR REPLACE INTO %s(%s, %s, %s, %s) VALUES (?, ?, ?, ?)",
+			TableName,
+			DateCol,
+			FromCol,
+			ToCol,
+			RateCol,
+		),
+		r.Date,
+		from.Code,
+		to.Code,
+		r.Rate,
+	)
+	if err != nil {
+		return fmt.Errorf("currency: could not insert %+v: %w", r, err)
+	}
+	return nil
+}
+
+// Rate returns how many units of "to" one unit of "from" was worth on "date".
+// It uses the latest rate from on or before "date", or the earliest rate after
+// it if there aren't any. Rates from "to" to "from" are inverted if there is
+// no rate in the other direction.
+func (t *Table) Rate(from, to string, date int64) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+	// Rates in both directions are considered together, preferring the ones
+	// closest to "date", and then the ones in the right direction.
+	row := t.DB.QueryRow(
+		fmt.Sprintf(
+			"SELECT %s, %s FROM %s WHERE (%s=? AND %s=?) OR (%s=? AND %s=?) "+
+				"ORDER BY %s > ? ASC, ABS(%s - ?) ASC, %s=? DESC LIMIT 1",
+			FromCol,
+			RateCol,
+			TableName,
+			FromCol,
+			ToCol,
+			FromCol,
+			ToCol,
+			DateCol,
+			DateCol,
+			FromCol,
+		),
+		from, to, to, from,
+		date, date, from,
+	)
+	var rateFrom string
+	var rate float64
+	err := row.Scan(&rateFrom, &rate)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("currency: could not convert %s to %s: %w", from, to, ErrNoRate)
+	} else if err != nil {
+		return 0, fmt.Errorf("currency: could not query table: %w", err)
+	}
+	if rateFrom != from {
+		rate = 1 / rate
+	}
+	return rate, nil
+}
+
+// All returns every rate in the table, ordered by date.
+func (t *Table) All() ([]Rate, error) {
+	rows, err := t.DB.Query(
+		fmt.Sprintf(
+			"SELECT %s, %s, %s, %s FROM %s ORDER BY %s ASC, %s ASC, %s ASC",
+			DateCol,
+			FromCol,
+			ToCol,
+			RateCol,
+			TableName,
+			DateCol,
+			FromCol,
+			ToCol,
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("currency: could not query table: %w", err)
+	}
+	defer rows.Close()
+
+	var result []Rate
+	for rows.Next() {
+		r := Rate{}
+		if err := rows.Scan(&r.Date, &r.From, &r.To, &r.Rate); err != nil {
+			return nil, fmt.Errorf("currency: could not scan rate: %w", err)
+		}
+		result = append(result, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("currency: failed to scan result set: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,148 @@
+// envelope provides a model for envelope, or zero-based, budgeting. Income
+// goes into a pool of money that's "to be budgeted", the user assigns it to
+// envelopes for their categories each month, and spending in a category draws
+// its envelope down. Whatever is left in an envelope rolls over to the next
+// month. It also provides a simple implementation of a sqlite table for
+// storing assignments.
+package envelope
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+const (
+	TableName   = "envelope_assignments"
+	MonthCol    = "Month"
+	CategoryCol = "Category"
+	AmountCol   = "Amount"
+)
+
+// Assignment is money assigned to the envelope of a category for a month, or
+// taken out of it if its amount is negative.
+type Assignment struct {
+	// Month is the first day of the month, stored in the same form as
+	// transaction dates. See MonthOf.
+	Month int64
+	// Category is the category of the envelope. Categories aren't case
+	// sensitive.
+	Category string
+	// Amount is in the home currency.
+	Amount transaction.Cent
+}
+
+// MonthOf returns the first day of the month that "date" is in. Both are in
+// the same form as transaction dates.
+func MonthOf(date int64) int64 {
+	d := time.Unix(date, 0).UTC()
+	return time.Date(d.Year(), d.Month(), 1, 0, 0, 0, 0, time.UTC).Unix()
+}
+
+// Activity is the total of the transactions made in a month in each category,
+// in the home currency. Uncategorized transactions are under "".
+type Activity map[string]transaction.Cent
+
+// Envelope is the money set aside for a category in a month.
+type Envelope struct {
+	Category string
+	// Carried is the balance that rolled over from the months before. It's
+	// negative if the envelope was overspent.
+	Carried transaction.Cent
+	// Assigned is how much was assigned to the envelope this month.
+	Assigned transaction.Cent
+	// Activity is the total of the category's transactions this month.
+	// Spending is negative, and refunds are positive.
+	Activity transaction.Cent
+	// Balance is what's left in the envelope at the end of the month.
+	Balance transaction.Cent
+}
+
+// Budget is the state of every envelope in a month.
+type Budget struct {
+	// Month is the first day of the month, stored in the same form as
+	// transaction dates.
+	Month int64
+	// ToBeBudgeted is the money that hasn't been assigned to an envelope by
+	// the end of the month.
+	ToBeBudgeted transaction.Cent
+	// Envelopes are sorted by category.
+	Envelopes []Envelope
+}
+
+// Compute returns the budget for "month", for envelope budgeting that started
+// in the month "start" with "opening" to be budgeted, e.g. the money that
+// the user had at the time. "activity" is keyed by month.
+//
+// Every category that money has been assigned to has an envelope. The
+// transactions in the other categories, and those without a category, go to
+// what's to be budgeted instead: income adds to it, and spending takes from
+// it. Assignments and activity from before "start" or after "month" are
+// ignored.
+func Compute(start int64, opening transaction.Cent, assignments []Assignment, activity map[int64]Activity,
+	month int64) (Budget, error) {
+	result := Budget{Month: month, ToBeBudgeted: opening}
+	envelopes := make(map[string]*Envelope)
+	for _, a := range assignments {
+		key := strings.ToLower(a.Category)
+		if _, ok := envelopes[key]; !ok && a.Month >= start {
+			envelopes[key] = &Envelope{Category: a.Category}
+		}
+	}
+
+	// Everything from before "month" rolls over into it.
+	for _, a := range assignments {
+		if a.Month < start || a.Month > month {
+			continue
+		}
+		e := envelopes[strings.ToLower(a.Category)]
+		field := &e.Carried
+		if a.Month == month {
+			field = &e.Assigned
+		}
+		var err error
+		if *field, err = field.Add(a.Amount); err != nil {
+			return Budget{}, err
+		}
+		if result.ToBeBudgeted, err = result.ToBeBudgeted.Sub(a.Amount); err != nil {
+			return Budget{}, err
+		}
+	}
+	for m, totals := range activity {
+		if m < start || m > month {
+			continue
+		}
+		for category, amount := range totals {
+			e, ok := envelopes[strings.ToLower(category)]
+			if !ok {
+				var err error
+				if result.ToBeBudgeted, err = result.ToBeBudgeted.Add(amount); err != nil {
+					return Budget{}, err
+				}
+				continue
+			}
+			field := &e.Carried
+			if m == month {
+				field = &e.Activity
+			}
+			var err error
+			if *field, err = field.Add(amount); err != nil {
+				return Budget{}, err
+			}
+		}
+	}
+
+	for _, e := range envelopes {
+		var err error
+		if e.Balance, err = transaction.Sum(e.Carried, e.Assigned, e.Activity); err != nil {
+			return Budget{}, err
+		}
+		result.Envelopes = append(result.Envelopes, *e)
+	}
+	sort.Slice(result.Envelopes, func(i, j int) bool {
+		return strings.ToLower(result.Envelopes[i].Category) < strings.ToLower(result.Envelopes[j].Category)
+	})
+	return result, nil
+}
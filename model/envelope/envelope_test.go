@@ -0,0 +1,68 @@
+package envelope_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/model/envelope"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+func month(year int, m time.Month) int64 {
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC).Unix()
+}
+
+func TestMonthOf(t *testing.T) {
+	date := time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC).Unix()
+	if actual := envelope.MonthOf(date); actual != month(2026, time.October) {
+		t.Errorf("expected the first of October but got %s", time.Unix(actual, 0).UTC())
+	}
+}
+
+func TestCompute(t *testing.T) {
+	start, sep, oct := month(2026, time.August), month(2026, time.September), month(2026, time.October)
+	assignments := []envelope.Assignment{
+		// Assignments from before envelope budgeting started don't count.
+		{Month: month(2026, time.July), Category: "Groceries", Amount: 99999},
+		{Month: start, Category: "Groceries", Amount: 40000},
+		{Month: start, Category: "Rent", Amount: 150000},
+		{Month: sep, Category: "groceries", Amount: 40000},
+		{Month: oct, Category: "Groceries", Amount: 30000},
+		// Assignments after the month don't count either.
+		{Month: month(2026, time.November), Category: "Groceries", Amount: 40000},
+	}
+	activity := map[int64]envelope.Activity{
+		start: {"": 300000, "Groceries": -35000, "Rent": -150000},
+		sep:   {"Salary": 300000, "GROCERIES": -50000, "Dining": -6000},
+		oct:   {"Groceries": -10000, "Rent": 5000},
+	}
+
+	budget, err := envelope.Compute(start, 100000, assignments, activity, oct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// $1000 opening, $6000 of income and $60 of dining outside of envelopes,
+	// less $2600 assigned.
+	if budget.Month != oct || budget.ToBeBudgeted != 434000 {
+		t.Errorf("expected $4,340.00 to be budgeted but got %+v", budget)
+	}
+	expected := []envelope.Envelope{
+		// $400 - $350 + $400 - $500 rolls over.
+		{Category: "Groceries", Carried: -5000, Assigned: 30000, Activity: -10000, Balance: 15000},
+		{Category: "Rent", Carried: 0, Assigned: 0, Activity: 5000, Balance: 5000},
+	}
+	if len(budget.Envelopes) != len(expected) {
+		t.Fatalf("expected %+v but got %+v", expected, budget.Envelopes)
+	}
+	for i, e := range budget.Envelopes {
+		if e != expected[i] {
+			t.Errorf("expected %+v but got %+v", expected[i], e)
+		}
+	}
+
+	// Nothing happened before envelope budgeting started.
+	budget, err = envelope.Compute(start, 0, nil, activity, month(2026, time.July))
+	if err != nil || budget.ToBeBudgeted != transaction.Cent(0) || len(budget.Envelopes) != 0 {
+		t.Errorf("expected an empty budget but got %+v: %v", budget, err)
+	}
+}
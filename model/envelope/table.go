@@ -0,0 +1,101 @@
+package envelope
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+// Table is the envelope assignments table in a database
+type Table struct{ DB *sql.DB }
+
+// Init creates the envelope assignments table if it doesn't exist.
+func (t *Table) Init() error {
+	_, err := t.DB.Exec(
+		fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s "+
+				"(%s INTEGER NOT NULL, %s TEXT NOT NULL COLLATE NOCASE, %s INTEGER NOT NULL, "+
+				"PRIMARY KEY(%s,%s))",
+			TableName,
+			MonthCol,
+			CategoryCol,
+			AmountCol,
+			MonthCol,
+			CategoryCol,
+		),
+	)
+	if err != nil {
+		return fmt.Errorf(
+			"envelope: cannot create table: %w", err,
+		)
+	}
+	return nil
+}
+
+// Assign adds "amount" to what's been assigned to the envelope of "category"
+// for the month that starts on "month". A negative amount takes money out of
+// the envelope.
+func (t *Table) Assign(month int64, category string, amount transaction.Cent) error {
+	category = strings.TrimSpace(category)
+	if category == "" {
+		return fmt.Errorf("envelope: money can only be assigned to a category")
+	}
+	if month != MonthOf(month) {
+		return fmt.Errorf("envelope: assignments must be made to the first day of a month")
+	}
+	_, err := t.DB.Exec(
+		fmt.Sprintf(
+			"INSERT INTO %s(%s, %s, %s) VALUES (?, ?, ?) "+
+				"ON CONFLICT(%s, %s) DO UPDATE SET %s=%s+excluded.%s",
+			TableName,
+			MonthCol,
+			CategoryCol,
+			AmountCol,
+			MonthCol,
+			CategoryCol,
+			AmountCol,
+			AmountCol,
+			AmountCol,
+		),
+		month,
+		category,
+		amount,
+	)
+	if err != nil {
+		return fmt.Errorf("envelope: could not assign %s to \"%s\": %w", amount, category, err)
+	}
+	return nil
+}
+
+// All returns every assignment, from the oldest month to the newest.
+func (t *Table) All() ([]Assignment, error) {
+	rows, err := t.DB.Query(
+		fmt.Sprintf(
+			"SELECT %s, %s, %s FROM %s ORDER BY %s ASC, rowid ASC",
+			MonthCol,
+			CategoryCol,
+			AmountCol,
+			TableName,
+			MonthCol,
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: could not query table: %w", err)
+	}
+	defer rows.Close()
+
+	var result []Assignment
+	for rows.Next() {
+		a := Assignment{}
+		if err := rows.Scan(&a.Month, &a.Category, &a.Amount); err != nil {
+			return nil, fmt.Errorf("envelope: could not scan assignment: %w", err)
+		}
+		result = append(result, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("envelope: failed to scan result set: %w", err)
+	}
+	return result, nil
+}
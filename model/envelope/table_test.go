@@ -0,0 +1,59 @@
+package envelope_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/model/envelope"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestTable(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection to ":memory:" gets its own database.
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+	table := &envelope.Table{DB: db}
+	if err := table.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	oct, nov := month(2026, time.October), month(2026, time.November)
+	if err := table.Assign(nov, "Rent", 150000); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Assign(oct, "Groceries", 40000); err != nil {
+		t.Fatal(err)
+	}
+	// Assigning to the same envelope again adds to it, whatever the case.
+	if err := table.Assign(oct, "groceries", -10000); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Assign(oct, " ", 1); err == nil {
+		t.Errorf("expected assigning to no category to fail")
+	}
+	if err := table.Assign(oct+86400, "Rent", 1); err == nil {
+		t.Errorf("expected assigning to the middle of a month to fail")
+	}
+
+	assignments, err := table.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []envelope.Assignment{
+		{Month: oct, Category: "Groceries", Amount: 30000},
+		{Month: nov, Category: "Rent", Amount: 150000},
+	}
+	if len(assignments) != len(expected) {
+		t.Fatalf("expected %+v but got %+v", expected, assignments)
+	}
+	for i, a := range assignments {
+		if a != expected[i] {
+			t.Errorf("expected %+v but got %+v", expected[i], a)
+		}
+	}
+}
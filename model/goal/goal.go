@@ -0,0 +1,170 @@
+// goal provides a model for savings goals, like an emergency fund or a
+// vacation, and the contributions made toward them. It also provides a simple
+// implementation of a sqlite table for storing them.
+package goal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/internal/month"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+const (
+	TableName              = "goals"
+	IDCol                  = "ID"
+	NameCol                = "Name"
+	TargetCol              = "Target"
+	DeadlineCol            = "Deadline"
+	CreatedCol             = "Created"
+	ContributionsTableName = "goal_contributions"
+	GoalCol                = "Goal"
+	TransactionIDCol       = "TransactionID"
+	AmountCol              = "Amount"
+	DateCol                = "Date"
+)
+
+var (
+	// ErrNotFound is returned when there's no goal with the given name.
+	ErrNotFound = errors.New("goal: no such goal")
+	// ErrDuplicate is returned when adding a goal with the same name as one
+	// that's already in the table.
+	ErrDuplicate = errors.New("goal: there's already a goal with that name")
+)
+
+// Goal is an amount that the user is saving toward.
+type Goal struct {
+	ID int
+	// Name is what the goal is called, e.g. "Emergency Fund". Names aren't
+	// case sensitive.
+	Name string
+	// Target is the amount to save, in the home currency.
+	Target transaction.Cent
+	// Deadline is the day that the target should be reached by, stored in the
+	// same form as transaction dates, or 0 if there isn't one.
+	Deadline int64
+	// Created is when the goal was added, in Unix seconds.
+	Created int64
+}
+
+// Validate returns an error if the goal can't be added.
+func (g Goal) Validate() error {
+	if strings.TrimSpace(g.Name) == "" {
+		return fmt.Errorf("goal: a goal must have a name")
+	}
+	if g.Target <= 0 {
+		return fmt.Errorf("goal: the target of \"%s\" must be more than 0", g.Name)
+	}
+	return nil
+}
+
+// Contribution is money put toward a goal, or taken out of it if its amount is
+// negative.
+type Contribution struct {
+	ID   int
+	Goal int
+	// TransactionID is the transaction that the contribution came from, like a
+	// transfer to a savings account, or 0 if it wasn't from one.
+	TransactionID int
+	// Amount is how much was contributed, in the home currency.
+	Amount transaction.Cent
+	// Date is the day the contribution was made, stored in the same form as
+	// transaction dates.
+	Date int64
+}
+
+// Progress is how far along a goal is.
+type Progress struct {
+	// Saved is the total of the goal's contributions.
+	Saved transaction.Cent
+	// Remaining is how much is left to save, or 0 if the goal has been
+	// reached.
+	Remaining transaction.Cent
+	// Percent is how much of the target has been saved, rounded down.
+	Percent int64
+	// Monthly is how much has to be contributed each month, including the
+	// current one, to reach the target by the deadline. It's all of Remaining
+	// if the deadline has passed, and 0 if there's no deadline.
+	Monthly transaction.Cent
+	// Overdue is whether the deadline has passed without reaching the target.
+	Overdue bool
+	// Projected is the month that the target will be reached in if the
+	// average monthly contribution so far keeps up. It's the zero time if
+	// the goal has been reached or nothing has been saved.
+	Projected time.Time
+}
+
+// Reached returns whether the goal's target has been saved.
+func (p Progress) Reached() bool {
+	return p.Remaining == 0
+}
+
+// monthsBetween returns the number of calendar months from the month of
+// "from" through the month of "to", counting both, or 0 if "to" is in an
+// earlier month.
+func monthsBetween(from, to time.Time) int {
+	n := (to.Year()-from.Year())*12 + int(to.Month()-from.Month()) + 1
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+// ceilDiv returns a / n rounded up, for positive amounts.
+func ceilDiv(a transaction.Cent, n int64) transaction.Cent {
+	return (a + transaction.Cent(n) - 1) / transaction.Cent(n)
+}
+
+// Progress returns how far along the goal is, given its contributions and the
+// current day, which is in the same form as transaction dates.
+func (g Goal) Progress(contributions []Contribution, today int64) (Progress, error) {
+	result := Progress{}
+	for _, c := range contributions {
+		var err error
+		if result.Saved, err = result.Saved.Add(c.Amount); err != nil {
+			return Progress{}, err
+		}
+	}
+	if result.Saved >= g.Target {
+		result.Percent = 100
+		return result, nil
+	}
+	var err error
+	if result.Remaining, err = g.Target.Sub(result.Saved); err != nil {
+		return Progress{}, err
+	}
+	if result.Saved > 0 {
+		result.Percent = int64(result.Saved) * 100 / int64(g.Target)
+	}
+
+	now := time.Unix(today, 0).UTC()
+	if g.Deadline != 0 {
+		deadline := time.Unix(g.Deadline, 0).UTC()
+		if deadline.Before(now) {
+			result.Overdue = true
+			result.Monthly = result.Remaining
+		} else {
+			result.Monthly = ceilDiv(result.Remaining, int64(monthsBetween(now, deadline)))
+		}
+	}
+
+	if len(contributions) > 0 && result.Saved > 0 {
+		first := contributions[0].Date
+		for _, c := range contributions {
+			if c.Date < first {
+				first = c.Date
+			}
+		}
+		elapsed := monthsBetween(time.Unix(first, 0).UTC(), now)
+		if elapsed < 1 {
+			elapsed = 1
+		}
+		rate := ceilDiv(result.Saved, int64(elapsed))
+		months := ceilDiv(result.Remaining, int64(rate))
+		result.Projected = month.Add(month.Start(now), int(months))
+	}
+	return result, nil
+}
@@ -0,0 +1,92 @@
+package goal_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/model/goal"
+)
+
+// day returns a date in the form that transaction dates are stored in.
+func day(year int, m time.Month, d int) int64 {
+	return time.Date(year, m, d, 0, 0, 0, 0, time.UTC).Unix()
+}
+
+func TestProgress(t *testing.T) {
+	today := day(2026, time.October, 19)
+	vacation := goal.Goal{Name: "Vacation", Target: 300000, Deadline: day(2026, time.December, 31)}
+	tests := []struct {
+		name          string
+		goal          goal.Goal
+		contributions []goal.Contribution
+		expected      goal.Progress
+	}{
+		{
+			name:     "nothing saved",
+			goal:     vacation,
+			expected: goal.Progress{Remaining: 300000, Monthly: 100000},
+		},
+		{
+			// $600 a month since August leaves $1200 to go, which takes 2
+			// more months at the same pace.
+			name: "on pace",
+			goal: vacation,
+			contributions: []goal.Contribution{
+				{Amount: 60000, Date: day(2026, time.August, 1)},
+				{Amount: 60000, Date: day(2026, time.September, 1)},
+				{Amount: 60000, Date: day(2026, time.October, 1)},
+			},
+			expected: goal.Progress{
+				Saved:     180000,
+				Remaining: 120000,
+				Percent:   60,
+				Monthly:   40000,
+				Projected: time.Date(2026, time.December, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:          "reached",
+			goal:          vacation,
+			contributions: []goal.Contribution{{Amount: 310000, Date: day(2026, time.January, 1)}},
+			expected:      goal.Progress{Saved: 310000, Percent: 100},
+		},
+		{
+			name: "overdue",
+			goal: goal.Goal{Name: "Car", Target: 100000, Deadline: day(2026, time.June, 1)},
+			contributions: []goal.Contribution{
+				{Amount: 50000, Date: day(2026, time.January, 1)},
+				{Amount: -25000, Date: day(2026, time.February, 1)},
+			},
+			expected: goal.Progress{
+				Saved:     25000,
+				Remaining: 75000,
+				Percent:   25,
+				Monthly:   75000,
+				Overdue:   true,
+				// $250 in 10 months is $25 a month, so $750 takes 30 more.
+				Projected: time.Date(2029, time.April, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:          "no deadline",
+			goal:          goal.Goal{Name: "Emergency Fund", Target: 1000000},
+			contributions: []goal.Contribution{{Amount: 100000, Date: day(2026, time.October, 2)}},
+			expected: goal.Progress{
+				Saved:     100000,
+				Remaining: 900000,
+				Percent:   10,
+				Projected: time.Date(2027, time.July, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+	for _, test := range tests {
+		actual, err := test.goal.Progress(test.contributions, today)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if actual != test.expected {
+			t.Errorf("%s: expected %+v but got %+v", test.name, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package goal
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Table is the goals table in a database, along with the table of their
+// contributions.
+type Table struct{ DB *sql.DB }
+
+// Init creates the goals and contributions tables if they don't exist.
+func (t *Table) Init() error {
+	_, err := t.DB.Exec(
+		fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s "+
+				"(%s INTEGER NOT NULL PRIMARY KEY, %s TEXT NOT NULL UNIQUE COLLATE NOCASE, "+
+				"%s INTEGER NOT NULL, %s INTEGER NOT NULL, %s INTEGER NOT NULL)",
+			TableName,
+			IDCol,
+			NameCol,
+			TargetCol,
+			DeadlineCol,
+			CreatedCol,
+		),
+	)
+	if err != nil {
+		return fmt.Errorf("goal: cannot create table: %w", err)
+	}
+	_, err = t.DB.Exec(
+		fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s "+
+				"(%s INTEGER NOT NULL PRIMARY KEY, %s INTEGER NOT NULL, %s INTEGER NOT NULL, "+
+				"%s INTEGER NOT NULL, %s INTEGER NOT NULL)",
+			ContributionsTableName,
+			IDCol,
+			GoalCol,
+			TransactionIDCol,
+			AmountCol,
+			DateCol,
+		),
+	)
+	if err != nil {
+		return fmt.Errorf("goal: cannot create contributions table: %w", err)
+	}
+	return nil
+}
+
+// Add adds a goal to the table and returns its ID. The ID provided by "g" is
+// ignored. It returns ErrDuplicate if there's already a goal with its name.
+func (t *Table) Add(g Goal) (int, error) {
+	g.Name = strings.TrimSpace(g.Name)
+	if err := g.Validate(); err != nil {
+		return 0, err
+	}
+	result, err := t.DB.Exec(
+		fmt.Sprintf(
+			"INSERT INTO %s(%s, %s, %s, %s) VALUES (?, ?, ?, ?)",
+			TableName,
+			NameCol,
+			TargetCol,
+			DeadlineCol,
+			CreatedCol,
+		),
+		g.Name,
+		g.Target,
+		g.Deadline,
+		g.Created,
+	)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return 0, fmt.Errorf("%w: \"%s\"", ErrDuplicate, g.Name)
+		}
+		return 0, fmt.Errorf("goal: could not add \"%s\": %w", g.Name, err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("goal: could not get the ID of \"%s\": %w", g.Name, err)
+	}
+	return int(id), nil
+}
+
+// goalColumns are the columns of the goals table, in the order that scanGoal
+// expects them.
+var goalColumns = strings.Join([]string{IDCol, NameCol, TargetCol, DeadlineCol, CreatedCol}, ", ")
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanGoal(s scanner) (Goal, error) {
+	g := Goal{}
+	err := s.Scan(&g.ID, &g.Name, &g.Target, &g.Deadline, &g.Created)
+	return g, err
+}
+
+// All returns every goal in the table, sorted by deadline, with the goals that
+// don't have one last.
+func (t *Table) All() ([]Goal, error) {
+	rows, err := t.DB.Query(
+		fmt.Sprintf(
+			"SELECT %s FROM %s ORDER BY %s=0 ASC, %s ASC, %s ASC",
+			goalColumns,
+			TableName,
+			DeadlineCol,
+			DeadlineCol,
+			NameCol,
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("goal: could not query table: %w", err)
+	}
+	defer rows.Close()
+
+	var result []Goal
+	for rows.Next() {
+		g, err := scanGoal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("goal: could not scan goal: %w", err)
+		}
+		result = append(result, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("goal: failed to scan result set: %w", err)
+	}
+	return result, nil
+}
+
+// Find returns the goal with the given name, ignoring case, or ErrNotFound if
+// there isn't one.
+func (t *Table) Find(name string) (Goal, error) {
+	row := t.DB.QueryRow(
+		fmt.Sprintf("SELECT %s FROM %s WHERE %s=?", goalColumns, TableName, NameCol),
+		strings.TrimSpace(name),
+	)
+	g, err := scanGoal(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Goal{}, fmt.Errorf("%w named \"%s\"", ErrNotFound, name)
+	}
+	if err != nil {
+		return Goal{}, fmt.Errorf("goal: could not query table: %w", err)
+	}
+	return g, nil
+}
+
+// Remove removes the goal with the given ID and its contributions.
+func (t *Table) Remove(goalID int) error {
+	dbTx, err := t.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("goal: could not begin a database transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+	result, err := dbTx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s=?", TableName, IDCol), goalID)
+	if err != nil {
+		return fmt.Errorf("goal: could not remove goal #%d: %w", goalID, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("goal: could not remove goal #%d: %w", goalID, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w #%d", ErrNotFound, goalID)
+	}
+	_, err = dbTx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s=?", ContributionsTableName, GoalCol), goalID)
+	if err != nil {
+		return fmt.Errorf("goal: could not remove the contributions of goal #%d: %w", goalID, err)
+	}
+	if err := dbTx.Commit(); err != nil {
+		return fmt.Errorf("goal: could not commit: %w", err)
+	}
+	return nil
+}
+
+// Contribute adds a contribution to a goal and returns its ID. The ID provided
+// by "c" is ignored.
+func (t *Table) Contribute(c Contribution) (int, error) {
+	if c.Amount == 0 {
+		return 0, fmt.Errorf("goal: a contribution can't be 0")
+	}
+	result, err := t.DB.Exec(
+		fmt.Sprintf(
+			"INSERT INTO %s(%s, %s, %s, %s) SELECT ?, ?, ?, ? WHERE EXISTS (SELECT 1 FROM %s WHERE %s=?)",
+			ContributionsTableName,
+			GoalCol,
+			TransactionIDCol,
+			AmountCol,
+			DateCol,
+			TableName,
+			IDCol,
+		),
+		c.Goal,
+		c.TransactionID,
+		c.Amount,
+		c.Date,
+		c.Goal,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("goal: could not add a contribution to goal #%d: %w", c.Goal, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("goal: could not add a contribution to goal #%d: %w", c.Goal, err)
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("%w #%d", ErrNotFound, c.Goal)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("goal: could not get the ID of a contribution: %w", err)
+	}
+	return int(id), nil
+}
+
+// Contributions returns the contributions to the goal with the given ID, from
+// oldest to newest.
+func (t *Table) Contributions(goalID int) ([]Contribution, error) {
+	rows, err := t.DB.Query(
+		fmt.Sprintf(
+			"SELECT %s, %s, %s, %s, %s FROM %s WHERE %s=? ORDER BY %s ASC, %s ASC",
+			IDCol,
+			GoalCol,
+			TransactionIDCol,
+			AmountCol,
+			DateCol,
+			ContributionsTableName,
+			GoalCol,
+			DateCol,
+			IDCol,
+		),
+		goalID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("goal: could not query contributions: %w", err)
+	}
+	defer rows.Close()
+
+	var result []Contribution
+	for rows.Next() {
+		c := Contribution{}
+		if err := rows.Scan(&c.ID, &c.Goal, &c.TransactionID, &c.Amount, &c.Date); err != nil {
+			return nil, fmt.Errorf("goal: could not scan contribution: %w", err)
+		}
+		result = append(result, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("goal: failed to scan contributions: %w", err)
+	}
+	return result, nil
+}
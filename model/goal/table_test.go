@@ -0,0 +1,75 @@
+package goal_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/model/goal"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestTable(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection to ":memory:" gets its own database.
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+	table := &goal.Table{DB: db}
+	if err := table.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	emergency, err := table.Add(goal.Goal{Name: "Emergency Fund", Target: 1000000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vacation, err := table.Add(goal.Goal{Name: "Vacation", Target: 300000, Deadline: day(2026, time.December, 31)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := table.Add(goal.Goal{Name: "vacation", Target: 1}); !errors.Is(err, goal.ErrDuplicate) {
+		t.Errorf("expected ErrDuplicate but got %v", err)
+	}
+	if _, err := table.Add(goal.Goal{Name: "Car"}); err == nil {
+		t.Errorf("expected a goal without a target to be invalid")
+	}
+
+	goals, err := table.All()
+	if err != nil || len(goals) != 2 || goals[0].ID != vacation || goals[1].ID != emergency {
+		t.Errorf("expected the goal with a deadline first but got %+v: %v", goals, err)
+	}
+	found, err := table.Find("VACATION")
+	if err != nil || found.ID != vacation || found.Target != 300000 {
+		t.Errorf("expected to find the vacation but got %+v: %v", found, err)
+	}
+
+	for _, c := range []goal.Contribution{
+		{Goal: vacation, Amount: 60000, Date: day(2026, time.September, 1)},
+		{Goal: vacation, TransactionID: 12, Amount: 60000, Date: day(2026, time.August, 1)},
+	} {
+		if _, err := table.Contribute(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := table.Contribute(goal.Contribution{Goal: 42, Amount: 1}); !errors.Is(err, goal.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing goal but got %v", err)
+	}
+	contributions, err := table.Contributions(vacation)
+	if err != nil || len(contributions) != 2 || contributions[0].TransactionID != 12 {
+		t.Errorf("expected the contributions from oldest to newest but got %+v: %v", contributions, err)
+	}
+
+	if err := table.Remove(vacation); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := table.Find("Vacation"); !errors.Is(err, goal.ErrNotFound) {
+		t.Errorf("expected ErrNotFound but got %v", err)
+	}
+	if contributions, err := table.Contributions(vacation); err != nil || len(contributions) != 0 {
+		t.Errorf("expected the contributions to be removed but got %+v: %v", contributions, err)
+	}
+}
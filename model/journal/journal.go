@@ -0,0 +1,92 @@
+// journal provides a record of the operations that change transactions, with
+// an image of each transaction before and after every change so that
+// operations can be undone and redone. It also provides a simple
+// implementation of a sqlite table for storing them.
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+const (
+	OperationsTable = "operations"
+	ChangesTable    = "changes"
+	IDCol           = "ID"
+	CommandCol      = "Command"
+	UserCol         = "User"
+	TimeCol         = "Time"
+	UndoneCol       = "Undone"
+	OperationCol    = "Operation"
+	TransactionCol  = "TransactionID"
+	BeforeCol       = "Before"
+	AfterCol        = "After"
+)
+
+var (
+	// ErrNotFound is returned when there's no operation with the given ID.
+	ErrNotFound = errors.New("journal: no such operation")
+	// ErrNothingToUndo is returned by Undo when every operation has been
+	// undone.
+	ErrNothingToUndo = errors.New("journal: there's nothing to undo")
+	// ErrNothingToRedo is returned by Redo when no operation has been undone.
+	ErrNothingToRedo = errors.New("journal: there's nothing to redo")
+	// ErrUndone is returned when undoing an operation that has already been
+	// undone.
+	ErrUndone = errors.New("journal: operation has already been undone")
+	// ErrConflict is returned when undoing or redoing an operation would
+	// overwrite a change that was made to a transaction after it.
+	ErrConflict = errors.New("journal: conflicting change")
+)
+
+// Operation is a command that changed transactions, like adding one or
+// ingesting a CSV file.
+type Operation struct {
+	ID int
+	// Command is the name of the command that made the changes, e.g. "add".
+	Command string
+	// User is the name of the user who ran the command.
+	User string
+	// Time is when the operation started, in Unix seconds.
+	Time int64
+	// Undone is whether the operation has been undone.
+	Undone bool
+	// Changes is how many changes the operation made.
+	Changes int
+}
+
+// Change is a change made to a single transaction. Before is nil if the
+// transaction was inserted, and After is nil if it was removed.
+type Change struct {
+	TransactionID int
+	Before        *transaction.Transaction
+	After         *transaction.Transaction
+}
+
+// encode returns the form of a transaction image that's stored in the changes
+// table. A missing transaction is stored as an empty string.
+func encode(image *transaction.Transaction) (string, error) {
+	if image == nil {
+		return "", nil
+	}
+	b, err := json.Marshal(image)
+	if err != nil {
+		return "", fmt.Errorf("journal: could not encode transaction #%d: %w", image.ID, err)
+	}
+	return string(b), nil
+}
+
+// decode is the inverse of encode.
+func decode(image string) (*transaction.Transaction, error) {
+	if image == "" {
+		return nil, nil
+	}
+	tx := &transaction.Transaction{}
+	if err := json.Unmarshal([]byte(image), tx); err != nil {
+		return nil, fmt.Errorf("journal: could not decode transaction: %w", err)
+	}
+	return tx, nil
+}
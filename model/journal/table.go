@@ -0,0 +1,411 @@
+package journal
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+// Table is the journal's operations and changes tables in a database. The
+// transactions table must be in the same database, since undoing and redoing
+// operations changes it.
+type Table struct{ DB *sql.DB }
+
+// Init creates the journal's tables if they don't exist.
+func (t *Table) Init() error {
+	// Undone is 0 for operations that haven't been undone. Otherwise, it's
+	// the order that they were undone in, so that Redo can redo the most
+	// recently undone one first.
+	_, err := t.DB.Exec(
+		fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s "+
+				"(%s INTEGER NOT NULL PRIMARY KEY, "+
+				"%s TEXT NOT NULL, %s TEXT NOT NULL, %s INTEGER NOT NULL, %s INTEGER NOT NULL DEFAULT 0)",
+			OperationsTable,
+			IDCol,
+			CommandCol,
+			UserCol,
+			TimeCol,
+			UndoneCol,
+		),
+	)
+	if err != nil {
+		return fmt.Errorf("journal: cannot create table: %w", err)
+	}
+	_, err = t.DB.Exec(
+		fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s "+
+				"(%s INTEGER NOT NULL PRIMARY KEY, "+
+				"%s INTEGER NOT NULL REFERENCES %s(%s), %s INTEGER NOT NULL, %s TEXT NOT NULL, %s TEXT NOT NULL)",
+			ChangesTable,
+			IDCol,
+			OperationCol,
+			OperationsTable,
+			IDCol,
+			TransactionCol,
+			BeforeCol,
+			AfterCol,
+		),
+	)
+	if err != nil {
+		return fmt.Errorf("journal: cannot create table: %w", err)
+	}
+	return nil
+}
+
+// Begin starts a new operation and returns its ID.
+func Begin(e transaction.Execer, command, user string, at time.Time) (int, error) {
+	result, err := e.Exec(
+		fmt.Sprintf(
+			"INSERT INTO %s(%s, %s, %s) VALUES (?, ?, ?)",
+			OperationsTable,
+			CommandCol,
+			UserCol,
+			TimeCol,
+		),
+		command,
+		user,
+		at.Unix(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("journal: could not begin operation \"%s\": %w", command, err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("journal: could not get the ID of operation \"%s\": %w", command, err)
+	}
+	return int(id), nil
+}
+
+// Record adds a change to an operation. It's meant to be called in the same
+// database transaction as the change, e.g. by a transaction.Observer, so that
+// every change that's made can be undone.
+func Record(e transaction.Execer, operationID int, c Change) error {
+	before, err := encode(c.Before)
+	if err != nil {
+		return err
+	}
+	after, err := encode(c.After)
+	if err != nil {
+		return err
+	}
+	_, err = e.Exec(
+		fmt.Sprintf(
+			"INSERT INTO %s(%s, %s, %s, %s) VALUES (?, ?, ?, ?)",
+			ChangesTable,
+			OperationCol,
+			TransactionCol,
+			BeforeCol,
+			AfterCol,
+		),
+		operationID,
+		c.TransactionID,
+		before,
+		after,
+	)
+	if err != nil {
+		return fmt.Errorf(
+			"journal: could not record a change to transaction #%d in operation #%d: %w",
+			c.TransactionID, operationID, err,
+		)
+	}
+	return nil
+}
+
+// Operations returns up to "limit" of the operations that changed something,
+// most recent first. A negative limit returns all of them.
+func (t *Table) Operations(limit int) ([]Operation, error) {
+	rows, err := t.DB.Query(
+		fmt.Sprintf(
+			"SELECT o.%s, o.%s, o.%s, o.%s, o.%s, COUNT(c.%s) FROM %s o JOIN %s c ON c.%s=o.%s "+
+				"GROUP BY o.%s ORDER BY o.%s DESC LIMIT ?",
+			IDCol,
+			CommandCol,
+			UserCol,
+			TimeCol,
+			UndoneCol,
+			IDCol,
+			OperationsTable,
+			ChangesTable,
+			OperationCol,
+			IDCol,
+			IDCol,
+			IDCol,
+		),
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("journal: could not query operations: %w", err)
+	}
+	defer rows.Close()
+	var result []Operation
+	for rows.Next() {
+		op, err := scanOperation(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, op)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("journal: could not query operations: %w", err)
+	}
+	return result, nil
+}
+
+// scanner is satisfied by both *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanOperation scans an operation from the columns that Operations selects.
+func scanOperation(s scanner) (Operation, error) {
+	op := Operation{}
+	var undone int
+	err := s.Scan(&op.ID, &op.Command, &op.User, &op.Time, &undone, &op.Changes)
+	if err != nil {
+		return Operation{}, err
+	}
+	op.Undone = undone != 0
+	return op, nil
+}
+
+// Undo reverts the changes made by the operation with the given ID, or by the
+// most recent operation that hasn't been undone if the ID is 0. Either every
+// change is reverted or none of them are.
+//
+// Undo returns ErrConflict if a transaction that the operation changed has
+// been changed again since, so that later changes are never lost.
+func (t *Table) Undo(operationID int) (Operation, error) {
+	var result Operation
+	err := t.inTx(func(tx *sql.Tx) error {
+		var err error
+		if operationID == 0 {
+			operationID, err = latest(tx, "0=", ErrNothingToUndo)
+			if err != nil {
+				return err
+			}
+		}
+		result, err = get(tx, operationID)
+		if err != nil {
+			return err
+		}
+		if result.Undone {
+			return fmt.Errorf("%w (#%d)", ErrUndone, operationID)
+		}
+		changes, err := changes(tx, operationID)
+		if err != nil {
+			return err
+		}
+		// Changes are reverted last to first, in case a transaction was
+		// changed more than once.
+		for i := len(changes) - 1; i >= 0; i-- {
+			c := changes[i]
+			if err := apply(tx, operationID, c.TransactionID, c.after, c.before); err != nil {
+				return err
+			}
+		}
+		_, err = tx.Exec(
+			fmt.Sprintf(
+				"UPDATE %s SET %s=(SELECT MAX(%s)+1 FROM %s) WHERE %s=?",
+				OperationsTable,
+				UndoneCol,
+				UndoneCol,
+				OperationsTable,
+				IDCol,
+			),
+			operationID,
+		)
+		if err != nil {
+			return fmt.Errorf("journal: could not mark operation #%d undone: %w", operationID, err)
+		}
+		result.Undone = true
+		return nil
+	})
+	return result, err
+}
+
+// Redo makes the changes of the most recently undone operation again. Either
+// every change is made or none of them are.
+//
+// Like Undo, Redo returns ErrConflict if a transaction that the operation
+// changed has been changed again since it was undone.
+func (t *Table) Redo() (Operation, error) {
+	var result Operation
+	err := t.inTx(func(tx *sql.Tx) error {
+		operationID, err := latest(tx, "0<>", ErrNothingToRedo)
+		if err != nil {
+			return err
+		}
+		result, err = get(tx, operationID)
+		if err != nil {
+			return err
+		}
+		changes, err := changes(tx, operationID)
+		if err != nil {
+			return err
+		}
+		for _, c := range changes {
+			if err := apply(tx, operationID, c.TransactionID, c.before, c.after); err != nil {
+				return err
+			}
+		}
+		_, err = tx.Exec(
+			fmt.Sprintf("UPDATE %s SET %s=0 WHERE %s=?", OperationsTable, UndoneCol, IDCol),
+			operationID,
+		)
+		if err != nil {
+			return fmt.Errorf("journal: could not mark operation #%d redone: %w", operationID, err)
+		}
+		result.Undone = false
+		return nil
+	})
+	return result, err
+}
+
+// inTx runs "f" in a database transaction, which is committed if "f" succeeds
+// and rolled back if it doesn't.
+func (t *Table) inTx(f func(tx *sql.Tx) error) error {
+	tx, err := t.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("journal: could not begin a database transaction: %w", err)
+	}
+	if err := f(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("journal: could not commit: %w", err)
+	}
+	return nil
+}
+
+// latest returns the ID of the operation that should be undone or redone
+// next. "undone" is the comparison with 0 that selects the operations that
+// can be, and "none" is returned if there aren't any.
+func latest(tx *sql.Tx, undone string, none error) (int, error) {
+	var id int
+	err := tx.QueryRow(
+		fmt.Sprintf(
+			"SELECT o.%s FROM %s o WHERE %s%s AND EXISTS (SELECT 1 FROM %s c WHERE c.%s=o.%s) "+
+				"ORDER BY o.%s DESC, o.%s DESC LIMIT 1",
+			IDCol,
+			OperationsTable,
+			undone,
+			UndoneCol,
+			ChangesTable,
+			OperationCol,
+			IDCol,
+			UndoneCol,
+			IDCol,
+		),
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, none
+	}
+	if err != nil {
+		return 0, fmt.Errorf("journal: could not query operations: %w", err)
+	}
+	return id, nil
+}
+
+// get returns the operation with the given ID.
+func get(tx *sql.Tx, operationID int) (Operation, error) {
+	row := tx.QueryRow(
+		fmt.Sprintf(
+			"SELECT o.%s, o.%s, o.%s, o.%s, o.%s, (SELECT COUNT(*) FROM %s c WHERE c.%s=o.%s) "+
+				"FROM %s o WHERE o.%s=?",
+			IDCol,
+			CommandCol,
+			UserCol,
+			TimeCol,
+			UndoneCol,
+			ChangesTable,
+			OperationCol,
+			IDCol,
+			OperationsTable,
+			IDCol,
+		),
+		operationID,
+	)
+	op, err := scanOperation(row)
+	if err == sql.ErrNoRows {
+		return Operation{}, fmt.Errorf("%w #%d", ErrNotFound, operationID)
+	}
+	if err != nil {
+		return Operation{}, fmt.Errorf("journal: could not get operation #%d: %w", operationID, err)
+	}
+	return op, nil
+}
+
+// storedChange is a change with its images in the form they're stored in.
+type storedChange struct {
+	TransactionID int
+	before, after string
+}
+
+// changes returns the changes that an operation made, in the order that it
+// made them.
+func changes(tx *sql.Tx, operationID int) ([]storedChange, error) {
+	rows, err := tx.Query(
+		fmt.Sprintf(
+			"SELECT %s, %s, %s FROM %s WHERE %s=? ORDER BY %s ASC",
+			TransactionCol,
+			BeforeCol,
+			AfterCol,
+			ChangesTable,
+			OperationCol,
+			IDCol,
+		),
+		operationID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("journal: could not query changes: %w", err)
+	}
+	defer rows.Close()
+	var result []storedChange
+	for rows.Next() {
+		c := storedChange{}
+		if err := rows.Scan(&c.TransactionID, &c.before, &c.after); err != nil {
+			return nil, fmt.Errorf("journal: could not scan change: %w", err)
+		}
+		result = append(result, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("journal: could not query changes: %w", err)
+	}
+	return result, nil
+}
+
+// apply changes a transaction from the image "from" to the image "to". It
+// returns ErrConflict if the transaction doesn't match "from" anymore, or if
+// "to" is the same as another transaction that isn't in the trash.
+func apply(tx *sql.Tx, operationID, transactionID int, from, to string) error {
+	current, err := transaction.Snapshot(tx, transactionID)
+	if err != nil {
+		return err
+	}
+	encoded, err := encode(current)
+	if err != nil {
+		return err
+	}
+	if encoded != from {
+		return fmt.Errorf(
+			"%w: transaction #%d has been changed since operation #%d", ErrConflict, transactionID, operationID,
+		)
+	}
+	image, err := decode(to)
+	if err != nil {
+		return err
+	}
+	err = transaction.Restore(tx, transactionID, image)
+	if errors.Is(err, transaction.ErrDuplicate) {
+		return fmt.Errorf(
+			"%w: transaction #%d would be the same as one added since operation #%d",
+			ErrConflict, transactionID, operationID,
+		)
+	}
+	return err
+}
@@ -0,0 +1,269 @@
+package journal_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/model/journal"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func getMemTables(t *testing.T) (*journal.Table, *transaction.Table) {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection to ":memory:" gets its own database.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	transactions := &transaction.Table{DB: db}
+	if err := transactions.Init(); err != nil {
+		t.Fatal(err)
+	}
+	j := &journal.Table{DB: db}
+	if err := j.Init(); err != nil {
+		t.Fatal(err)
+	}
+	return j, transactions
+}
+
+// insert inserts a transaction and records it in a new operation.
+func insert(t *testing.T, j *journal.Table, transactions *transaction.Table, tx transaction.Transaction) int {
+	t.Helper()
+	op, err := journal.Begin(j.DB, "add", "sarah", time.Unix(1600000000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := transactions.Insert(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := transactions.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.Record(j.DB, op, journal.Change{TransactionID: id, After: &after}); err != nil {
+		t.Fatal(err)
+	}
+	return op
+}
+
+func TestUndoRedo(t *testing.T) {
+	j, transactions := getMemTables(t)
+
+	if _, err := j.Undo(0); !errors.Is(err, journal.ErrNothingToUndo) {
+		t.Fatalf("expected ErrNothingToUndo from an empty journal but got %v", err)
+	}
+	added := insert(t, j, transactions, transaction.Transaction{Entity: "Kroger", Amount: -2000, Date: 86400})
+
+	// Edit and then remove the transaction in one operation.
+	op, err := journal.Begin(j.DB, "categorize", "sarah", time.Unix(1600000100, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := transactions.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := before
+	edited.Category = "Groceries"
+	edited.Tags = []string{"food"}
+	if err := transactions.Update(edited); err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.Record(j.DB, op, journal.Change{TransactionID: 1, Before: &before, After: &edited}); err != nil {
+		t.Fatal(err)
+	}
+	if err := transactions.Remove(1); err != nil {
+		t.Fatal(err)
+	}
+	trashed, err := transactions.Snapshot(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.Record(j.DB, op, journal.Change{TransactionID: 1, Before: &edited, After: trashed}); err != nil {
+		t.Fatal(err)
+	}
+
+	ops, err := j.Operations(-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 2 || ops[0].ID != op || ops[0].Changes != 2 || ops[0].User != "sarah" ||
+		ops[0].Time != 1600000100 || ops[1].ID != added {
+		t.Fatalf("unexpected operations %+v", ops)
+	}
+
+	undone, err := j.Undo(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if undone.ID != op || !undone.Undone {
+		t.Errorf("expected operation #%d to be undone but got %+v", op, undone)
+	}
+	tx, err := transactions.Get(1)
+	if err != nil {
+		t.Fatalf("expected the removed transaction to be back: %v", err)
+	}
+	if tx.Category != "" || tx.Tags != nil || tx.Entity != "Kroger" {
+		t.Errorf("expected the transaction from before the edit but got %+v", tx)
+	}
+
+	if _, err := j.Undo(op); !errors.Is(err, journal.ErrUndone) {
+		t.Errorf("expected ErrUndone when undoing an operation twice but got %v", err)
+	}
+	if _, err := j.Undo(0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := transactions.Get(1); !errors.Is(err, transaction.ErrNotFound) {
+		t.Errorf("expected undoing the add to remove the transaction but got %v", err)
+	}
+
+	// Redo goes in the opposite order of Undo.
+	redone, err := j.Redo()
+	if err != nil || redone.ID != added {
+		t.Fatalf("expected to redo operation #%d but got %+v: %v", added, redone, err)
+	}
+	redone, err = j.Redo()
+	if err != nil || redone.ID != op {
+		t.Fatalf("expected to redo operation #%d but got %+v: %v", op, redone, err)
+	}
+	if _, err := transactions.Get(1); !errors.Is(err, transaction.ErrNotFound) {
+		t.Errorf("expected redoing the operation to remove the transaction again but got %v", err)
+	}
+	if _, err := j.Redo(); !errors.Is(err, journal.ErrNothingToRedo) {
+		t.Errorf("expected ErrNothingToRedo but got %v", err)
+	}
+	if _, err := j.Undo(12); !errors.Is(err, journal.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing operation but got %v", err)
+	}
+}
+
+func TestUndoConflict(t *testing.T) {
+	j, transactions := getMemTables(t)
+	first := insert(t, j, transactions, transaction.Transaction{Entity: "Kroger", Amount: -2000, Date: 86400})
+	insert(t, j, transactions, transaction.Transaction{Entity: "Lyft", Amount: -1368, Date: 86400})
+
+	// A change that wasn't recorded in the journal.
+	tx, err := transactions.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Note = "Changed"
+	if err := transactions.Update(tx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := j.Undo(first); !errors.Is(err, journal.ErrConflict) {
+		t.Fatalf("expected ErrConflict but got %v", err)
+	}
+	if tx, err := transactions.Get(1); err != nil || tx.Note != "Changed" {
+		t.Errorf("expected the conflicting transaction to be left alone but got %+v: %v", tx, err)
+	}
+	ops, err := j.Operations(-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ops[1].Undone {
+		t.Errorf("expected the operation not to be marked undone after a conflict")
+	}
+
+	// A failed undo changes nothing, even if some of its changes could have
+	// been reverted.
+	op, err := journal.Begin(j.DB, "recur", "sarah", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []int{2, 1} {
+		before, err := transactions.Get(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		after := before
+		after.Category = "Recurring"
+		if err := transactions.Update(after); err != nil {
+			t.Fatal(err)
+		}
+		if err := journal.Record(j.DB, op, journal.Change{TransactionID: id, Before: &before, After: &after}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tx, err = transactions.Get(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Category = "Rides"
+	if err := transactions.Update(tx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := j.Undo(op); !errors.Is(err, journal.ErrConflict) {
+		t.Fatalf("expected ErrConflict but got %v", err)
+	}
+	if tx, err := transactions.Get(1); err != nil || tx.Category != "Recurring" {
+		t.Errorf("expected the undo to be rolled back but got %+v: %v", tx, err)
+	}
+
+	// Undo ignores locks, since the changes it reverts were allowed.
+	if err := transactions.SetStatus(1, transaction.Reconciled); err != nil {
+		t.Fatal(err)
+	}
+	before, err := transactions.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before.Status = transaction.Cleared
+	after, err := transactions.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	op, err = journal.Begin(j.DB, "reconcile", "sarah", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.Record(j.DB, op, journal.Change{TransactionID: 1, Before: &before, After: &after}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := j.Undo(0); err != nil {
+		t.Fatal(err)
+	}
+	if tx, err := transactions.Get(1); err != nil || tx.Status != transaction.Cleared {
+		t.Errorf("expected the transaction to be cleared again but got %+v: %v", tx, err)
+	}
+}
+
+func TestUndoDuplicate(t *testing.T) {
+	j, transactions := getMemTables(t)
+	kroger := transaction.Transaction{Entity: "Kroger", Amount: -1212, Date: 86400}
+	insert(t, j, transactions, kroger)
+
+	op, err := journal.Begin(j.DB, "remove", "sarah", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := transactions.Snapshot(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := transactions.Remove(1); err != nil {
+		t.Fatal(err)
+	}
+	after, err := transactions.Snapshot(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.Record(j.DB, op, journal.Change{TransactionID: 1, Before: before, After: after}); err != nil {
+		t.Fatal(err)
+	}
+	// The same transaction is added again, so taking the removed one out of
+	// the trash would make a duplicate.
+	insert(t, j, transactions, kroger)
+	if _, err := j.Undo(op); !errors.Is(err, journal.ErrConflict) {
+		t.Fatalf("expected ErrConflict but got %v", err)
+	}
+	if tx, err := transactions.Snapshot(1); err != nil || tx.Deleted == 0 {
+		t.Errorf("expected #1 to stay in the trash but got %+v: %v", tx, err)
+	}
+}
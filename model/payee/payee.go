@@ -0,0 +1,85 @@
+// payee provides a model for mapping the entity names that banks use, like
+// "KROGER #1234 COLUMBUS OH", to canonical payee names like "Kroger". It also
+// provides a simple implementation of a sqlite table for storing them.
+package payee
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	TableName  = "payees"
+	IDCol      = "ID"
+	PatternCol = "Pattern"
+	RegexCol   = "Regex"
+	NameCol    = "Name"
+)
+
+// Alias maps raw entity names to a canonical payee name.
+type Alias struct {
+	ID int
+	// Pattern is compared to raw entity names. It's not case sensitive.
+	Pattern string
+	// Regex determines whether Pattern is a regular expression that entities
+	// must match or a name that they must be exactly equal to.
+	Regex bool
+	// Name is the canonical name of the payee.
+	Name string
+}
+
+// Validate returns an error if the alias can't be used.
+func (a Alias) Validate() error {
+	if strings.TrimSpace(a.Pattern) == "" {
+		return fmt.Errorf("payee: an alias must have a pattern")
+	}
+	if strings.TrimSpace(a.Name) == "" {
+		return fmt.Errorf("payee: an alias must have a name")
+	}
+	if a.Regex {
+		if _, err := regexp.Compile("(?i)" + a.Pattern); err != nil {
+			return fmt.Errorf("payee: invalid pattern %q: %w", a.Pattern, err)
+		}
+	}
+	return nil
+}
+
+// Normalizer finds the canonical names of entities.
+type Normalizer struct {
+	exact    map[string]string
+	patterns []*regexp.Regexp
+	names    []string
+}
+
+// NewNormalizer returns a Normalizer that uses the given aliases. Exact aliases
+// are checked first, and then patterns are checked in order.
+func NewNormalizer(aliases []Alias) (*Normalizer, error) {
+	result := &Normalizer{exact: make(map[string]string)}
+	for _, a := range aliases {
+		if err := a.Validate(); err != nil {
+			return nil, fmt.Errorf("payee: alias #%d is invalid: %w", a.ID, err)
+		}
+		if !a.Regex {
+			result.exact[strings.ToLower(strings.TrimSpace(a.Pattern))] = a.Name
+			continue
+		}
+		result.patterns = append(result.patterns, regexp.MustCompile("(?i)"+a.Pattern))
+		result.names = append(result.names, a.Name)
+	}
+	return result, nil
+}
+
+// Normalize returns the canonical name of "entity". If no alias matches it,
+// "entity" is returned as is.
+func (n *Normalizer) Normalize(entity string) string {
+	if name, ok := n.exact[strings.ToLower(strings.TrimSpace(entity))]; ok {
+		return name
+	}
+	for i, p := range n.patterns {
+		if p.MatchString(entity) {
+			return n.names[i]
+		}
+	}
+	return entity
+}
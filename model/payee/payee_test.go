@@ -0,0 +1,134 @@
+package payee_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/Anthony-Fiddes/budgeter/model/payee"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestNormalizer(t *testing.T) {
+	aliases := []payee.Alias{
+		{Pattern: "^kroger fuel", Regex: true, Name: "Kroger Fuel"},
+		{Pattern: "^kroger", Regex: true, Name: "Kroger"},
+		{Pattern: "KROGER FUEL 567", Name: "Kroger"},
+		{Pattern: "amzn mktp us", Name: "Amazon"},
+	}
+	n, err := payee.NewNormalizer(aliases)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		entity   string
+		expected string
+	}{
+		{entity: "KROGER #1234 COLUMBUS OH", expected: "Kroger"},
+		// exact aliases are checked before patterns
+		{entity: "KROGER FUEL 567", expected: "Kroger"},
+		{entity: "KROGER FUEL 999", expected: "Kroger Fuel"},
+		{entity: " AMZN Mktp US ", expected: "Amazon"},
+		{entity: "AMZN Mktp US*2K3", expected: "AMZN Mktp US*2K3"},
+		{entity: "Lyft", expected: "Lyft"},
+	}
+	for _, test := range tests {
+		if result := n.Normalize(test.entity); result != test.expected {
+			t.Errorf("%q was normalized to %q but expected %q", test.entity, result, test.expected)
+		}
+	}
+
+	if _, err := payee.NewNormalizer([]payee.Alias{{Pattern: "(", Regex: true, Name: "Oops"}}); err == nil {
+		t.Error("expected an invalid pattern to be rejected")
+	}
+}
+
+func TestTable(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// Every connection to ":memory:" gets its own database.
+	db.SetMaxOpenConns(1)
+	table := &payee.Table{DB: db}
+	if err := table.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	testData := []payee.Alias{
+		{Pattern: "^kroger", Regex: true, Name: "Kroger"},
+		{Pattern: "KROGER FUEL 567", Name: "Kroger"},
+		{Pattern: "amzn mktp us", Name: "Amazon"},
+	}
+	for _, a := range testData {
+		if err := table.Insert(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := table.Insert(payee.Alias{Pattern: "", Name: "Nobody"}); err == nil {
+		t.Fatal("expected an alias without a pattern to be rejected")
+	}
+	// Inserting the same pattern again replaces the old alias.
+	if err := table.Insert(payee.Alias{Pattern: "amzn mktp us", Name: "Amazon.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Rename("kroger", "Kroger Co"); err != nil {
+		t.Fatal(err)
+	}
+
+	aliases, err := table.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aliases) != 3 {
+		t.Fatalf("expected 3 aliases but received %+v", aliases)
+	}
+	if aliases[0].Name != "Amazon.com" || aliases[1].Name != "Kroger Co" || aliases[2].Name != "Kroger Co" {
+		t.Fatalf("aliases were not replaced and renamed: %+v", aliases)
+	}
+	if !aliases[1].Regex || aliases[2].Regex {
+		t.Fatalf("aliases did not keep their type: %+v", aliases)
+	}
+
+	if err := table.Remove(aliases[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	aliases, err = table.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aliases) != 2 {
+		t.Fatalf("expected 2 aliases after removing one but received %+v", aliases)
+	}
+
+	// A merge that's rolled back leaves the aliases as they were.
+	dbTx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := payee.Merge(dbTx, "KROGER CO", "Kroger"); err != nil {
+		t.Fatal(err)
+	}
+	if err := dbTx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if after, err := table.All(); err != nil || len(after) != 2 || after[0].Name != "Kroger Co" {
+		t.Fatalf("expected a rolled back merge to change nothing but received %+v and %v", after, err)
+	}
+	if err := payee.Merge(db, "KROGER CO", "Kroger"); err != nil {
+		t.Fatal(err)
+	}
+	aliases, err = table.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aliases) != 3 {
+		t.Fatalf("expected 3 aliases after a merge but received %+v", aliases)
+	}
+	for _, a := range aliases {
+		if a.Name != "Kroger" {
+			t.Fatalf("expected every alias to be merged into Kroger but received %+v", aliases)
+		}
+	}
+}
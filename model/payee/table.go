@@ -0,0 +1,149 @@
+package payee
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+// Table is the payees table in a database
+type Table struct{ DB *sql.DB }
+
+// Init creates the payees table if it doesn't exist.
+func (t *Table) Init() error {
+	_, err := t.DB.Exec(
+		fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s "+
+				"(%s INTEGER NOT NULL PRIMARY KEY, "+
+				"%s TEXT NOT NULL, %s INTEGER NOT NULL, %s TEXT NOT NULL, "+
+				"UNIQUE(%s,%s))",
+			TableName,
+			IDCol,
+			PatternCol,
+			RegexCol,
+			NameCol,
+			PatternCol,
+			RegexCol,
+		),
+	)
+	if err != nil {
+		return fmt.Errorf(
+			"payee: cannot create table: %w", err,
+		)
+	}
+	return nil
+}
+
+// All returns every alias in the table, sorted by name and then by ID.
+func (t *Table) All() ([]Alias, error) {
+	rows, err := t.DB.Query(
+		fmt.Sprintf(
+			"SELECT %s, %s, %s, %s FROM %s ORDER BY %s ASC, %s ASC",
+			IDCol,
+			PatternCol,
+			RegexCol,
+			NameCol,
+			TableName,
+			NameCol,
+			IDCol,
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("payee: could not query table: %w", err)
+	}
+	defer rows.Close()
+
+	var result []Alias
+	for rows.Next() {
+		a := Alias{}
+		if err := rows.Scan(&a.ID, &a.Pattern, &a.Regex, &a.Name); err != nil {
+			return nil, fmt.Errorf("payee: could not scan alias: %w", err)
+		}
+		result = append(result, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("payee: failed to scan result set: %w", err)
+	}
+	return result, nil
+}
+
+// Insert inserts an alias into the table. The ID provided by "a" is ignored,
+// as the database determines the ID. If there is already an alias with the
+// same pattern, it's replaced.
+func (t *Table) Insert(a Alias) error {
+	return insert(t.DB, a)
+}
+
+// insert inserts an alias with "e", like Insert.
+func insert(e transaction.Execer, a Alias) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+	_, err := e.Exec(
+		fmt.Sprintf(
+			"INSERT OR REPLACE INTO %s(%s, %s, %s) VALUES (?, ?, ?)",
+			TableName,
+			PatternCol,
+			RegexCol,
+			NameCol,
+		),
+		a.Pattern,
+		a.Regex,
+		a.Name,
+	)
+	if err != nil {
+		return fmt.Errorf("payee: could not insert %+v: %w", a, err)
+	}
+	return nil
+}
+
+// Rename changes the name of every alias named "name" to "newName". Names
+// aren't case sensitive.
+func (t *Table) Rename(name, newName string) error {
+	return rename(t.DB, name, newName)
+}
+
+// rename renames aliases with "e", like Rename.
+func rename(e transaction.Execer, name, newName string) error {
+	_, err := e.Exec(
+		fmt.Sprintf(
+			"UPDATE %s SET %s=? WHERE %s=? COLLATE NOCASE",
+			TableName,
+			NameCol,
+			NameCol,
+		),
+		newName,
+		name,
+	)
+	if err != nil {
+		return fmt.Errorf("payee: could not rename \"%s\" to \"%s\": %w", name, newName, err)
+	}
+	return nil
+}
+
+// Merge makes "from" an alias of "into" with "e", renaming every alias named
+// "from" to "into" too. "e" can be a database transaction, so that the merge
+// is part of a larger change.
+func Merge(e transaction.Execer, from, into string) error {
+	if err := rename(e, from, into); err != nil {
+		return err
+	}
+	return insert(e, Alias{Pattern: from, Name: into})
+}
+
+// Remove deletes the given alias from the table.
+func (t *Table) Remove(aliasID int) error {
+	_, err := t.DB.Exec(
+		fmt.Sprintf(
+			"DELETE FROM %s WHERE %s=?",
+			TableName,
+			IDCol,
+		),
+		aliasID,
+	)
+	if err != nil {
+		return fmt.Errorf("payee: could not remove alias #%d: %w", aliasID, err)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+// person provides a model for the people that the user shares expenses with,
+// like friends who split a dinner or an employer that reimburses travel. It
+// also provides a simple implementation of a sqlite table for storing them.
+package person
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	TableName = "people"
+	IDCol     = "ID"
+	NameCol   = "Name"
+)
+
+var (
+	// ErrNotFound is returned when there's no person with the given name or
+	// ID.
+	ErrNotFound = errors.New("person: no such person")
+	// ErrDuplicate is returned when adding a person with the same name as
+	// someone who's already in the table.
+	ErrDuplicate = errors.New("person: there's already someone with that name")
+)
+
+// Person is someone that the user shares expenses with.
+type Person struct {
+	ID int
+	// Name is what the person is called. Names aren't case sensitive, so no
+	// two people can have names that only differ by case.
+	Name string
+}
+
+// Validate returns an error if the person can't be added.
+func (p Person) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("person: a person must have a name")
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package person
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Table is the people table in a database
+type Table struct{ DB *sql.DB }
+
+// Init creates the people table if it doesn't exist.
+func (t *Table) Init() error {
+	_, err := t.DB.Exec(
+		fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s "+
+				"(%s INTEGER NOT NULL PRIMARY KEY, %s TEXT NOT NULL UNIQUE COLLATE NOCASE)",
+			TableName,
+			IDCol,
+			NameCol,
+		),
+	)
+	if err != nil {
+		return fmt.Errorf(
+			"person: cannot create table: %w", err,
+		)
+	}
+	return nil
+}
+
+// Add adds a person with the given name to the table and returns them. It
+// returns ErrDuplicate if someone already has the name.
+func (t *Table) Add(name string) (Person, error) {
+	p := Person{Name: strings.TrimSpace(name)}
+	if err := p.Validate(); err != nil {
+		return Person{}, err
+	}
+	result, err := t.DB.Exec(
+		fmt.Sprintf("INSERT INTO %s(%s) VALUES (?)", TableName, NameCol),
+		p.Name,
+	)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return Person{}, fmt.Errorf("%w: \"%s\"", ErrDuplicate, p.Name)
+		}
+		return Person{}, fmt.Errorf("person: could not add \"%s\": %w", p.Name, err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return Person{}, fmt.Errorf("person: could not get the ID of \"%s\": %w", p.Name, err)
+	}
+	p.ID = int(id)
+	return p, nil
+}
+
+// All returns everyone in the table, sorted by name.
+func (t *Table) All() ([]Person, error) {
+	rows, err := t.DB.Query(
+		fmt.Sprintf("SELECT %s, %s FROM %s ORDER BY %s ASC", IDCol, NameCol, TableName, NameCol),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("person: could not query table: %w", err)
+	}
+	defer rows.Close()
+
+	var result []Person
+	for rows.Next() {
+		p := Person{}
+		if err := rows.Scan(&p.ID, &p.Name); err != nil {
+			return nil, fmt.Errorf("person: could not scan person: %w", err)
+		}
+		result = append(result, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("person: failed to scan result set: %w", err)
+	}
+	return result, nil
+}
+
+// Find returns the person with the given name, ignoring case, or ErrNotFound
+// if there isn't one.
+func (t *Table) Find(name string) (Person, error) {
+	p := Person{}
+	err := t.DB.QueryRow(
+		fmt.Sprintf("SELECT %s, %s FROM %s WHERE %s=?", IDCol, NameCol, TableName, NameCol),
+		strings.TrimSpace(name),
+	).Scan(&p.ID, &p.Name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Person{}, fmt.Errorf("%w named \"%s\"", ErrNotFound, name)
+	}
+	if err != nil {
+		return Person{}, fmt.Errorf("person: could not query table: %w", err)
+	}
+	return p, nil
+}
+
+// Remove removes the person with the given ID from the table.
+func (t *Table) Remove(personID int) error {
+	result, err := t.DB.Exec(
+		fmt.Sprintf("DELETE FROM %s WHERE %s=?", TableName, IDCol),
+		personID,
+	)
+	if err != nil {
+		return fmt.Errorf("person: could not remove person #%d: %w", personID, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("person: could not remove person #%d: %w", personID, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w #%d", ErrNotFound, personID)
+	}
+	return nil
+}
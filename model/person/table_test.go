@@ -0,0 +1,56 @@
+package person_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/Anthony-Fiddes/budgeter/model/person"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestTable(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection to ":memory:" gets its own database.
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+	table := &person.Table{DB: db}
+	if err := table.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	bob, err := table.Add(" Bob ")
+	if err != nil || bob.Name != "Bob" {
+		t.Fatalf("expected to add Bob but got %+v: %v", bob, err)
+	}
+	if _, err := table.Add("Alice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := table.Add("bob"); !errors.Is(err, person.ErrDuplicate) {
+		t.Errorf("expected ErrDuplicate but got %v", err)
+	}
+	if _, err := table.Add("  "); err == nil {
+		t.Errorf("expected a person without a name to be invalid")
+	}
+
+	people, err := table.All()
+	if err != nil || len(people) != 2 || people[0].Name != "Alice" || people[1] != bob {
+		t.Errorf("expected Alice and Bob but got %+v: %v", people, err)
+	}
+	if found, err := table.Find("BOB"); err != nil || found != bob {
+		t.Errorf("expected to find Bob but got %+v: %v", found, err)
+	}
+
+	if err := table.Remove(bob.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := table.Find("Bob"); !errors.Is(err, person.ErrNotFound) {
+		t.Errorf("expected ErrNotFound but got %v", err)
+	}
+	if err := table.Remove(bob.ID); !errors.Is(err, person.ErrNotFound) {
+		t.Errorf("expected ErrNotFound but got %v", err)
+	}
+}
@@ -0,0 +1,292 @@
+// recurring provides a model for transactions that happen on a schedule, like
+// rent or a paycheck. It also provides a simple implementation of a sqlite
+// table for storing them.
+package recurring
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/internal/month"
+	"github.com/Anthony-Fiddes/budgeter/internal/period"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+const (
+	TableName   = "recurring"
+	IDCol       = "ID"
+	EntityCol   = "Entity"
+	AmountCol   = "Amount"
+	NoteCol     = "Note"
+	ScheduleCol = "Schedule"
+	StartCol    = "Starts"
+	EndCol      = "Ends"
+	PostedCol   = "Posted"
+	CurrencyCol = "Currency"
+	// LastDay can be used as a Schedule's Day to have it fall on the last day
+	// of every month.
+	LastDay = -1
+)
+
+// Schedule describes how often a recurring transaction happens.
+type Schedule struct {
+	// Period is the unit of time between occurrences.
+	Period period.Period
+	// Every is the number of Periods between occurrences.
+	Every int
+	// Day is the day of the month that monthly schedules fall on. 0 means the
+	// day of the month that the rule started on.
+	Day int
+	// BusinessDay moves occurrences that fall on a weekend to the closest
+	// weekday in the same month. Occurrences on the last day of the month move
+	// backwards, and all others move forwards.
+	BusinessDay bool
+}
+
+func ordinal(n int) string {
+	suffix := "th"
+	switch n % 10 {
+	case 1:
+		suffix = "st"
+	case 2:
+		suffix = "nd"
+	case 3:
+		suffix = "rd"
+	}
+	if n%100 >= 11 && n%100 <= 13 {
+		suffix = "th"
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+// String returns the schedule in the same format that ParseSchedule accepts,
+// e.g. "every 2 weeks" or "monthly on the last business day".
+func (s Schedule) String() string {
+	var result string
+	if s.Every == 1 {
+		result = map[period.Period]string{
+			period.Day:   "daily",
+			period.Week:  "weekly",
+			period.Month: "monthly",
+		}[s.Period]
+	} else {
+		result = fmt.Sprintf("every %d %ss", s.Every, strings.ToLower(s.Period.String()))
+	}
+	if s.Day == 0 && !s.BusinessDay {
+		return result
+	}
+	result += " on the "
+	switch s.Day {
+	case 0:
+		result += "same"
+	case LastDay:
+		result += "last"
+	default:
+		result += ordinal(s.Day)
+	}
+	if s.BusinessDay {
+		return result + " business day"
+	}
+	if s.Day == LastDay {
+		return result + " day"
+	}
+	return result
+}
+
+// ParseSchedule reads a schedule written like "daily", "weekly", "monthly", or
+// "every N days|weeks|months", optionally followed by the day of the month
+// that a monthly schedule falls on, e.g. "monthly on the 1st", "every 2 months
+// on the 15th" or "monthly on the last business day".
+func ParseSchedule(s string) (Schedule, error) {
+	scheduleErr := func(reason string) error {
+		return fmt.Errorf("recurring: invalid schedule \"%s\": %s", s, reason)
+	}
+
+	result := Schedule{Every: 1}
+	words := strings.Fields(strings.ToLower(s))
+	if len(words) == 0 {
+		return Schedule{}, scheduleErr("it is empty")
+	}
+	switch words[0] {
+	case "daily":
+		result.Period = period.Day
+	case "weekly":
+		result.Period = period.Week
+	case "monthly":
+		result.Period = period.Month
+	case "every":
+		words = words[1:]
+		if len(words) == 0 {
+			return Schedule{}, scheduleErr("\"every\" must be followed by a number of days, weeks or months")
+		}
+		if n, err := strconv.Atoi(words[0]); err == nil {
+			if n < 1 {
+				return Schedule{}, scheduleErr("the number of periods must be at least 1")
+			}
+			result.Every = n
+			words = words[1:]
+		}
+		if len(words) == 0 {
+			return Schedule{}, scheduleErr("\"every\" must be followed by a number of days, weeks or months")
+		}
+		result.Period = period.Get(strings.Title(strings.TrimSuffix(words[0], "s")))
+		if result.Period.Unknown() {
+			return Schedule{}, scheduleErr(fmt.Sprintf("unknown period \"%s\"", words[0]))
+		}
+	default:
+		return Schedule{}, scheduleErr("it must start with \"daily\", \"weekly\", \"monthly\" or \"every\"")
+	}
+	words = words[1:]
+	if len(words) == 0 {
+		return result, nil
+	}
+
+	if words[0] != "on" {
+		return Schedule{}, scheduleErr(fmt.Sprintf("unexpected \"%s\"", words[0]))
+	}
+	if result.Period != period.Month {
+		return Schedule{}, scheduleErr("only monthly schedules can fall on a specific day")
+	}
+	words = words[1:]
+	if len(words) > 0 && words[0] == "the" {
+		words = words[1:]
+	}
+	if len(words) == 0 {
+		return Schedule{}, scheduleErr("\"on\" must be followed by a day of the month")
+	}
+	switch day := words[0]; day {
+	case "last":
+		result.Day = LastDay
+	case "same":
+	default:
+		for _, suffix := range []string{"st", "nd", "rd", "th"} {
+			day = strings.TrimSuffix(day, suffix)
+		}
+		n, err := strconv.Atoi(day)
+		if err != nil || n < 1 || n > 31 {
+			return Schedule{}, scheduleErr(fmt.Sprintf("\"%s\" is not a day of the month", words[0]))
+		}
+		result.Day = n
+	}
+	words = words[1:]
+	if len(words) > 0 && words[0] == "business" {
+		result.BusinessDay = true
+		words = words[1:]
+	}
+	if len(words) > 0 && words[0] == "day" {
+		words = words[1:]
+	}
+	if len(words) > 0 {
+		return Schedule{}, scheduleErr(fmt.Sprintf("unexpected \"%s\"", words[0]))
+	}
+	return result, nil
+}
+
+// Rule represents a transaction that happens on a schedule.
+type Rule struct {
+	ID int
+	// Entity is the person or company the transaction is made with.
+	Entity string
+	// Amount is the cost of each transaction in cents
+	Amount transaction.Cent
+	// Currency is the ISO 4217 code of the currency that Amount is in. It's
+	// empty for rules made by older versions of budgeter, whose transactions
+	// are in the home currency.
+	Currency string
+	// Note is the note that each transaction is given.
+	Note     string
+	Schedule Schedule
+	// Start is the date of the first occurrence in Unix seconds.
+	Start int64
+	// End is the last date that an occurrence can happen on in Unix seconds. 0
+	// means that the rule never ends.
+	End int64
+	// Posted is the date of the latest occurrence that has been posted to the
+	// transactions table in Unix seconds. 0 means nothing has been posted yet.
+	Posted int64
+}
+
+// date returns the date of the "n"th occurrence of the rule, without checking
+// whether or not it's within the rule's bounds.
+func (r Rule) date(n int) time.Time {
+	start := time.Unix(r.Start, 0).UTC()
+	every := r.Schedule.Every
+	switch r.Schedule.Period {
+	case period.Day:
+		return start.AddDate(0, 0, n*every)
+	case period.Week:
+		return start.AddDate(0, 0, 7*n*every)
+	}
+
+	m := month.Add(month.Start(start), n*every)
+	lastDay := month.End(m).Day()
+	day := r.Schedule.Day
+	if day == 0 {
+		day = start.Day()
+	}
+	if day == LastDay || day > lastDay {
+		day = lastDay
+	}
+	result := time.Date(m.Year(), m.Month(), day, 0, 0, 0, 0, time.UTC)
+	if !r.Schedule.BusinessDay {
+		return result
+	}
+
+	step := 1
+	if r.Schedule.Day == LastDay {
+		step = -1
+	}
+	for result.Weekday() == time.Saturday || result.Weekday() == time.Sunday {
+		next := result.AddDate(0, 0, step)
+		if next.Month() != result.Month() {
+			step *= -1
+			continue
+		}
+		result = next
+	}
+	return result
+}
+
+// Occurrences returns the dates of the rule's occurrences between "from" and
+// "to", inclusive, in chronological order.
+func (r Rule) Occurrences(from, to time.Time) []time.Time {
+	var result []time.Time
+	if r.Schedule.Every < 1 || r.Schedule.Period.Unknown() {
+		return nil
+	}
+	for n := 0; ; n++ {
+		date := r.date(n)
+		if date.After(to) || (r.End != 0 && date.Unix() > r.End) {
+			break
+		}
+		if date.Before(from) || date.Unix() < r.Start {
+			continue
+		}
+		result = append(result, date)
+	}
+	return result
+}
+
+// Due returns the dates of the occurrences that have not been posted yet and
+// happen on or before "today".
+func (r Rule) Due(today time.Time) []time.Time {
+	from := time.Unix(r.Start, 0)
+	if r.Posted != 0 {
+		from = time.Unix(r.Posted, 0).AddDate(0, 0, 1)
+	}
+	return r.Occurrences(from, today)
+}
+
+// Transaction returns the transaction for the occurrence of the rule on the
+// given date.
+func (r Rule) Transaction(date time.Time) transaction.Transaction {
+	return transaction.Transaction{
+		Entity:   r.Entity,
+		Amount:   r.Amount,
+		Currency: r.Currency,
+		Date:     date.Unix(),
+		Note:     r.Note,
+	}
+}
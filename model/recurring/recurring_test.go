@@ -0,0 +1,195 @@
+package recurring_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/internal/period"
+	"github.com/Anthony-Fiddes/budgeter/model/recurring"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+func date(t *testing.T, s string) time.Time {
+	unix, err := transaction.Unix(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return time.Unix(unix, 0).UTC()
+}
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected recurring.Schedule
+		str      string
+	}{
+		{
+			input:    "daily",
+			expected: recurring.Schedule{Period: period.Day, Every: 1},
+			str:      "daily",
+		},
+		{
+			input:    "every 2 weeks",
+			expected: recurring.Schedule{Period: period.Week, Every: 2},
+			str:      "every 2 weeks",
+		},
+		{
+			input:    "Monthly on the 1st",
+			expected: recurring.Schedule{Period: period.Month, Every: 1, Day: 1},
+			str:      "monthly on the 1st",
+		},
+		{
+			input:    "every 3 months on 22",
+			expected: recurring.Schedule{Period: period.Month, Every: 3, Day: 22},
+			str:      "every 3 months on the 22nd",
+		},
+		{
+			input:    "monthly on the last business day",
+			expected: recurring.Schedule{Period: period.Month, Every: 1, Day: recurring.LastDay, BusinessDay: true},
+			str:      "monthly on the last business day",
+		},
+		{
+			input:    "every month on the same business day",
+			expected: recurring.Schedule{Period: period.Month, Every: 1, BusinessDay: true},
+			str:      "monthly on the same business day",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			result, err := recurring.ParseSchedule(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result != test.expected {
+				t.Fatalf("received %+v but expected %+v", result, test.expected)
+			}
+			if result.String() != test.str {
+				t.Fatalf("received %q but expected %q", result.String(), test.str)
+			}
+			again, err := recurring.ParseSchedule(result.String())
+			if err != nil || again != result {
+				t.Fatalf("schedule %q does not survive a round trip: %+v, %v", result, again, err)
+			}
+		})
+	}
+
+	for _, input := range []string{"", "yearly", "every 0 days", "weekly on the 1st", "monthly on the 32nd", "monthly please"} {
+		if _, err := recurring.ParseSchedule(input); err == nil {
+			t.Errorf("expected an error parsing %q", input)
+		}
+	}
+}
+
+func TestOccurrences(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule string
+		start    string
+		end      string
+		from     string
+		to       string
+		expected []string
+	}{
+		{
+			name:     "monthly on the 1st",
+			schedule: "monthly on the 1st",
+			start:    "1/1/2021",
+			from:     "1/1/2021",
+			to:       "4/1/2021",
+			expected: []string{"1/1/2021", "2/1/2021", "3/1/2021", "4/1/2021"},
+		},
+		{
+			name:     "start after day of month",
+			schedule: "monthly on the 1st",
+			start:    "1/15/2021",
+			from:     "1/1/2021",
+			to:       "3/31/2021",
+			expected: []string{"2/1/2021", "3/1/2021"},
+		},
+		{
+			name:     "every 2 weeks",
+			schedule: "every 2 weeks",
+			start:    "7/2/2021",
+			from:     "7/10/2021",
+			to:       "8/31/2021",
+			expected: []string{"7/16/2021", "7/30/2021", "8/13/2021", "8/27/2021"},
+		},
+		{
+			name:     "last business day",
+			schedule: "monthly on the last business day",
+			start:    "1/1/2021",
+			from:     "1/1/2021",
+			to:       "5/31/2021",
+			expected: []string{"1/29/2021", "2/26/2021", "3/31/2021", "4/30/2021", "5/31/2021"},
+		},
+		{
+			name:     "business day moves forward",
+			schedule: "monthly on the 1st business day",
+			start:    "5/1/2021",
+			from:     "5/1/2021",
+			to:       "8/31/2021",
+			expected: []string{"5/3/2021", "6/1/2021", "7/1/2021", "8/2/2021"},
+		},
+		{
+			name:     "day past the end of the month",
+			schedule: "monthly on the 31st",
+			start:    "1/31/2021",
+			from:     "1/1/2021",
+			to:       "4/30/2021",
+			expected: []string{"1/31/2021", "2/28/2021", "3/31/2021", "4/30/2021"},
+		},
+		{
+			name:     "end date",
+			schedule: "weekly",
+			start:    "7/1/2021",
+			end:      "7/20/2021",
+			from:     "7/1/2021",
+			to:       "12/31/2021",
+			expected: []string{"7/1/2021", "7/8/2021", "7/15/2021"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			schedule, err := recurring.ParseSchedule(test.schedule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := recurring.Rule{Schedule: schedule, Start: date(t, test.start).Unix()}
+			if test.end != "" {
+				r.End = date(t, test.end).Unix()
+			}
+			result := r.Occurrences(date(t, test.from), date(t, test.to))
+			if len(result) != len(test.expected) {
+				t.Fatalf("received %v but expected %v", result, test.expected)
+			}
+			for i := range result {
+				if got := result[i].Format(transaction.DateLayout); got != test.expected[i] {
+					t.Fatalf("occurrence %d is %s but expected %s", i, got, test.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDue(t *testing.T) {
+	schedule, err := recurring.ParseSchedule("monthly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := recurring.Rule{Schedule: schedule, Start: date(t, "1/5/2021").Unix()}
+	due := r.Due(date(t, "3/10/2021"))
+	if len(due) != 3 {
+		t.Fatalf("expected 3 due occurrences but received %v", due)
+	}
+
+	r.Posted = due[len(due)-1].Unix()
+	if due := r.Due(date(t, "3/10/2021")); len(due) != 0 {
+		t.Fatalf("expected no due occurrences after posting but received %v", due)
+	}
+	due = r.Due(date(t, "4/5/2021"))
+	if len(due) != 1 || due[0].Format(transaction.DateLayout) != "4/5/2021" {
+		t.Fatalf("expected only 4/5/2021 to be due but received %v", due)
+	}
+}
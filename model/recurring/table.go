@@ -0,0 +1,184 @@
+package recurring
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Table is the recurring transactions table in a database
+type Table struct{ DB *sql.DB }
+
+// Init creates the recurring transactions table if it doesn't exist.
+func (t *Table) Init() error {
+	_, err := t.DB.Exec(
+		fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s "+
+				"(%s INTEGER NOT NULL PRIMARY KEY, "+
+				"%s TEXT NOT NULL, %s INTEGER NOT NULL, %s TEXT NOT NULL, %s TEXT NOT NULL, "+
+				"%s INTEGER NOT NULL, %s INTEGER NOT NULL, %s INTEGER NOT NULL, %s %s)",
+			TableName,
+			IDCol,
+			EntityCol,
+			AmountCol,
+			NoteCol,
+			ScheduleCol,
+			StartCol,
+			EndCol,
+			PostedCol,
+			CurrencyCol,
+			currencyDefinition,
+		),
+	)
+	if err != nil {
+		return fmt.Errorf(
+			"recurring: cannot create table: %w", err,
+		)
+	}
+	return t.migrate()
+}
+
+// currencyDefinition is the definition of CurrencyCol, which tables made by
+// older versions of budgeter don't have.
+const currencyDefinition = "TEXT NOT NULL DEFAULT ''"
+
+// migrate adds CurrencyCol to a recurring table that doesn't have it.
+func (t *Table) migrate() error {
+	rows, err := t.DB.Query(fmt.Sprintf("PRAGMA table_info(%s)", TableName))
+	if err != nil {
+		return fmt.Errorf("recurring: cannot read table columns: %w", err)
+	}
+	found := false
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, colType    string
+			defaultValue     sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			rows.Close()
+			return fmt.Errorf("recurring: cannot read table columns: %w", err)
+		}
+		found = found || name == CurrencyCol
+	}
+	if err := rows.Close(); err != nil {
+		return fmt.Errorf("recurring: cannot read table columns: %w", err)
+	}
+	if found {
+		return nil
+	}
+	_, err = t.DB.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", TableName, CurrencyCol, currencyDefinition))
+	if err != nil {
+		return fmt.Errorf("recurring: cannot add column %s to table: %w", CurrencyCol, err)
+	}
+	return nil
+}
+
+// All returns every rule in the table, ordered by ID.
+func (t *Table) All() ([]Rule, error) {
+	rows, err := t.DB.Query(
+		fmt.Sprintf(
+			"SELECT %s, %s, %s, %s, %s, %s, %s, %s, %s FROM %s ORDER BY %s ASC",
+			IDCol,
+			EntityCol,
+			AmountCol,
+			CurrencyCol,
+			NoteCol,
+			ScheduleCol,
+			StartCol,
+			EndCol,
+			PostedCol,
+			TableName,
+			IDCol,
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("recurring: could not query table: %w", err)
+	}
+	defer rows.Close()
+
+	var result []Rule
+	for rows.Next() {
+		r := Rule{}
+		var schedule string
+		err := rows.Scan(&r.ID, &r.Entity, &r.Amount, &r.Currency, &r.Note, &schedule, &r.Start, &r.End, &r.Posted)
+		if err != nil {
+			return nil, fmt.Errorf("recurring: could not scan rule: %w", err)
+		}
+		r.Schedule, err = ParseSchedule(schedule)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("recurring: failed to scan result set: %w", err)
+	}
+	return result, nil
+}
+
+// Insert inserts a rule into the table. The ID provided by "r" is ignored, as
+// the database determines the ID.
+func (t *Table) Insert(r Rule) error {
+	_, err := t.DB.Exec(
+		fmt.Sprintf(
+			"INSERT INTO %s(%s, %s, %s, %s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			TableName,
+			EntityCol,
+			AmountCol,
+			CurrencyCol,
+			NoteCol,
+			ScheduleCol,
+			StartCol,
+			EndCol,
+			PostedCol,
+		),
+		r.Entity,
+		r.Amount,
+		r.Currency,
+		r.Note,
+		r.Schedule.String(),
+		r.Start,
+		r.End,
+		r.Posted,
+	)
+	if err != nil {
+		return fmt.Errorf("recurring: could not insert %+v: %w", r, err)
+	}
+	return nil
+}
+
+// SetPosted records that the occurrences of the given rule have been posted
+// up to and including "date".
+func (t *Table) SetPosted(ruleID int, date int64) error {
+	_, err := t.DB.Exec(
+		fmt.Sprintf(
+			"UPDATE %s SET %s=? WHERE %s=?",
+			TableName,
+			PostedCol,
+			IDCol,
+		),
+		date,
+		ruleID,
+	)
+	if err != nil {
+		return fmt.Errorf("recurring: could not update rule #%d: %w", ruleID, err)
+	}
+	return nil
+}
+
+// Remove deletes the given rule from the table. Transactions that it already
+// posted are left alone.
+func (t *Table) Remove(ruleID int) error {
+	_, err := t.DB.Exec(
+		fmt.Sprintf(
+			"DELETE FROM %s WHERE %s=?",
+			TableName,
+			IDCol,
+		),
+		ruleID,
+	)
+	if err != nil {
+		return fmt.Errorf("recurring: could not remove rule #%d: %w", ruleID, err)
+	}
+	return nil
+}
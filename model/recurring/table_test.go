@@ -0,0 +1,130 @@
+package recurring_test
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/Anthony-Fiddes/budgeter/model/recurring"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func getMemTable() (*recurring.Table, error) {
+	const URI = ":memory:"
+	db, err := sql.Open("sqlite3", URI)
+	if err != nil {
+		return nil, fmt.Errorf("error creating an in-memory database for testing: %w", err)
+	}
+	table := &recurring.Table{DB: db}
+	err = table.Init()
+	if err != nil {
+		return nil, fmt.Errorf("error creating the recurring table: %w", err)
+	}
+	return table, nil
+}
+
+func TestTable(t *testing.T) {
+	table, err := getMemTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.DB.Close()
+
+	rent, err := recurring.ParseSchedule("monthly on the 1st")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pay, err := recurring.ParseSchedule("monthly on the last business day")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testData := []recurring.Rule{
+		{Entity: "Landlord", Amount: -120000, Note: "Rent", Schedule: rent, Start: date(t, "1/1/2021").Unix()},
+		{Entity: "Employer", Amount: 250000, Schedule: pay, Start: date(t, "1/1/2021").Unix(), End: date(t, "12/31/2021").Unix()},
+		{Entity: "Ryokan", Amount: -30000, Currency: "JPY", Schedule: rent, Start: date(t, "1/1/2021").Unix()},
+	}
+	for _, r := range testData {
+		if err := table.Insert(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rules, err := table.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != len(testData) {
+		t.Fatalf("expected %d rules but received %+v", len(testData), rules)
+	}
+	for i, r := range rules {
+		expected := testData[i]
+		expected.ID = r.ID
+		if r != expected {
+			t.Fatalf("received %+v but expected %+v", r, expected)
+		}
+	}
+
+	posted := date(t, "2/1/2021").Unix()
+	if err := table.SetPosted(rules[0].ID, posted); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Remove(rules[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Remove(rules[2].ID); err != nil {
+		t.Fatal(err)
+	}
+	rules, err = table.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 1 || rules[0].Posted != posted {
+		t.Fatalf("expected only the first rule, posted on %d, but received %+v", posted, rules)
+	}
+}
+
+// TestMigrate tests that Init adds the currency column to a table made by an
+// older version of budgeter, and that its rules keep working.
+func TestMigrate(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	_, err = db.Exec(
+		"CREATE TABLE recurring (ID INTEGER NOT NULL PRIMARY KEY, Entity TEXT NOT NULL, Amount INTEGER NOT NULL, " +
+			"Note TEXT NOT NULL, Schedule TEXT NOT NULL, Starts INTEGER NOT NULL, Ends INTEGER NOT NULL, " +
+			"Posted INTEGER NOT NULL)",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec("INSERT INTO recurring VALUES (1, 'Landlord', -120000, 'Rent', 'monthly', 0, 0, 0)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := &recurring.Table{DB: db}
+	for i := 0; i < 2; i++ {
+		if err := table.Init(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rules, err := table.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 1 || rules[0].Entity != "Landlord" || rules[0].Currency != "" {
+		t.Fatalf("expected the old rule without a currency but received %+v", rules)
+	}
+	weekly, err := recurring.ParseSchedule("weekly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Insert(recurring.Rule{Entity: "Ryokan", Amount: -30000, Currency: "JPY", Schedule: weekly}); err != nil {
+		t.Fatal(err)
+	}
+	if rules, err = table.All(); err != nil || len(rules) != 2 || rules[1].Currency != "JPY" {
+		t.Fatalf("expected a new rule in yen but received %+v: %v", rules, err)
+	}
+}
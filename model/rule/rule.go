@@ -0,0 +1,189 @@
+// rule provides a model for rules that automatically categorize and clean up
+// transactions. It also provides a simple implementation of a sqlite table for
+// storing them.
+package rule
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+const (
+	TableName      = "rules"
+	IDCol          = "ID"
+	EntityCol      = "MatchEntity"
+	NoteCol        = "MatchNote"
+	MinCol         = "MinAmount"
+	MaxCol         = "MaxAmount"
+	AccountCol     = "MatchAccount"
+	SetCategoryCol = "SetCategory"
+	SetTagsCol     = "SetTags"
+	SetEntityCol   = "SetEntity"
+	SetNoteCol     = "SetNote"
+)
+
+// Rule matches transactions and changes them. Empty fields match everything
+// and change nothing.
+type Rule struct {
+	ID int
+	// Entity is a regular expression that a transaction's Entity must match.
+	// It's not case sensitive.
+	Entity string
+	// Note is a regular expression that a transaction's Note must match. It's
+	// not case sensitive.
+	Note string
+	// Min is the smallest Amount that a transaction can have to match.
+	Min *transaction.Cent
+	// Max is the largest Amount that a transaction can have to match.
+	Max *transaction.Cent
+	// Account is the Account that a transaction must have to match. It's not
+	// case sensitive.
+	Account string
+
+	// SetCategory is the Category given to matching transactions.
+	SetCategory string
+	// SetTags are added to the Tags of matching transactions.
+	SetTags []string
+	// SetEntity replaces the Entity of matching transactions, e.g. to clean up
+	// the names that banks use.
+	SetEntity string
+	// SetNote replaces the Note of matching transactions.
+	SetNote string
+}
+
+// Validate returns an error if the rule can't be used.
+func (r Rule) Validate() error {
+	_, err := compile(r)
+	return err
+}
+
+// String returns a short description of the rule, e.g.
+// `entity ~ "kroger" -> category "Groceries"`.
+func (r Rule) String() string {
+	var match, set []string
+	if r.Entity != "" {
+		match = append(match, fmt.Sprintf("entity ~ %q", r.Entity))
+	}
+	if r.Note != "" {
+		match = append(match, fmt.Sprintf("note ~ %q", r.Note))
+	}
+	if r.Min != nil {
+		match = append(match, fmt.Sprintf("amount >= %s", *r.Min))
+	}
+	if r.Max != nil {
+		match = append(match, fmt.Sprintf("amount <= %s", *r.Max))
+	}
+	if r.Account != "" {
+		match = append(match, fmt.Sprintf("account = %q", r.Account))
+	}
+	if len(match) == 0 {
+		match = append(match, "everything")
+	}
+	if r.SetEntity != "" {
+		set = append(set, fmt.Sprintf("entity %q", r.SetEntity))
+	}
+	if r.SetCategory != "" {
+		set = append(set, fmt.Sprintf("category %q", r.SetCategory))
+	}
+	if len(r.SetTags) > 0 {
+		set = append(set, fmt.Sprintf("tags %q", strings.Join(r.SetTags, transaction.TagSeparator)))
+	}
+	if r.SetNote != "" {
+		set = append(set, fmt.Sprintf("note %q", r.SetNote))
+	}
+	return strings.Join(match, ", ") + " -> " + strings.Join(set, ", ")
+}
+
+// compiled is a Rule with its regular expressions ready to use.
+type compiled struct {
+	Rule
+	entity *regexp.Regexp
+	note   *regexp.Regexp
+}
+
+func compile(r Rule) (compiled, error) {
+	result := compiled{Rule: r}
+	var err error
+	if r.Entity != "" {
+		result.entity, err = regexp.Compile("(?i)" + r.Entity)
+		if err != nil {
+			return compiled{}, fmt.Errorf("rule: invalid entity pattern %q: %w", r.Entity, err)
+		}
+	}
+	if r.Note != "" {
+		result.note, err = regexp.Compile("(?i)" + r.Note)
+		if err != nil {
+			return compiled{}, fmt.Errorf("rule: invalid note pattern %q: %w", r.Note, err)
+		}
+	}
+	if r.Min != nil && r.Max != nil && *r.Min > *r.Max {
+		return compiled{}, fmt.Errorf("rule: minimum amount %s is more than maximum amount %s", *r.Min, *r.Max)
+	}
+	if r.SetCategory == "" && len(r.SetTags) == 0 && r.SetEntity == "" && r.SetNote == "" {
+		return compiled{}, fmt.Errorf("rule: a rule must change at least one field")
+	}
+	return result, nil
+}
+
+func (c compiled) matches(tx transaction.Transaction) bool {
+	if c.entity != nil && !c.entity.MatchString(tx.Entity) {
+		return false
+	}
+	if c.note != nil && !c.note.MatchString(tx.Note) {
+		return false
+	}
+	if c.Min != nil && tx.Amount < *c.Min {
+		return false
+	}
+	if c.Max != nil && tx.Amount > *c.Max {
+		return false
+	}
+	if c.Account != "" && !strings.EqualFold(c.Account, tx.Account) {
+		return false
+	}
+	return true
+}
+
+// Engine applies a list of rules to transactions.
+type Engine struct {
+	rules []compiled
+}
+
+// NewEngine returns an Engine that applies the given rules in order.
+func NewEngine(rules []Rule) (*Engine, error) {
+	result := &Engine{}
+	for _, r := range rules {
+		c, err := compile(r)
+		if err != nil {
+			return nil, fmt.Errorf("rule: rule #%d is invalid: %w", r.ID, err)
+		}
+		result.rules = append(result.rules, c)
+	}
+	return result, nil
+}
+
+// Apply returns "tx" after it's been changed by every rule that matches it.
+// Rules are checked in order, so a rule sees the changes made by the ones
+// before it, and later rules win when they set the same field.
+func (e *Engine) Apply(tx transaction.Transaction) transaction.Transaction {
+	tx.Tags = append([]string(nil), tx.Tags...)
+	for _, r := range e.rules {
+		if !r.matches(tx) {
+			continue
+		}
+		if r.SetCategory != "" {
+			tx.Category = r.SetCategory
+		}
+		tx.AddTags(r.SetTags...)
+		if r.SetEntity != "" {
+			tx.Entity = r.SetEntity
+		}
+		if r.SetNote != "" {
+			tx.Note = r.SetNote
+		}
+	}
+	return tx
+}
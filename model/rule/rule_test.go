@@ -0,0 +1,149 @@
+package rule_test
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/Anthony-Fiddes/budgeter/model/rule"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func cents(c transaction.Cent) *transaction.Cent {
+	return &c
+}
+
+func TestEngine(t *testing.T) {
+	rules := []rule.Rule{
+		{Entity: "^kroger", SetEntity: "Kroger", SetCategory: "Groceries"},
+		{Entity: "^kroger$", Max: cents(-10000), SetTags: []string{"big"}},
+		{Note: "fuel|gas", SetCategory: "Gas", SetTags: []string{"car"}},
+		{Account: "work card", SetTags: []string{"reimbursable"}},
+		{Min: cents(100000), SetNote: "Paycheck"},
+	}
+	engine, err := rule.NewEngine(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		input    transaction.Transaction
+		expected transaction.Transaction
+	}{
+		{
+			name:     "no match",
+			input:    transaction.Transaction{Entity: "Lyft", Amount: -1368},
+			expected: transaction.Transaction{Entity: "Lyft", Amount: -1368},
+		},
+		{
+			name:     "clean up entity",
+			input:    transaction.Transaction{Entity: "KROGER #1234 COLUMBUS OH", Amount: -1212},
+			expected: transaction.Transaction{Entity: "Kroger", Amount: -1212, Category: "Groceries"},
+		},
+		{
+			name:  "later rules see earlier changes",
+			input: transaction.Transaction{Entity: "KROGER #1234", Amount: -25000},
+			expected: transaction.Transaction{
+				Entity: "Kroger", Amount: -25000, Category: "Groceries", Tags: []string{"big"},
+			},
+		},
+		{
+			name:  "later rules win",
+			input: transaction.Transaction{Entity: "Kroger Fuel", Amount: -4000, Note: "Fuel", Tags: []string{"car"}},
+			expected: transaction.Transaction{
+				Entity: "Kroger", Amount: -4000, Note: "Fuel", Category: "Gas", Tags: []string{"car"},
+			},
+		},
+		{
+			name:  "account",
+			input: transaction.Transaction{Entity: "Hotel", Amount: -20000, Account: "Work Card"},
+			expected: transaction.Transaction{
+				Entity: "Hotel", Amount: -20000, Account: "Work Card", Tags: []string{"reimbursable"},
+			},
+		},
+		{
+			name:     "minimum amount",
+			input:    transaction.Transaction{Entity: "Employer", Amount: 250000},
+			expected: transaction.Transaction{Entity: "Employer", Amount: 250000, Note: "Paycheck"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := engine.Apply(test.input)
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Fatalf("received %+v but expected %+v", result, test.expected)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	invalid := []rule.Rule{
+		{Entity: "kroger"},
+		{Entity: "(kroger", SetCategory: "Groceries"},
+		{Note: "[", SetCategory: "Groceries"},
+		{Min: cents(10), Max: cents(-10), SetCategory: "Groceries"},
+	}
+	for _, r := range invalid {
+		if err := r.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", r)
+		}
+	}
+	if _, err := rule.NewEngine(invalid); err == nil {
+		t.Error("expected NewEngine to reject invalid rules")
+	}
+}
+
+func TestTable(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	table := &rule.Table{DB: db}
+	if err := table.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	testData := []rule.Rule{
+		{Entity: "^kroger", SetEntity: "Kroger", SetCategory: "Groceries"},
+		{Min: cents(-5000), Max: cents(0), Account: "Checking", SetTags: []string{"small", "checking"}},
+	}
+	for _, r := range testData {
+		if err := table.Insert(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := table.Insert(rule.Rule{Entity: "kroger"}); err == nil {
+		t.Fatal("expected inserting an invalid rule to fail")
+	}
+
+	rules, err := table.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != len(testData) {
+		t.Fatalf("expected %d rules but received %+v", len(testData), rules)
+	}
+	for i, r := range rules {
+		expected := testData[i]
+		expected.ID = r.ID
+		if !reflect.DeepEqual(r, expected) {
+			t.Fatalf("received %+v but expected %+v", r, expected)
+		}
+	}
+
+	if err := table.Remove(rules[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	rules, err = table.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 1 || rules[0].Account != "Checking" {
+		t.Fatalf("expected only the second rule to remain but received %+v", rules)
+	}
+}
@@ -0,0 +1,154 @@
+package rule
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+// Table is the rules table in a database
+type Table struct{ DB *sql.DB }
+
+// Init creates the rules table if it doesn't exist.
+func (t *Table) Init() error {
+	_, err := t.DB.Exec(
+		fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s "+
+				"(%s INTEGER NOT NULL PRIMARY KEY, "+
+				"%s TEXT NOT NULL, %s TEXT NOT NULL, %s INTEGER, %s INTEGER, %s TEXT NOT NULL, "+
+				"%s TEXT NOT NULL, %s TEXT NOT NULL, %s TEXT NOT NULL, %s TEXT NOT NULL)",
+			TableName,
+			IDCol,
+			EntityCol,
+			NoteCol,
+			MinCol,
+			MaxCol,
+			AccountCol,
+			SetCategoryCol,
+			SetTagsCol,
+			SetEntityCol,
+			SetNoteCol,
+		),
+	)
+	if err != nil {
+		return fmt.Errorf(
+			"rule: cannot create table: %w", err,
+		)
+	}
+	return nil
+}
+
+// All returns every rule in the table in the order that they should be
+// applied.
+func (t *Table) All() ([]Rule, error) {
+	rows, err := t.DB.Query(
+		fmt.Sprintf(
+			"SELECT %s, %s, %s, %s, %s, %s, %s, %s, %s, %s FROM %s ORDER BY %s ASC",
+			IDCol,
+			EntityCol,
+			NoteCol,
+			MinCol,
+			MaxCol,
+			AccountCol,
+			SetCategoryCol,
+			SetTagsCol,
+			SetEntityCol,
+			SetNoteCol,
+			TableName,
+			IDCol,
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("rule: could not query table: %w", err)
+	}
+	defer rows.Close()
+
+	var result []Rule
+	for rows.Next() {
+		r := Rule{}
+		var min, max sql.NullInt64
+		var tags string
+		err := rows.Scan(
+			&r.ID, &r.Entity, &r.Note, &min, &max, &r.Account,
+			&r.SetCategory, &tags, &r.SetEntity, &r.SetNote,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("rule: could not scan rule: %w", err)
+		}
+		if min.Valid {
+			amount := transaction.Cent(min.Int64)
+			r.Min = &amount
+		}
+		if max.Valid {
+			amount := transaction.Cent(max.Int64)
+			r.Max = &amount
+		}
+		r.SetTags = transaction.ParseTags(tags)
+		result = append(result, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rule: failed to scan result set: %w", err)
+	}
+	return result, nil
+}
+
+// Insert inserts a rule into the table. The ID provided by "r" is ignored, as
+// the database determines the ID.
+func (t *Table) Insert(r Rule) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	var min, max sql.NullInt64
+	if r.Min != nil {
+		min = sql.NullInt64{Int64: int64(*r.Min), Valid: true}
+	}
+	if r.Max != nil {
+		max = sql.NullInt64{Int64: int64(*r.Max), Valid: true}
+	}
+	_, err := t.DB.Exec(
+		fmt.Sprintf(
+			"INSERT INTO %s(%s, %s, %s, %s, %s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			TableName,
+			EntityCol,
+			NoteCol,
+			MinCol,
+			MaxCol,
+			AccountCol,
+			SetCategoryCol,
+			SetTagsCol,
+			SetEntityCol,
+			SetNoteCol,
+		),
+		r.Entity,
+		r.Note,
+		min,
+		max,
+		r.Account,
+		r.SetCategory,
+		strings.Join(r.SetTags, transaction.TagSeparator),
+		r.SetEntity,
+		r.SetNote,
+	)
+	if err != nil {
+		return fmt.Errorf("rule: could not insert %+v: %w", r, err)
+	}
+	return nil
+}
+
+// Remove deletes the given rule from the table.
+func (t *Table) Remove(ruleID int) error {
+	_, err := t.DB.Exec(
+		fmt.Sprintf(
+			"DELETE FROM %s WHERE %s=?",
+			TableName,
+			IDCol,
+		),
+		ruleID,
+	)
+	if err != nil {
+		return fmt.Errorf("rule: could not remove rule #%d: %w", ruleID, err)
+	}
+	return nil
+}
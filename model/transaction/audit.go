@@ -0,0 +1,416 @@
+package transaction
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+)
+
+const (
+	AuditTableName       = "audit"
+	AuditIDCol           = "ID"
+	AuditActionCol       = "Action"
+	AuditTransactionCol  = "TransactionID"
+	AuditBeforeCol       = "Before"
+	AuditAfterCol        = "After"
+	AuditTimeCol         = "Time"
+	AuditUserCol         = "User"
+	AuditHashCol         = "Hash"
+	auditNoUpdateTrigger = "audit_no_update"
+	auditNoDeleteTrigger = "audit_no_delete"
+)
+
+// ErrTampered is returned by VerifyAudit when an entry in the audit log
+// doesn't match its hash, which means that the log has been edited.
+var ErrTampered = errors.New("transaction: the audit log has been tampered with")
+
+// Action is a kind of change to a transaction.
+type Action string
+
+const (
+	ActionInsert Action = "insert"
+	ActionUpdate Action = "update"
+	ActionStatus Action = "status"
+	// ActionRemove is a transaction being moved to the trash.
+	ActionRemove Action = "remove"
+	// ActionRecover is a transaction being taken out of the trash.
+	ActionRecover Action = "recover"
+	// ActionPurge is a transaction in the trash being deleted for good.
+	ActionPurge Action = "purge"
+	// ActionRestore is a transaction being put back the way it was, e.g. by
+	// undoing a change.
+	ActionRestore Action = "restore"
+	// ActionShare is a transaction's shares being changed.
+	ActionShare Action = "share"
+)
+
+// AuditEntry is a change to a transaction in the audit log. Before is nil if
+// the transaction was inserted, and After is nil if it was removed.
+type AuditEntry struct {
+	ID            int
+	Action        Action
+	TransactionID int
+	Before        *Transaction
+	After         *Transaction
+	// Time is when the change was made, in Unix seconds.
+	Time int64
+	// User is the name of the OS user who made the change.
+	User string
+	// Hash is the hex encoded SHA-256 hash of the entry and the hash of the
+	// entry before it, so that editing any entry breaks the chain of hashes
+	// after it.
+	Hash string
+}
+
+// initAudit creates the audit table if it doesn't exist. Triggers keep the
+// table append-only, so that changing its history takes going out of the way
+// to drop them, and VerifyAudit catches anything that does.
+func (t *Table) initAudit() error {
+	_, err := t.DB.Exec(
+		fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s "+
+				"(%s INTEGER NOT NULL PRIMARY KEY, %s TEXT NOT NULL, %s INTEGER NOT NULL, "+
+				"%s TEXT NOT NULL, %s TEXT NOT NULL, %s INTEGER NOT NULL, %s TEXT NOT NULL, %s TEXT NOT NULL)",
+			AuditTableName,
+			AuditIDCol,
+			AuditActionCol,
+			AuditTransactionCol,
+			AuditBeforeCol,
+			AuditAfterCol,
+			AuditTimeCol,
+			AuditUserCol,
+			AuditHashCol,
+		),
+	)
+	if err != nil {
+		return fmt.Errorf("transaction: cannot create audit table: %w", err)
+	}
+	for trigger, event := range map[string]string{
+		auditNoUpdateTrigger: "UPDATE",
+		auditNoDeleteTrigger: "DELETE",
+	} {
+		_, err := t.DB.Exec(
+			fmt.Sprintf(
+				"CREATE TRIGGER IF NOT EXISTS %s BEFORE %s ON %s "+
+					"BEGIN SELECT RAISE(ABORT, 'the audit log is append-only'); END",
+				trigger,
+				event,
+				AuditTableName,
+			),
+		)
+		if err != nil {
+			return fmt.Errorf("transaction: cannot create audit trigger: %w", err)
+		}
+	}
+	return nil
+}
+
+// CurrentUser returns the name of the OS user running budgeter, which is
+// recorded with every change in the audit log.
+func CurrentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
+
+// Observer is called with a change to a transaction in the same database
+// transaction as the change, so that whatever it records about the change is
+// kept if and only if the change is. The change is rolled back if it returns an
+// error. Before is nil if the transaction was inserted.
+type Observer func(e Execer, transactionID int, before, after *Transaction) error
+
+// Observe sets the observer that's called with every change made through the
+// table, except for restoring and purging transactions. A nil observer stops
+// observing.
+func (t *Table) Observe(o Observer) {
+	t.observer = o
+}
+
+// audited runs "change" in a database transaction along with the audit log
+// entry that records it, so that there's never a change without an entry.
+// The table's observer is called in the same database transaction. See audit.
+func (t *Table) audited(action Action, transactionID int, change func(e Execer) (int, error)) (int, error) {
+	var id int
+	err := t.inTx(func(e Execer) error {
+		var err error
+		id, err = audit(e, action, transactionID, t.observer, func() (int, error) { return change(e) })
+		return err
+	})
+	return id, err
+}
+
+// inTx runs "f" in a database transaction, which is committed if "f" succeeds
+// and rolled back if it doesn't.
+func (t *Table) inTx(f func(e Execer) error) error {
+	dbTx, err := t.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("transaction: could not begin a database transaction: %w", err)
+	}
+	if err := f(dbTx); err != nil {
+		dbTx.Rollback()
+		return err
+	}
+	if err := dbTx.Commit(); err != nil {
+		return fmt.Errorf("transaction: could not commit: %w", err)
+	}
+	return nil
+}
+
+// audit runs "change", which changes the transaction with the given ID and
+// returns its ID, and appends an entry for the change to the audit log. Then
+// it calls "observe" with the change, if it isn't nil. transactionID is 0 when
+// "change" inserts a new transaction. Nothing is appended or observed if the
+// transaction didn't actually change.
+func audit(e Execer, action Action, transactionID int, observe Observer, change func() (int, error)) (int, error) {
+	var before *Transaction
+	var err error
+	if transactionID != 0 {
+		if before, err = Snapshot(e, transactionID); err != nil {
+			return 0, err
+		}
+	}
+	id, err := change()
+	if err != nil {
+		return 0, err
+	}
+	after, err := Snapshot(e, id)
+	if err != nil {
+		return 0, err
+	}
+	entry := AuditEntry{
+		Action:        action,
+		TransactionID: id,
+		Before:        before,
+		After:         after,
+		Time:          time.Now().Unix(),
+		User:          CurrentUser(),
+	}
+	beforeImage, err := encodeImage(before)
+	if err != nil {
+		return 0, err
+	}
+	afterImage, err := encodeImage(after)
+	if err != nil {
+		return 0, err
+	}
+	if beforeImage == afterImage {
+		return id, nil
+	}
+
+	prevID, prevHash, err := lastAuditEntry(e)
+	if err != nil {
+		return 0, err
+	}
+	entry.ID = prevID + 1
+	entry.Hash = auditHash(prevHash, entry, beforeImage, afterImage)
+	_, err = e.Exec(
+		fmt.Sprintf(
+			"INSERT INTO %s(%s, %s, %s, %s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			AuditTableName,
+			AuditIDCol,
+			AuditActionCol,
+			AuditTransactionCol,
+			AuditBeforeCol,
+			AuditAfterCol,
+			AuditTimeCol,
+			AuditUserCol,
+			AuditHashCol,
+		),
+		entry.ID,
+		entry.Action,
+		entry.TransactionID,
+		beforeImage,
+		afterImage,
+		entry.Time,
+		entry.User,
+		entry.Hash,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("transaction: could not add to the audit log: %w", err)
+	}
+	if observe != nil {
+		if err := observe(e, id, before, after); err != nil {
+			return 0, err
+		}
+	}
+	return id, nil
+}
+
+// lastAuditEntry returns the ID and hash of the newest entry in the audit
+// log, or 0 and "" if it's empty.
+func lastAuditEntry(e Execer) (int, string, error) {
+	rows, err := e.Query(
+		fmt.Sprintf(
+			"SELECT %s, %s FROM %s ORDER BY %s DESC LIMIT 1",
+			AuditIDCol,
+			AuditHashCol,
+			AuditTableName,
+			AuditIDCol,
+		),
+	)
+	if err != nil {
+		return 0, "", fmt.Errorf("transaction: could not query the audit log: %w", err)
+	}
+	defer rows.Close()
+	var id int
+	var hash string
+	if rows.Next() {
+		if err := rows.Scan(&id, &hash); err != nil {
+			return 0, "", fmt.Errorf("transaction: could not scan the audit log: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, "", fmt.Errorf("transaction: could not query the audit log: %w", err)
+	}
+	return id, hash, nil
+}
+
+// auditHash returns the hash of an entry, given the hash of the entry before
+// it and the entry's images in the form that they're stored in.
+func auditHash(prevHash string, entry AuditEntry, before, after string) string {
+	h := sha256.New()
+	fmt.Fprintf(
+		h, "%s\n%d\n%s\n%d\n%q\n%q\n%d\n%q\n",
+		prevHash, entry.ID, entry.Action, entry.TransactionID, before, after, entry.Time, entry.User,
+	)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// encodeImage returns the form of a transaction that's stored in the audit
+// log. A missing transaction is stored as an empty string.
+func encodeImage(image *Transaction) (string, error) {
+	if image == nil {
+		return "", nil
+	}
+	b, err := json.Marshal(image)
+	if err != nil {
+		return "", fmt.Errorf("transaction: could not encode transaction #%d: %w", image.ID, err)
+	}
+	return string(b), nil
+}
+
+// decodeImage is the inverse of encodeImage.
+func decodeImage(image string) (*Transaction, error) {
+	if image == "" {
+		return nil, nil
+	}
+	tx := &Transaction{}
+	if err := json.Unmarshal([]byte(image), tx); err != nil {
+		return nil, fmt.Errorf("transaction: could not decode transaction: %w", err)
+	}
+	return tx, nil
+}
+
+// auditRows wraps the rows of the audit table to scan entries from them. The
+// entries' images are returned in the form they're stored in, so that they
+// can be checked against the entries' hashes before they're decoded.
+type auditRows struct{ *sql.Rows }
+
+func (r auditRows) scan() (AuditEntry, string, string, error) {
+	entry := AuditEntry{}
+	var before, after string
+	err := r.Rows.Scan(
+		&entry.ID, &entry.Action, &entry.TransactionID, &before, &after, &entry.Time, &entry.User, &entry.Hash,
+	)
+	if err != nil {
+		return AuditEntry{}, "", "", fmt.Errorf("transaction: could not scan the audit log: %w", err)
+	}
+	return entry, before, after, nil
+}
+
+// queryAudit returns the rows of the audit log in the given order, e.g.
+// "ASC", limited to "limit" rows. A negative limit returns all of them.
+func (t *Table) queryAudit(order string, limit int) (auditRows, error) {
+	rows, err := t.DB.Query(
+		fmt.Sprintf(
+			"SELECT %s, %s, %s, %s, %s, %s, %s, %s FROM %s ORDER BY %s %s LIMIT ?",
+			AuditIDCol,
+			AuditActionCol,
+			AuditTransactionCol,
+			AuditBeforeCol,
+			AuditAfterCol,
+			AuditTimeCol,
+			AuditUserCol,
+			AuditHashCol,
+			AuditTableName,
+			AuditIDCol,
+			order,
+		),
+		limit,
+	)
+	if err != nil {
+		return auditRows{}, fmt.Errorf("transaction: could not query the audit log: %w", err)
+	}
+	return auditRows{rows}, nil
+}
+
+// Audit returns up to "limit" of the newest entries in the audit log, newest
+// first. A negative limit returns all of them.
+func (t *Table) Audit(limit int) ([]AuditEntry, error) {
+	rows, err := t.queryAudit("DESC", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var result []AuditEntry
+	for rows.Next() {
+		entry, before, after, err := rows.scan()
+		if err != nil {
+			return nil, err
+		}
+		if entry.Before, err = decodeImage(before); err != nil {
+			return nil, err
+		}
+		if entry.After, err = decodeImage(after); err != nil {
+			return nil, err
+		}
+		result = append(result, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("transaction: could not query the audit log: %w", err)
+	}
+	return result, nil
+}
+
+// VerifyAudit checks every entry in the audit log against its hash, and
+// returns how many there are and the hash of the newest one. It returns
+// ErrTampered for the first entry that has been edited, or that comes after
+// an entry that was removed.
+//
+// Removing the newest entries doesn't break the chain, so VerifyAudit can't
+// detect it on its own. Comparing the newest hash to one that was noted down
+// earlier can.
+func (t *Table) VerifyAudit() (int, string, error) {
+	rows, err := t.queryAudit("ASC", -1)
+	if err != nil {
+		return 0, "", err
+	}
+	defer rows.Close()
+	count := 0
+	hash := ""
+	for rows.Next() {
+		entry, before, after, err := rows.scan()
+		if err != nil {
+			return count, hash, err
+		}
+		if entry.Hash != auditHash(hash, entry, before, after) {
+			return count, hash, fmt.Errorf("%w: entry #%d doesn't match its hash", ErrTampered, entry.ID)
+		}
+		count++
+		hash = entry.Hash
+	}
+	if err := rows.Err(); err != nil {
+		return count, hash, fmt.Errorf("transaction: could not query the audit log: %w", err)
+	}
+	return count, hash, nil
+}
@@ -0,0 +1,141 @@
+package transaction_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+func TestAudit(t *testing.T) {
+	table, err := getMemTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.DB.Close()
+	table.DB.SetMaxOpenConns(1)
+
+	id, err := table.Insert(transaction.Transaction{Entity: "Kroger", Amount: -2000, Date: 86400})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := table.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Category = "Groceries"
+	if err := table.Update(tx); err != nil {
+		t.Fatal(err)
+	}
+	// Changes that don't change anything aren't logged.
+	if err := table.Update(tx); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.SetStatus(id, transaction.Cleared); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.SetStatus(id, transaction.Reconciled); err != nil {
+		t.Fatal(err)
+	}
+	// Failed changes aren't logged either.
+	if err := table.Remove(id); !errors.Is(err, transaction.ErrLocked) {
+		t.Fatalf("expected ErrLocked but got %v", err)
+	}
+	if err := table.SetStatus(id, transaction.Cleared); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Remove(id); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := table.Audit(-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []transaction.Action{
+		transaction.ActionRemove,
+		transaction.ActionStatus,
+		transaction.ActionStatus,
+		transaction.ActionUpdate,
+		transaction.ActionInsert,
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries but got %+v", len(expected), entries)
+	}
+	for i, e := range entries {
+		if e.Action != expected[i] || e.TransactionID != id || e.User == "" || e.Time == 0 {
+			t.Errorf("expected entry %d to be a %s of #%d but got %+v", i, expected[i], id, e)
+		}
+	}
+	if insert := entries[4]; insert.Before != nil || insert.After == nil || insert.After.Entity != "Kroger" {
+		t.Errorf("expected the insert to have only an after image but got %+v", insert)
+	}
+	if update := entries[3]; update.Before.Category != "" || update.After.Category != "Groceries" {
+		t.Errorf("expected the update's images to show the change but got %+v", update)
+	}
+	if remove := entries[0]; remove.Before.Deleted != 0 || remove.After.Deleted == 0 {
+		t.Errorf("expected the removal to move the transaction to the trash but got %+v", remove)
+	}
+
+	count, hash, err := table.VerifyAudit()
+	if err != nil || count != len(expected) || hash != entries[0].Hash {
+		t.Fatalf("expected the log to verify but got %d, %s: %v", count, hash, err)
+	}
+
+	if _, err := table.DB.Exec("UPDATE audit SET User='mallory' WHERE ID=2"); err == nil {
+		t.Fatal("expected the audit log to be append-only")
+	}
+	if _, err := table.DB.Exec("DROP TRIGGER audit_no_update"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := table.DB.Exec("UPDATE audit SET User='mallory' WHERE ID=2"); err != nil {
+		t.Fatal(err)
+	}
+	count, _, err = table.VerifyAudit()
+	if !errors.Is(err, transaction.ErrTampered) || count != 1 {
+		t.Errorf("expected ErrTampered after 1 good entry but got %d: %v", count, err)
+	}
+}
+
+func TestObserve(t *testing.T) {
+	table, err := getMemTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.DB.Close()
+	table.DB.SetMaxOpenConns(1)
+
+	var observed []int
+	errObserver := errors.New("could not record the change")
+	fail := false
+	table.Observe(func(e transaction.Execer, id int, before, after *transaction.Transaction) error {
+		if fail {
+			return errObserver
+		}
+		observed = append(observed, id)
+		return nil
+	})
+	id, err := table.Insert(transaction.Transaction{Entity: "Kroger", Amount: -2000, Date: 86400})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := table.RemoveAll([]int{id}); err != nil {
+		t.Fatal(err)
+	}
+	if len(observed) != 2 || observed[0] != id || observed[1] != id {
+		t.Errorf("expected the insert and the removal to be observed but got %v", observed)
+	}
+
+	// A change that can't be observed isn't made.
+	fail = true
+	if err := table.Recover(id); !errors.Is(err, errObserver) {
+		t.Fatalf("expected the observer's error but got %v", err)
+	}
+	if _, err := table.Get(id); !errors.Is(err, transaction.ErrNotFound) {
+		t.Errorf("expected the transaction to still be in the trash but got %v", err)
+	}
+	entries, err := table.Audit(-1)
+	if err != nil || len(entries) != 2 {
+		t.Errorf("expected only 2 audit entries but got %d: %v", len(entries), err)
+	}
+}
@@ -0,0 +1,162 @@
+package transaction
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/Anthony-Fiddes/budgeter/internal/money"
+	"github.com/Anthony-Fiddes/budgeter/model/currency"
+)
+
+var (
+	// ErrOverflow is returned when the result of arithmetic on amounts doesn't
+	// fit in a Cent.
+	ErrOverflow = errors.New("transaction: amount is too large")
+	// ErrInexact is returned when an amount can't be divided exactly and
+	// rounding isn't allowed.
+	ErrInexact = errors.New("transaction: amount can't be divided exactly")
+)
+
+// Cent represents the smallest unit of a currency, e.g. 1/100th of a Dollar or
+// 1 Yen. The name comes from when budgeter only supported US dollars. It's
+// always stored in 64 bits, so it has the same range on every platform.
+//
+// Arithmetic with the usual operators wraps around silently when it overflows,
+// so amounts that could be large (e.g. sums of many transactions) should use
+// the checked methods instead.
+type Cent int64
+
+// String returns a string that represents the value of the given number of
+// "cents" in the home currency.
+func (c Cent) String() string {
+	return c.Format(home)
+}
+
+// Format returns a string that represents the value of the given number of
+// minor units of "cur" in the current locale, e.g. "-$5.30" or "¥530".
+func (c Cent) Format(cur currency.Currency) string {
+	return locale.Format(int64(c), cur)
+}
+
+// Add returns c + other, or ErrOverflow if it doesn't fit in a Cent.
+func (c Cent) Add(other Cent) (Cent, error) {
+	result := c + other
+	if (other > 0 && result < c) || (other < 0 && result > c) {
+		return 0, ErrOverflow
+	}
+	return result, nil
+}
+
+// Sub returns c - other, or ErrOverflow if it doesn't fit in a Cent.
+func (c Cent) Sub(other Cent) (Cent, error) {
+	result := c - other
+	if (other > 0 && result > c) || (other < 0 && result < c) {
+		return 0, ErrOverflow
+	}
+	return result, nil
+}
+
+// Mul returns c * n, or ErrOverflow if it doesn't fit in a Cent.
+func (c Cent) Mul(n int64) (Cent, error) {
+	if c == 0 || n == 0 {
+		return 0, nil
+	}
+	if (c == -1 && n == math.MinInt64) || (n == -1 && c == math.MinInt64) {
+		return 0, ErrOverflow
+	}
+	result := c * Cent(n)
+	if int64(result)/n != int64(c) {
+		return 0, ErrOverflow
+	}
+	return result, nil
+}
+
+// abs returns the magnitude of "n" without overflowing.
+func abs(n int64) uint64 {
+	if n < 0 {
+		return uint64(-n)
+	}
+	return uint64(n)
+}
+
+// Div returns c / n, rounded to a whole number of minor units according to
+// "rounding". It returns ErrInexact if "rounding" is money.Reject and n
+// doesn't divide c exactly, and ErrOverflow if the result doesn't fit in a
+// Cent.
+func (c Cent) Div(n int64, rounding money.Rounding) (Cent, error) {
+	if n == 0 {
+		return 0, fmt.Errorf("transaction: can't divide %d by zero", c)
+	}
+	if c == math.MinInt64 && n == -1 {
+		return 0, ErrOverflow
+	}
+	quotient := int64(c) / n
+	remainder := int64(c) % n
+	if remainder == 0 {
+		return Cent(quotient), nil
+	}
+
+	// away is the direction away from zero. Since the remainder isn't zero,
+	// |n| is at least 2, so the quotient can move one step without
+	// overflowing.
+	away := int64(1)
+	if (c < 0) != (n < 0) {
+		away = -1
+	}
+	r, d := abs(remainder), abs(n)
+	switch rounding {
+	case money.Reject:
+		return 0, ErrInexact
+	case money.HalfUp:
+		if r >= d-r {
+			quotient += away
+		}
+	case money.HalfEven:
+		if r > d-r || (r == d-r && quotient%2 != 0) {
+			quotient += away
+		}
+	case money.Down:
+	default:
+		return 0, fmt.Errorf("transaction: unknown rounding %s", rounding)
+	}
+	return Cent(quotient), nil
+}
+
+// Allocate splits c into "n" parts that add up to exactly c. The parts differ
+// by at most one minor unit, and the ones furthest from zero come first, e.g.
+// $10.00 split 3 ways is $3.34, $3.33 and $3.33.
+func (c Cent) Allocate(n int) ([]Cent, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("transaction: can't split an amount into %d parts", n)
+	}
+	quotient := c / Cent(n)
+	remainder := c % Cent(n)
+	step := Cent(1)
+	if remainder < 0 {
+		step = -1
+		remainder = -remainder
+	}
+	result := make([]Cent, n)
+	for i := range result {
+		result[i] = quotient
+		if Cent(i) < remainder {
+			result[i] += step
+		}
+	}
+	return result, nil
+}
+
+// Sum adds up "amounts", or returns ErrOverflow if the total doesn't fit in a
+// Cent.
+func Sum(amounts ...Cent) (Cent, error) {
+	var result Cent
+	for _, a := range amounts {
+		var err error
+		result, err = result.Add(a)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return result, nil
+}
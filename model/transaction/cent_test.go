@@ -0,0 +1,199 @@
+package transaction_test
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+	"testing/quick"
+
+	"github.com/Anthony-Fiddes/budgeter/internal/money"
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+// fits reports whether "n" can be stored in a Cent.
+func fits(n *big.Int) bool {
+	return n.IsInt64()
+}
+
+// edgeCents are amounts that are likely to overflow, which quick.Check is
+// unlikely to generate by itself.
+var edgeCents = []int64{0, 1, -1, 2, -2, math.MaxInt64, math.MinInt64, math.MaxInt64 - 1, math.MinInt64 + 1}
+
+// checkEdges runs "f" on every pair of edgeCents.
+func checkEdges(t *testing.T, f func(a, b int64) bool) {
+	for _, a := range edgeCents {
+		for _, b := range edgeCents {
+			if !f(a, b) {
+				t.Errorf("failed for %d and %d", a, b)
+			}
+		}
+	}
+}
+
+func TestAdd(t *testing.T) {
+	property := func(a, b int64) bool {
+		expected := new(big.Int).Add(big.NewInt(a), big.NewInt(b))
+		result, err := transaction.Cent(a).Add(transaction.Cent(b))
+		if !fits(expected) {
+			return errors.Is(err, transaction.ErrOverflow)
+		}
+		return err == nil && int64(result) == expected.Int64()
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+	checkEdges(t, property)
+}
+
+func TestSub(t *testing.T) {
+	property := func(a, b int64) bool {
+		expected := new(big.Int).Sub(big.NewInt(a), big.NewInt(b))
+		result, err := transaction.Cent(a).Sub(transaction.Cent(b))
+		if !fits(expected) {
+			return errors.Is(err, transaction.ErrOverflow)
+		}
+		return err == nil && int64(result) == expected.Int64()
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+	checkEdges(t, property)
+}
+
+func TestMul(t *testing.T) {
+	property := func(a, b int64) bool {
+		expected := new(big.Int).Mul(big.NewInt(a), big.NewInt(b))
+		result, err := transaction.Cent(a).Mul(b)
+		if !fits(expected) {
+			return errors.Is(err, transaction.ErrOverflow)
+		}
+		return err == nil && int64(result) == expected.Int64()
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+	// Small factors are far more common in practice, and more likely to give
+	// results that fit.
+	small := func(a int64, b int16) bool { return property(a>>16, int64(b)) }
+	if err := quick.Check(small, nil); err != nil {
+		t.Error(err)
+	}
+	checkEdges(t, property)
+}
+
+func TestDiv(t *testing.T) {
+	// expectedDiv divides using exact rational arithmetic.
+	expectedDiv := func(a, b int64, rounding money.Rounding) (*big.Int, bool) {
+		exact := new(big.Rat).SetFrac(big.NewInt(a), big.NewInt(b))
+		quotient := new(big.Int).Quo(big.NewInt(a), big.NewInt(b))
+		remainder := new(big.Rat).Sub(exact, new(big.Rat).SetInt(quotient))
+		if remainder.Sign() == 0 {
+			return quotient, true
+		}
+		away := big.NewInt(int64(exact.Sign()))
+		half := big.NewRat(1, 2)
+		cmp := new(big.Rat).Abs(remainder).Cmp(half)
+		switch rounding {
+		case money.Reject:
+			return nil, false
+		case money.HalfUp:
+			if cmp >= 0 {
+				quotient.Add(quotient, away)
+			}
+		case money.HalfEven:
+			if cmp > 0 || (cmp == 0 && quotient.Bit(0) == 1) {
+				quotient.Add(quotient, away)
+			}
+		}
+		return quotient, true
+	}
+
+	for _, rounding := range []money.Rounding{money.Reject, money.HalfEven, money.HalfUp, money.Down} {
+		property := func(a, b int64) bool {
+			if b == 0 {
+				_, err := transaction.Cent(a).Div(b, rounding)
+				return err != nil
+			}
+			expected, exact := expectedDiv(a, b, rounding)
+			result, err := transaction.Cent(a).Div(b, rounding)
+			if !exact {
+				return errors.Is(err, transaction.ErrInexact)
+			}
+			if !fits(expected) {
+				return errors.Is(err, transaction.ErrOverflow)
+			}
+			return err == nil && int64(result) == expected.Int64()
+		}
+		if err := quick.Check(property, nil); err != nil {
+			t.Errorf("%s: %v", rounding, err)
+		}
+		small := func(a int64, b int8) bool { return property(a, int64(b)) }
+		if err := quick.Check(small, nil); err != nil {
+			t.Errorf("%s: %v", rounding, err)
+		}
+		checkEdges(t, property)
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	property := func(a int64, n uint8) bool {
+		if n == 0 {
+			_, err := transaction.Cent(a).Allocate(0)
+			return err != nil
+		}
+		parts, err := transaction.Cent(a).Allocate(int(n))
+		if err != nil || len(parts) != int(n) {
+			return false
+		}
+		total := new(big.Int)
+		for i, p := range parts {
+			total.Add(total, big.NewInt(int64(p)))
+			diff := int64(parts[0] - p)
+			if diff < -1 || diff > 1 {
+				return false
+			}
+			// Parts further from zero come first.
+			if i > 0 && abs(int64(p)) > abs(int64(parts[i-1])) {
+				return false
+			}
+		}
+		return total.IsInt64() && total.Int64() == a
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+	for _, a := range edgeCents {
+		if !property(a, 3) || !property(a, 255) {
+			t.Errorf("failed to allocate %d", a)
+		}
+	}
+
+	parts, err := transaction.Cent(1000).Allocate(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []transaction.Cent{334, 333, 333}
+	for i := range expected {
+		if parts[i] != expected[i] {
+			t.Fatalf("allocated %v but expected %v", parts, expected)
+		}
+	}
+}
+
+func abs(n int64) uint64 {
+	if n < 0 {
+		return uint64(-n)
+	}
+	return uint64(n)
+}
+
+func TestSum(t *testing.T) {
+	total, err := transaction.Sum(1, 2, 3)
+	if err != nil || total != 6 {
+		t.Errorf("Sum(1, 2, 3) = %d, %v", total, err)
+	}
+	if _, err := transaction.Sum(math.MaxInt64, 1, -1); !errors.Is(err, transaction.ErrOverflow) {
+		t.Errorf("expected an overflow but got %v", err)
+	}
+}
@@ -5,24 +5,97 @@ import (
 	"fmt"
 	"io"
 	"strings"
+
+	"github.com/Anthony-Fiddes/budgeter/internal/money"
+	"github.com/Anthony-Fiddes/budgeter/model/currency"
+)
+
+const (
+	numCols = 4
+	// numColsWithStatus is the number of columns in rows that also have a
+	// status.
+	numColsWithStatus = numCols + 1
+	// numColsWithCurrency is the number of columns in rows that also have a
+	// status and a currency.
+	numColsWithCurrency = numColsWithStatus + 1
+	// numColsExported is the number of columns in the rows that CSVWriter
+	// writes, which also have a category, tags, an account and a kind.
+	numColsExported = numColsWithCurrency + 4
 )
 
-const numCols = 4
+// Signs is a convention for which way amounts are signed. Banks don't agree on
+// one, so the amounts in files may be signed either way, but they're always
+// IncomePositive in a Transaction.
+type Signs string
+
+const (
+	// IncomePositive amounts are positive for money coming in and negative
+	// for money going out.
+	IncomePositive Signs = "income-positive"
+	// SpendingPositive amounts are positive for money going out and negative
+	// for money coming in, like on a credit card statement.
+	SpendingPositive Signs = "spending-positive"
+)
+
+// ParseSigns returns the sign convention with the given name. Names aren't
+// case sensitive.
+func ParseSigns(name string) (Signs, error) {
+	for _, s := range []Signs{IncomePositive, SpendingPositive} {
+		if strings.EqualFold(name, string(s)) {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("transaction: unknown sign convention \"%s\"", name)
+}
+
+// convert converts an amount between the sign convention and
+// IncomePositive. It works both ways, since it only ever flips the sign.
+func (s Signs) convert(amount Cent) Cent {
+	if s == SpendingPositive {
+		return -amount
+	}
+	return amount
+}
 
+// CSVWriter writes transactions as rows with the columns Date, Entity, Amount,
+// Note, Status, Currency, Category, Tags, Account and Kind, which CSVReader
+// can read back. Amounts are signed with FileSigns, and written as plain
+// numbers like "-1234.56" whatever the locale is (see money.Plain).
 type CSVWriter struct {
 	*csv.Writer
 }
 
 func (cw *CSVWriter) Write(tx Transaction) error {
+	cur := tx.CurrencyInfo()
 	row := []string{
 		tx.DateString(),
 		tx.Entity,
-		tx.Amount.String(),
+		formatPlain(signs.convert(tx.Amount), cur),
 		tx.Note,
+		string(tx.Status),
+		cur.Code,
+		tx.Category,
+		strings.Join(tx.Tags, TagSeparator),
+		tx.Account,
+		string(tx.Kind),
 	}
 	return cw.Writer.Write(row)
 }
 
+// formatPlain returns "amount" minor units of "cur" as a plain number, e.g.
+// "-1234.56".
+func formatPlain(amount Cent, cur currency.Currency) string {
+	cur.Symbol = ""
+	return money.Plain.Format(int64(amount), cur)
+}
+
+// parsePlain reads an amount of "cur" written by formatPlain.
+func parsePlain(amount string, cur currency.Currency) (Cent, error) {
+	cur.Symbol = ""
+	result, err := money.Plain.Parse(amount, cur)
+	return Cent(result), err
+}
+
 func (cw *CSVWriter) WriteAll(txs []Transaction) error {
 	for _, t := range txs {
 		if err := cw.Write(t); err != nil {
@@ -40,19 +113,31 @@ func NewCSVWriter(w io.Writer) *CSVWriter {
 
 type CSVReader struct {
 	*csv.Reader
+	// Currency is the currency that the amounts being read are in. If it's not
+	// set, they're in the home currency.
+	Currency currency.Currency
 }
 
+// Read reads a transaction from a row with the columns Date, Entity, Amount
+// and Note. A row may also have a fifth column with the transaction's status,
+// e.g. "pending", and a sixth with the ISO 4217 code of its currency, which
+// takes the place of cr.Currency. Amounts are read with FileSigns in the
+// current locale.
+//
+// Rows written by CSVWriter also have the transaction's category, tags,
+// account and kind, and their amounts are read as plain numbers instead.
 // ? Should I consider allowing headers to set the order?
 func (cr *CSVReader) Read() (Transaction, error) {
 	cols, err := cr.Reader.Read()
 	if err != nil {
 		return Transaction{}, err
 	}
-	if len(cols) != numCols {
+	exported := len(cols) == numColsExported
+	if !exported && (len(cols) < numCols || len(cols) > numColsWithCurrency) {
 		row := strings.Join(cols, string(cr.Reader.Comma))
 		return Transaction{}, fmt.Errorf(
-			"transaction: CSV row \"%s\" must have %d columns",
-			row, numCols,
+			"transaction: CSV row \"%s\" must have %d to %d columns, or %d",
+			row, numCols, numColsWithCurrency, numColsExported,
 		)
 	}
 	tx := Transaction{}
@@ -61,11 +146,44 @@ func (cr *CSVReader) Read() (Transaction, error) {
 		return Transaction{}, err
 	}
 	tx.Entity = cols[1]
-	tx.Amount, err = GetCents(cols[2])
+	cur := cr.Currency
+	if len(cols) >= numColsWithCurrency && strings.TrimSpace(cols[5]) != "" {
+		cur, err = currency.Lookup(strings.TrimSpace(cols[5]))
+		if err != nil {
+			return Transaction{}, err
+		}
+	}
+	if cur.Code == "" {
+		cur = home
+	}
+	tx.Currency = cur.Code
+	if exported {
+		tx.Amount, err = parsePlain(cols[2], cur)
+	} else {
+		tx.Amount, err = ParseAmount(cols[2], cur)
+	}
 	if err != nil {
 		return Transaction{}, err
 	}
+	tx.Amount = signs.convert(tx.Amount)
 	tx.Note = cols[3]
+	if len(cols) >= numColsWithStatus && strings.TrimSpace(cols[4]) != "" {
+		tx.Status, err = ParseStatus(strings.TrimSpace(cols[4]))
+		if err != nil {
+			return Transaction{}, err
+		}
+	}
+	if !exported {
+		return tx, nil
+	}
+	tx.Category = cols[6]
+	tx.Tags = ParseTags(cols[7])
+	tx.Account = cols[8]
+	if kind := strings.TrimSpace(cols[9]); kind != "" {
+		if tx.Kind, err = ParseKind(kind); err != nil {
+			return Transaction{}, err
+		}
+	}
 	return tx, nil
 }
 
@@ -86,5 +204,7 @@ func (cr *CSVReader) ReadAll() ([]Transaction, error) {
 
 func NewCSVReader(r io.Reader) *CSVReader {
 	cr := csv.NewReader(r)
+	// Rows may or may not have a status and a currency.
+	cr.FieldsPerRecord = -1
 	return &CSVReader{Reader: cr}
 }
@@ -2,8 +2,11 @@ package transaction_test
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 
+	"github.com/Anthony-Fiddes/budgeter/internal/money"
+	"github.com/Anthony-Fiddes/budgeter/model/currency"
 	"github.com/Anthony-Fiddes/budgeter/model/transaction"
 )
 
@@ -38,7 +41,7 @@ func csvTestData() []csvTest {
 					Note:   "it has begun.",
 				},
 			},
-			text: "12/31/1969,Apossumtheosis,$4000.00,it has begun.\n",
+			text: "12/31/1969,Apossumtheosis,4000.00,it has begun.,,USD,,,,\n",
 		},
 		{
 			name: "single negative transaction",
@@ -50,7 +53,7 @@ func csvTestData() []csvTest {
 					Note:   "it has begun.",
 				},
 			},
-			text: "12/31/1969,Apossumtheosis,-$4000.00,it has begun.\n",
+			text: "12/31/1969,Apossumtheosis,-4000.00,it has begun.,,USD,,,,\n",
 		},
 		{
 			name: "single modern transaction",
@@ -62,7 +65,7 @@ func csvTestData() []csvTest {
 					Note:   "it has begun.",
 				},
 			},
-			text: "7/8/2021,Apossumtheosis,$4000.00,it has begun.\n",
+			text: "7/8/2021,Apossumtheosis,4000.00,it has begun.,,USD,,,,\n",
 		},
 		{
 			name: "duplicate modern transactions",
@@ -80,8 +83,8 @@ func csvTestData() []csvTest {
 					Note:   "it has begun.",
 				},
 			},
-			text: "7/8/2021,Apossumtheosis,$4000.00,it has begun.\n" +
-				"7/8/2021,Apossumtheosis,$4000.00,it has begun.\n",
+			text: "7/8/2021,Apossumtheosis,4000.00,it has begun.,,USD,,,,\n" +
+				"7/8/2021,Apossumtheosis,4000.00,it has begun.,,USD,,,,\n",
 		},
 	}
 }
@@ -134,3 +137,134 @@ func TestCSVReader(t *testing.T) {
 		})
 	}
 }
+
+func TestCSVReaderStatus(t *testing.T) {
+	text := "7/8/2021,Kroger,-$12.12,Groceries,pending\n" +
+		"7/9/2021,Kroger,-$12.50,Groceries,Cleared\n" +
+		"7/9/2021,Lyft,-$13.68,,\n" +
+		"7/10/2021,Lyft,-$13.68,Ride\n"
+	results, err := transaction.NewCSVReader(bytes.NewBufferString(text)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []transaction.Status{transaction.Pending, transaction.Cleared, "", ""}
+	if len(results) != len(expected) {
+		t.Fatalf("read %d transactions instead of %d", len(results), len(expected))
+	}
+	for i, tx := range results {
+		if tx.Status != expected[i] {
+			t.Errorf("row %d had status %q instead of %q", i+1, tx.Status, expected[i])
+		}
+	}
+
+	_, err = transaction.NewCSVReader(bytes.NewBufferString("7/8/2021,Kroger,-$12.12,,bounced\n")).Read()
+	if err == nil {
+		t.Error("expected an error reading an unknown status")
+	}
+}
+
+func TestCSVReaderCurrency(t *testing.T) {
+	text := "7/8/2021,Ramen,\"¥1,200\",Lunch,,JPY\n" +
+		"7/9/2021,Kroger,-12.12,Groceries,pending,\n" +
+		"7/10/2021,Kroger,-12.50,Groceries\n"
+	cr := transaction.NewCSVReader(bytes.NewBufferString(text))
+	var err error
+	if cr.Currency, err = currency.Lookup("EUR"); err != nil {
+		t.Fatal(err)
+	}
+	results, err := cr.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 || results[0].Currency != "JPY" || results[0].Amount != 1200 ||
+		results[1].Currency != "EUR" || results[1].Amount != -1212 || results[2].Currency != "EUR" {
+		t.Errorf("expected the currency column to take the place of the reader's currency but got %+v", results)
+	}
+
+	_, err = transaction.NewCSVReader(bytes.NewBufferString("7/8/2021,Kroger,-$12.12,,,XYZ\n")).Read()
+	if err == nil {
+		t.Error("expected an error reading an unknown currency")
+	}
+	_, err = transaction.NewCSVReader(bytes.NewBufferString("7/8/2021,Kroger,-$12.12,,,USD,extra\n")).Read()
+	if err == nil {
+		t.Error("expected an error reading a row with too many columns")
+	}
+	_, err = transaction.NewCSVReader(bytes.NewBufferString("7/8/2021,Kroger,\"-$1,212.00\",,,USD,,,,\n")).Read()
+	if err == nil {
+		t.Error("expected an error reading an exported row whose amount isn't a plain number")
+	}
+}
+
+// TestCSVRoundTrip tests that transactions read back what was written, even
+// in other currencies, and even when the locale changed in between.
+func TestCSVRoundTrip(t *testing.T) {
+	written := []transaction.Transaction{
+		{Entity: "Ramen", Amount: -1200, Currency: "JPY", Date: 1625702400, Note: "Lunch", Tags: []string{"Travel"}},
+		{
+			Entity: "Boulangerie", Amount: -450, Currency: "EUR", Date: 1625788800, Status: transaction.Pending,
+			Category: "Groceries", Account: "Visa",
+		},
+		{
+			Entity: "Paycheck", Amount: 123456, Currency: "USD", Date: 1625875200, Status: transaction.Cleared,
+			Account: "Checking", Kind: transaction.Income,
+		},
+		{
+			Entity: "Savings", Amount: -50000, Currency: "USD", Date: 1625875200, Status: transaction.Reconciled,
+			Tags: []string{"Goals", "Emergency fund"}, Account: "Checking", Kind: transaction.Transfer,
+		},
+	}
+	buf := &bytes.Buffer{}
+	if err := transaction.NewCSVWriter(buf).WriteAll(written); err != nil {
+		t.Fatal(err)
+	}
+	de, err := money.Lookup("de-DE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	transaction.SetLocale(de)
+	defer transaction.SetLocale(money.Default)
+	read, err := transaction.NewCSVReader(buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != len(written) {
+		t.Fatalf("read %d transactions instead of %d", len(read), len(written))
+	}
+	for i, tx := range read {
+		want := written[i]
+		if !equal(tx, want) || tx.Currency != want.Currency || tx.Status != want.Status ||
+			tx.Category != want.Category || tx.Account != want.Account || tx.Kind != want.Kind ||
+			strings.Join(tx.Tags, ",") != strings.Join(want.Tags, ",") {
+			t.Errorf("read %+v instead of %+v", tx, want)
+		}
+	}
+}
+
+func TestCSVSigns(t *testing.T) {
+	transaction.SetFileSigns(transaction.SpendingPositive)
+	defer transaction.SetFileSigns(transaction.IncomePositive)
+
+	text := "7/8/2021,Kroger,$12.12,Groceries\n" +
+		"7/9/2021,Payroll,-$1000.00,Paycheck\n"
+	results, err := transaction.NewCSVReader(bytes.NewBufferString(text)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Amount != -1212 || results[1].Amount != 100000 {
+		t.Fatalf("expected spending to be negative and income to be positive but got %+v", results)
+	}
+	buf := &bytes.Buffer{}
+	if err := transaction.NewCSVWriter(buf).WriteAll(results[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "7/8/2021,Kroger,12.12,Groceries,,USD,,,,\n" {
+		t.Errorf("expected spending to be written as positive but got %q", buf.String())
+	}
+
+	if _, err := transaction.ParseSigns("Spending-Positive"); err != nil {
+		t.Error(err)
+	}
+	if _, err := transaction.ParseSigns("backwards"); err == nil {
+		t.Error("expected an error parsing an unknown sign convention")
+	}
+}
@@ -1,8 +1,12 @@
 package transaction_test
 
 import (
+	"errors"
 	"testing"
+	"unicode/utf8"
 
+	"github.com/Anthony-Fiddes/budgeter/internal/money"
+	"github.com/Anthony-Fiddes/budgeter/model/currency"
 	"github.com/Anthony-Fiddes/budgeter/model/transaction"
 )
 
@@ -47,6 +51,14 @@ func TestCents(t *testing.T) {
 			input:    "500,000.00",
 			expected: 50000000,
 		},
+		{
+			input:    "1,234,567.89",
+			expected: 123456789,
+		},
+		{
+			input:    "($12.50)",
+			expected: -1250,
+		},
 		{
 			input:    "-.5",
 			expected: -50,
@@ -97,3 +109,104 @@ func TestCents(t *testing.T) {
 		})
 	}
 }
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		input    string
+		code     string
+		expected transaction.Cent
+	}{
+		{input: "¥530", code: "JPY", expected: 530},
+		{input: "-1,000", code: "JPY", expected: -1000},
+		{input: "1.234", code: "BHD", expected: 1234},
+		{input: "BD1.5", code: "BHD", expected: 1500},
+		{input: "-.005", code: "BHD", expected: -5},
+		{input: "€12.50", code: "EUR", expected: 1250},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			cur, err := currency.Lookup(test.code)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := transaction.ParseAmount(test.input, cur)
+			if err != nil {
+				t.Fatalf("err: %s\ntest: %+v", err, test)
+			}
+			if result != test.expected {
+				t.Fatalf("received %d but expected %d", result, test.expected)
+			}
+		})
+	}
+
+	invalid := []struct{ input, code string }{
+		{input: "5.3", code: "JPY"},
+		{input: "5.123", code: "USD"},
+		{input: "1.2345", code: "BHD"},
+		{input: "1-2", code: "USD"},
+		{input: "5-", code: "USD"},
+		{input: "1.2.3", code: "USD"},
+	}
+	for _, test := range invalid {
+		cur, err := currency.Lookup(test.code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := transaction.ParseAmount(test.input, cur); err == nil {
+			t.Errorf("expected an error parsing %q as %s", test.input, test.code)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		amount   transaction.Cent
+		code     string
+		expected string
+	}{
+		{amount: 530, code: "USD", expected: "$5.30"},
+		{amount: -5, code: "USD", expected: "-$0.05"},
+		{amount: 530, code: "JPY", expected: "¥530"},
+		{amount: -1500, code: "BHD", expected: "-BD1.500"},
+		{amount: 123456789, code: "USD", expected: "$1,234,567.89"},
+	}
+	for _, test := range tests {
+		cur, err := currency.Lookup(test.code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result := test.amount.Format(cur); result != test.expected {
+			t.Errorf("received %q but expected %q", result, test.expected)
+		}
+	}
+}
+
+func FuzzGetCents(f *testing.F) {
+	for _, seed := range []string{
+		"5", "-5.30", "$1,234,567.89", "($12.50)", "12.50 DR", "12.50 CR", "1-2",
+		"5.123", "+$.5", "USD 5", "  $ - 5 ", "1,23", "", "(", "9223372036854775807",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		amount, err := transaction.GetCents(input)
+		if err != nil {
+			var parseErr *money.ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("parsing %q returned %v, not a *money.ParseError", input, err)
+			}
+			if parseErr.Column < 1 || parseErr.Column > utf8.RuneCountInString(input)+1 {
+				t.Fatalf("parsing %q returned an error at column %d, which is out of range", input, parseErr.Column)
+			}
+			return
+		}
+		formatted := amount.String()
+		again, err := transaction.GetCents(formatted)
+		if err != nil {
+			t.Fatalf("%q parsed as %d, which formats as %q, which can't be parsed: %v", input, amount, formatted, err)
+		}
+		if again != amount {
+			t.Fatalf("%q parsed as %d, which formats as %q, which parses as %d", input, amount, formatted, again)
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package transaction
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Kind is what a transaction means for the user's budget: money they earned,
+// money they spent, or money they moved between their own accounts.
+type Kind string
+
+const (
+	// Income is money the user earned or was given, like a paycheck.
+	Income Kind = "income"
+	// Expense is money the user spent, like a purchase. Refunds are expenses
+	// too, since they take back part of what was spent.
+	Expense Kind = "expense"
+	// Transfer is money moved between the user's own accounts, like paying off
+	// a credit card. It's neither income nor an expense.
+	Transfer Kind = "transfer"
+)
+
+// kinds are all of the kinds that a transaction can have.
+var kinds = []Kind{Income, Expense, Transfer}
+
+// Kinds returns every kind that a transaction can have.
+func Kinds() []Kind {
+	return append([]Kind(nil), kinds...)
+}
+
+// ParseKind returns the kind with the given name. Names aren't case
+// sensitive.
+func ParseKind(name string) (Kind, error) {
+	for _, k := range kinds {
+		if strings.EqualFold(name, string(k)) {
+			return k, nil
+		}
+	}
+	return "", fmt.Errorf("transaction: unknown kind \"%s\"", name)
+}
+
+// checkKind returns an error if "k" isn't empty or one of the kinds.
+func checkKind(k Kind) error {
+	if k == "" {
+		return nil
+	}
+	_, err := ParseKind(string(k))
+	return err
+}
+
+// Classify returns the transaction's kind. Transactions without one are
+// classified by their amount: money coming in is Income, and money going out
+// is an Expense.
+func (t Transaction) Classify() Kind {
+	if t.Kind != "" {
+		return t.Kind
+	}
+	if t.Amount > 0 {
+		return Income
+	}
+	return Expense
+}
+
+// kindOf is the kind of a transaction in SQL, in the same way as Classify.
+var kindOf = fmt.Sprintf(
+	"(CASE WHEN %s.%s<>'' THEN %s.%s WHEN %s.%s>0 THEN '%s' ELSE '%s' END)",
+	TableName, KindCol,
+	TableName, KindCol,
+	TableName, AmountCol,
+	Income,
+	Expense,
+)
+
+// RangeKindTotals returns the cost of the transactions that occurred within
+// the given range of time of each kind and currency, keyed by kind (see
+// Classify) and then by currency code. The bounds, statuses and shares are
+// treated in the same way as in RangeTotal.
+func (t *Table) RangeKindTotals(start, end time.Time, exclude ...Status) (map[Kind]map[string]Cent, error) {
+	condition, args := excluding(exclude)
+	rows, err := t.DB.Query(
+		fmt.Sprintf(
+			"SELECT %s, %s, SUM(%s) FROM %s WHERE %s >= ? AND %s <= ? AND %s GROUP BY %s, %s",
+			kindOf,
+			CurrencyCol,
+			ownAmount,
+			TableName,
+			DateCol,
+			DateCol,
+			condition,
+			kindOf,
+			CurrencyCol,
+		),
+		append([]interface{}{wallClock(start), wallClock(end)}, args...)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not get kind totals from %s to %s: %w", start, end, err)
+	}
+	defer rows.Close()
+	result := make(map[Kind]map[string]Cent)
+	for rows.Next() {
+		var kind Kind
+		var code string
+		var total int64
+		if err := rows.Scan(&kind, &code, &total); err != nil {
+			return nil, fmt.Errorf("transaction: could not scan totals: %w", err)
+		}
+		if result[kind] == nil {
+			result[kind] = make(map[string]Cent)
+		}
+		result[kind][code] = Cent(total)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("transaction: failed to scan totals: %w", err)
+	}
+	return result, nil
+}
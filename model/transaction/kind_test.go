@@ -0,0 +1,77 @@
+package transaction_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		tx       transaction.Transaction
+		expected transaction.Kind
+	}{
+		{transaction.Transaction{Amount: 100000}, transaction.Income},
+		{transaction.Transaction{Amount: -1212}, transaction.Expense},
+		{transaction.Transaction{Amount: 0}, transaction.Expense},
+		{transaction.Transaction{Amount: -50000, Kind: transaction.Transfer}, transaction.Transfer},
+		{transaction.Transaction{Amount: 2000, Kind: transaction.Expense}, transaction.Expense},
+	}
+	for _, test := range tests {
+		if kind := test.tx.Classify(); kind != test.expected {
+			t.Errorf("expected %+v to be %s but got %s", test.tx, test.expected, kind)
+		}
+	}
+}
+
+func TestKinds(t *testing.T) {
+	table, err := getMemTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.DB.Close()
+	table.DB.SetMaxOpenConns(1)
+
+	for _, tx := range []transaction.Transaction{
+		{Entity: "Payroll", Amount: 300000, Date: 86400},
+		{Entity: "Kroger", Amount: -5000, Date: 86400},
+		// A refund is taken off of what was spent.
+		{Entity: "Kroger", Amount: 1000, Date: 86400, Kind: transaction.Expense},
+		{Entity: "Credit Card", Amount: -20000, Date: 86400, Kind: transaction.Transfer},
+	} {
+		if _, err := table.Insert(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := table.Insert(transaction.Transaction{Entity: "Kroger", Amount: -1, Kind: "gift"}); err == nil {
+		t.Error("expected an error inserting a transaction with an unknown kind")
+	}
+
+	totals, err := table.RangeKindTotals(time.Unix(0, 0).UTC(), time.Unix(2*86400, 0).UTC())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if totals[transaction.Income]["USD"] != 300000 ||
+		totals[transaction.Expense]["USD"] != -4000 ||
+		totals[transaction.Transfer]["USD"] != -20000 {
+		t.Errorf("got the wrong totals: %v", totals)
+	}
+
+	rows, err := table.Search("Payroll", -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactions, err := rows.ScanSet()
+	if err != nil || len(transactions) != 1 {
+		t.Fatalf("expected to find the paycheck but got %v: %v", transactions, err)
+	}
+	tx := transactions[0]
+	tx.Kind = transaction.Transfer
+	if err := table.Update(tx); err != nil {
+		t.Fatal(err)
+	}
+	if tx, err = table.Get(tx.ID); err != nil || tx.Kind != transaction.Transfer {
+		t.Errorf("expected the paycheck to be a transfer but got %+v: %v", tx, err)
+	}
+}
@@ -0,0 +1,232 @@
+package transaction
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	SharesTableName     = "shares"
+	ShareTransactionCol = "TransactionID"
+	SharePersonCol      = "Person"
+	ShareAmountCol      = "Amount"
+)
+
+// ErrShares is returned when a transaction's shares don't fit its amount.
+var ErrShares = errors.New("transaction: invalid shares")
+
+// Share is the part of a transaction that belongs to someone else, like their
+// half of a dinner that the user paid for.
+type Share struct {
+	// Person is the ID of the person that the share belongs to.
+	Person int
+	// Amount is the person's part of the transaction's amount, in its currency
+	// and with the same sign. E.g. a share of -$30.00 of a -$90.00 dinner is
+	// what they owe the user, and a share of $40.00 of a $100.00 refund is
+	// what the user owes them.
+	Amount Cent
+}
+
+// ownAmount is the part of a transaction's amount that belongs to the user,
+// i.e. its amount without its shares.
+var ownAmount = fmt.Sprintf(
+	"(%s.%s - COALESCE((SELECT SUM(%s.%s) FROM %s WHERE %s.%s=%s.%s), 0))",
+	TableName,
+	AmountCol,
+	SharesTableName,
+	ShareAmountCol,
+	SharesTableName,
+	SharesTableName,
+	ShareTransactionCol,
+	TableName,
+	IDCol,
+)
+
+// OwnAmount returns the part of the transaction's amount that belongs to the
+// user, i.e. its amount without its shares. Only transactions from Get and
+// Snapshot have their shares filled in.
+func (t Transaction) OwnAmount() (Cent, error) {
+	result := t.Amount
+	for _, s := range t.Shares {
+		var err error
+		if result, err = result.Sub(s.Amount); err != nil {
+			return 0, err
+		}
+	}
+	return result, nil
+}
+
+// initShares creates the shares table if it doesn't exist.
+func (t *Table) initShares() error {
+	_, err := t.DB.Exec(
+		fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s "+
+				"(%s INTEGER NOT NULL, %s INTEGER NOT NULL, %s INTEGER NOT NULL, PRIMARY KEY(%s,%s))",
+			SharesTableName,
+			ShareTransactionCol,
+			SharePersonCol,
+			ShareAmountCol,
+			ShareTransactionCol,
+			SharePersonCol,
+		),
+	)
+	if err != nil {
+		return fmt.Errorf("transaction: cannot create shares table: %w", err)
+	}
+	return nil
+}
+
+// checkShares returns ErrShares if "shares" can't be the shares of a
+// transaction with the given amount. Each person can only have one share, and
+// together the shares can't be more than the amount.
+func checkShares(amount Cent, shares []Share) error {
+	seen := make(map[int]bool)
+	var total Cent
+	for _, s := range shares {
+		if seen[s.Person] {
+			return fmt.Errorf("%w: person #%d has more than one share", ErrShares, s.Person)
+		}
+		seen[s.Person] = true
+		if s.Amount == 0 || (s.Amount < 0) != (amount < 0) {
+			return fmt.Errorf("%w: a share of %s can't be part of %s", ErrShares, s.Amount, amount)
+		}
+		var err error
+		if total, err = total.Add(s.Amount); err != nil {
+			return err
+		}
+	}
+	if abs(int64(total)) > abs(int64(amount)) {
+		return fmt.Errorf("%w: shares of %s are more than %s", ErrShares, total, amount)
+	}
+	return nil
+}
+
+// readShares returns the shares of the transaction with the given ID in the
+// order they were added, or nil if it has none.
+func readShares(e Execer, transactionID int) ([]Share, error) {
+	rows, err := e.Query(
+		fmt.Sprintf(
+			"SELECT %s, %s FROM %s WHERE %s=? ORDER BY rowid ASC",
+			SharePersonCol,
+			ShareAmountCol,
+			SharesTableName,
+			ShareTransactionCol,
+		),
+		transactionID,
+	)
+	if err != nil {
+		return nil, queryError(err)
+	}
+	defer rows.Close()
+	var result []Share
+	for rows.Next() {
+		s := Share{}
+		if err := rows.Scan(&s.Person, &s.Amount); err != nil {
+			return nil, fmt.Errorf("transaction: could not scan share: %w", err)
+		}
+		result = append(result, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, queryError(err)
+	}
+	return result, nil
+}
+
+// writeShares replaces the shares of the transaction with the given ID.
+func writeShares(e Execer, transactionID int, shares []Share) error {
+	_, err := e.Exec(
+		fmt.Sprintf("DELETE FROM %s WHERE %s=?", SharesTableName, ShareTransactionCol),
+		transactionID,
+	)
+	if err != nil {
+		return fmt.Errorf("transaction: could not remove the shares of transaction #%d: %w", transactionID, err)
+	}
+	for _, s := range shares {
+		_, err := e.Exec(
+			fmt.Sprintf(
+				"INSERT INTO %s(%s, %s, %s) VALUES (?, ?, ?)",
+				SharesTableName,
+				ShareTransactionCol,
+				SharePersonCol,
+				ShareAmountCol,
+			),
+			transactionID,
+			s.Person,
+			s.Amount,
+		)
+		if err != nil {
+			return fmt.Errorf("transaction: could not add a share to transaction #%d: %w", transactionID, err)
+		}
+	}
+	return nil
+}
+
+// SetShares replaces the shares of the transaction with the given ID. No
+// shares means that all of it belongs to the user. Shares can be changed even
+// if the transaction is reconciled, since they don't change its amount. It
+// returns ErrNotFound if there isn't one, or ErrShares if the shares don't fit
+// its amount.
+func (t *Table) SetShares(transactionID int, shares []Share) error {
+	_, err := t.audited(ActionShare, transactionID, func(e Execer) (int, error) {
+		tx, err := Snapshot(e, transactionID)
+		if err != nil {
+			return 0, err
+		}
+		if tx == nil || tx.Deleted != 0 {
+			return 0, fmt.Errorf("%w #%d", ErrNotFound, transactionID)
+		}
+		if err := checkShares(tx.Amount, shares); err != nil {
+			return 0, fmt.Errorf("%w (#%d)", err, transactionID)
+		}
+		return transactionID, writeShares(e, transactionID, shares)
+	})
+	return err
+}
+
+// Balances returns how much each person owes the user in each currency, keyed
+// by person ID and then by currency code. A negative balance is what the user
+// owes them. Like totals, it leaves out void transactions and those in the
+// trash. People whose shares add up to nothing are included with a balance of
+// 0.
+func (t *Table) Balances() (map[int]map[string]Cent, error) {
+	condition, args := excluding(nil)
+	rows, err := t.DB.Query(
+		fmt.Sprintf(
+			"SELECT %s.%s, %s.%s, -SUM(%s.%s) FROM %s JOIN %s ON %s.%s=%s.%s WHERE %s GROUP BY %s.%s, %s.%s",
+			SharesTableName, SharePersonCol,
+			TableName, CurrencyCol,
+			SharesTableName, ShareAmountCol,
+			SharesTableName,
+			TableName,
+			SharesTableName, ShareTransactionCol,
+			TableName, IDCol,
+			condition,
+			SharesTableName, SharePersonCol,
+			TableName, CurrencyCol,
+		),
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("transaction: could not query database for balances: %w", err)
+	}
+	defer rows.Close()
+	result := make(map[int]map[string]Cent)
+	for rows.Next() {
+		var (
+			person  int
+			code    string
+			balance int64
+		)
+		if err := rows.Scan(&person, &code, &balance); err != nil {
+			return nil, fmt.Errorf("transaction: could not scan balances: %w", err)
+		}
+		if result[person] == nil {
+			result[person] = make(map[string]Cent)
+		}
+		result[person][code] = Cent(balance)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("transaction: failed to scan balances: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,104 @@
+package transaction_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+func TestShares(t *testing.T) {
+	table, err := getMemTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.DB.Close()
+	table.DB.SetMaxOpenConns(1)
+
+	const alice, bob = 1, 2
+	dinner, err := table.Insert(transaction.Transaction{Entity: "Olive Garden", Amount: -9000, Date: 86400})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shares := []transaction.Share{{Person: alice, Amount: -3000}, {Person: bob, Amount: -3000}}
+	if err := table.SetShares(dinner, shares); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := table.Get(dinner)
+	if err != nil || len(tx.Shares) != 2 || tx.Shares[0] != shares[0] || tx.Shares[1] != shares[1] {
+		t.Errorf("expected the dinner to have the shares but got %+v: %v", tx, err)
+	}
+	if own, err := tx.OwnAmount(); err != nil || own != -3000 {
+		t.Errorf("expected the user's part of the dinner to be -3000 but got %s: %v", own, err)
+	}
+	// Alice pays the user back.
+	_, err = table.Insert(transaction.Transaction{
+		Entity: "Alice",
+		Amount: 3000,
+		Date:   86400,
+		Shares: []transaction.Share{{Person: alice, Amount: 3000}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the user's part of the dinner is spending.
+	total, err := table.RangeTotal(time.Unix(0, 0).UTC(), time.Unix(2*86400, 0).UTC())
+	if err != nil || total != -3000 {
+		t.Errorf("expected a total of -3000 but got %s: %v", total, err)
+	}
+	categories, err := table.RangeCategoryTotals(time.Unix(0, 0).UTC(), time.Unix(2*86400, 0).UTC())
+	if err != nil || len(categories) != 1 || categories[""]["USD"] != -3000 {
+		t.Errorf("expected an uncategorized total of -3000 but got %v: %v", categories, err)
+	}
+	entities, err := table.RangeEntityExpenses(time.Unix(0, 0).UTC(), time.Unix(2*86400, 0).UTC())
+	if err != nil || entities["Olive Garden"]["USD"] != -3000 || entities["Alice"]["USD"] != 0 {
+		t.Errorf("expected only the user's part of each entity's total but got %v: %v", entities, err)
+	}
+	if total, err := table.Total(); err != nil || total != -3000 {
+		t.Errorf("expected a total of -3000 but got %s: %v", total, err)
+	}
+	if totals, err := table.Totals(); err != nil || len(totals) != 1 || totals["USD"] != -3000 {
+		t.Errorf("expected a USD total of -3000 but got %v: %v", totals, err)
+	}
+	balances, err := table.Balances()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balances[alice]["USD"] != 0 || balances[bob]["USD"] != 3000 || len(balances) != 2 {
+		t.Errorf("expected alice to be settled and bob to owe 3000 but got %v", balances)
+	}
+
+	for _, invalid := range [][]transaction.Share{
+		{{Person: alice, Amount: 3000}},
+		{{Person: alice, Amount: -5000}, {Person: bob, Amount: -5000}},
+		{{Person: alice, Amount: -1000}, {Person: alice, Amount: -1000}},
+	} {
+		if err := table.SetShares(dinner, invalid); !errors.Is(err, transaction.ErrShares) {
+			t.Errorf("expected ErrShares for %+v but got %v", invalid, err)
+		}
+	}
+	tx.Amount = -5000
+	if err := table.Update(tx); !errors.Is(err, transaction.ErrShares) {
+		t.Errorf("expected ErrShares when the amount is less than the shares but got %v", err)
+	}
+
+	// Shares in the trash don't count, and are gone once it's purged.
+	if err := table.Remove(dinner); err != nil {
+		t.Fatal(err)
+	}
+	if balances, err := table.Balances(); err != nil || len(balances) != 1 {
+		t.Errorf("expected only alice's repayment to count but got %v: %v", balances, err)
+	}
+	if _, err := table.Purge(time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := table.DB.QueryRow("SELECT COUNT(*) FROM " + transaction.SharesTableName).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("expected the dinner's shares to be purged but there are %d shares", n)
+	}
+}
@@ -0,0 +1,108 @@
+package transaction
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrTransition is returned when changing a transaction to a status that it
+// can't go to from its current one.
+var ErrTransition = errors.New("transaction: invalid status change")
+
+// Status is where a transaction is in its life, from being recorded to being
+// matched against a bank statement.
+type Status string
+
+const (
+	// Pending transactions haven't gone through the bank yet, so their amount
+	// may still change.
+	Pending Status = "pending"
+	// Cleared transactions have gone through the bank. It's the status that
+	// transactions have by default.
+	Cleared Status = "cleared"
+	// Reconciled transactions have been matched against a bank statement.
+	// They're locked, so they can't be updated or removed by accident.
+	Reconciled Status = "reconciled"
+	// Void transactions were canceled. They're kept for the record, but
+	// they're never included in totals.
+	Void Status = "void"
+)
+
+// statuses are all of the statuses that a transaction can have.
+var statuses = []Status{Pending, Cleared, Reconciled, Void}
+
+// transitions are the statuses that a transaction can go to from each status.
+// Reconciled transactions can go back to being cleared so that a mistake
+// while reconciling can be fixed, but void is final.
+var transitions = map[Status][]Status{
+	Pending:    {Cleared, Void},
+	Cleared:    {Reconciled, Void},
+	Reconciled: {Cleared},
+	Void:       {},
+}
+
+// Statuses returns every status that a transaction can have, in the order
+// that they usually go through.
+func Statuses() []Status {
+	return append([]Status(nil), statuses...)
+}
+
+// ParseStatus returns the status with the given name. Names aren't case
+// sensitive.
+func ParseStatus(name string) (Status, error) {
+	for _, s := range statuses {
+		if strings.EqualFold(name, string(s)) {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("transaction: unknown status \"%s\"", name)
+}
+
+// ParseStatuses parses a list of status names separated by TagSeparator.
+func ParseStatuses(names string) ([]Status, error) {
+	var result []Status
+	for _, name := range ParseTags(names) {
+		s, err := ParseStatus(name)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+// Locked returns whether transactions with the status can't be changed.
+func (s Status) Locked() bool {
+	return s == Reconciled
+}
+
+// CanBecome returns whether a transaction with the status can be changed to
+// "next".
+func (s Status) CanBecome(next Status) bool {
+	for _, t := range transitions[s.orDefault()] {
+		if t == next {
+			return true
+		}
+	}
+	return false
+}
+
+// from returns the statuses that a transaction can be changed to "s" from.
+func (s Status) from() []Status {
+	var result []Status
+	for _, prev := range statuses {
+		if prev.CanBecome(s) {
+			result = append(result, prev)
+		}
+	}
+	return result
+}
+
+// orDefault returns the status, or Cleared if it's empty.
+func (s Status) orDefault() Status {
+	if s == "" {
+		return Cleared
+	}
+	return s
+}
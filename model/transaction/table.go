@@ -2,46 +2,257 @@ package transaction
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+var (
+	// ErrDuplicate is returned when inserting a transaction that is already in
+	// the table.
+	ErrDuplicate = errors.New("transaction: duplicate transaction")
+	// ErrNotFound is returned when there's no transaction with the given ID.
+	ErrNotFound = errors.New("transaction: no such transaction")
+	// ErrLocked is returned when updating or removing a transaction whose
+	// status is locked, i.e. one that has been reconciled.
+	ErrLocked = errors.New("transaction: reconciled transactions can't be changed")
+	// ErrNotTrashed is returned when recovering a transaction that isn't in
+	// the trash.
+	ErrNotTrashed = errors.New("transaction: no such transaction in the trash")
 )
 
 // Table is the transactions table in a database
-type Table struct{ DB *sql.DB }
+type Table struct {
+	DB *sql.DB
+	// observer is called with every change made through the table. See
+	// Observe.
+	observer Observer
+}
+
+// columns are all of the columns in the transactions table, in the order that
+// Rows.Scan expects them.
+var columns = strings.Join(
+	[]string{
+		IDCol, EntityCol, AmountCol, DateCol, NoteCol, CategoryCol, TagsCol, AccountCol, CurrencyCol, StatusCol,
+		DeletedCol, KindCol,
+	},
+	", ",
+)
+
+// addedColumns are the columns that have been added to the transactions table
+// since it was first created, in the order they were added. Init adds any that
+// are missing, so that tables created by older versions of budgeter keep
+// working.
+var addedColumns = []struct{ name, definition string }{
+	{CategoryCol, "TEXT NOT NULL DEFAULT ''"},
+	{TagsCol, "TEXT NOT NULL DEFAULT ''"},
+	{AccountCol, "TEXT NOT NULL DEFAULT ''"},
+	// Transactions were always in US dollars before they had a currency.
+	{CurrencyCol, "TEXT NOT NULL DEFAULT 'USD'"},
+	{StatusCol, "TEXT NOT NULL DEFAULT '" + string(Cleared) + "'"},
+	{DeletedCol, "INTEGER NOT NULL DEFAULT 0"},
+	{KindCol, "TEXT NOT NULL DEFAULT ''"},
+}
+
+// uniqueIndexName is the name of the index that keeps the same transaction
+// from being added twice.
+const uniqueIndexName = TableName + "_unique"
+
+// baseColumns are the definitions of the columns that the transactions table
+// was first created with.
+var baseColumns = []struct{ name, definition string }{
+	{IDCol, "INTEGER NOT NULL PRIMARY KEY"},
+	{EntityCol, "TEXT NOT NULL"},
+	{AmountCol, "INTEGER NOT NULL"},
+	{DateCol, "INTEGER NOT NULL"},
+	{NoteCol, "TEXT NOT NULL"},
+}
 
 // Init creates the transactions table if it doesn't exist.
+//
+// Transactions with the same entity, amount, date and note are duplicates,
+// unless all but one of them are in the trash, so that a transaction that was
+// removed can be added again.
 func (t *Table) Init() error {
+	var definitions []string
+	for _, col := range baseColumns {
+		definitions = append(definitions, col.name+" "+col.definition)
+	}
 	_, err := t.DB.Exec(
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", TableName, strings.Join(definitions, ", ")),
+	)
+	if err != nil {
+		return fmt.Errorf(
+			"transaction: cannot create table: %w", err,
+		)
+	}
+	if err := t.migrate(); err != nil {
+		return err
+	}
+	if err := t.dropUniqueConstraint(); err != nil {
+		return err
+	}
+	_, err = t.DB.Exec(
 		fmt.Sprintf(
-			"CREATE TABLE IF NOT EXISTS %s "+
-				"(%s INTEGER NOT NULL PRIMARY KEY, "+
-				"%s TEXT NOT NULL, %s INTEGER NOT NULL, %s INTEGER NOT NULL, %s TEXT NOT NULL, "+
-				"UNIQUE(%s,%s,%s,%s))",
+			"CREATE UNIQUE INDEX IF NOT EXISTS %s ON %s(%s, %s, %s, %s) WHERE %s=0",
+			uniqueIndexName,
 			TableName,
-			IDCol,
-			EntityCol,
-			AmountCol,
-			DateCol,
-			NoteCol,
 			EntityCol,
 			AmountCol,
 			DateCol,
 			NoteCol,
+			DeletedCol,
 		),
 	)
 	if err != nil {
-		return fmt.Errorf(
-			"transaction: cannot create table: %w", err,
+		return fmt.Errorf("transaction: cannot create index: %w", err)
+	}
+	if err := t.initShares(); err != nil {
+		return err
+	}
+	return t.initAudit()
+}
+
+// migrate adds any of addedColumns that the transactions table is missing.
+func (t *Table) migrate() error {
+	rows, err := t.DB.Query(fmt.Sprintf("PRAGMA table_info(%s)", TableName))
+	if err != nil {
+		return fmt.Errorf("transaction: cannot read table columns: %w", err)
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, colType    string
+			defaultValue     sql.NullString
 		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			rows.Close()
+			return fmt.Errorf("transaction: cannot read table columns: %w", err)
+		}
+		existing[name] = true
+	}
+	if err := rows.Close(); err != nil {
+		return fmt.Errorf("transaction: cannot read table columns: %w", err)
+	}
+
+	for _, col := range addedColumns {
+		if existing[col.name] {
+			continue
+		}
+		_, err := t.DB.Exec(
+			fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", TableName, col.name, col.definition),
+		)
+		if err != nil {
+			return fmt.Errorf("transaction: cannot add column %s to table: %w", col.name, err)
+		}
 	}
 	return nil
 }
 
+// dropUniqueConstraint rebuilds a transactions table that was created by an
+// older version of budgeter, whose entities, amounts, dates and notes had to
+// be unique even in the trash. SQLite can't drop a table's constraint, so the
+// table is copied to a new one without it. It must be called after migrate,
+// so that the table has all of its columns.
+func (t *Table) dropUniqueConstraint() error {
+	var n int
+	err := t.DB.QueryRow(
+		"SELECT COUNT(*) FROM sqlite_master WHERE type='index' AND tbl_name=? AND name LIKE 'sqlite_autoindex_%'",
+		TableName,
+	).Scan(&n)
+	if err != nil {
+		return fmt.Errorf("transaction: cannot read table indexes: %w", err)
+	}
+	if n == 0 {
+		return nil
+	}
+
+	const rebuilt = TableName + "_rebuilt"
+	var definitions []string
+	for _, col := range append(baseColumns, addedColumns...) {
+		definitions = append(definitions, col.name+" "+col.definition)
+	}
+	return t.inTx(func(e Execer) error {
+		statements := []string{
+			fmt.Sprintf("CREATE TABLE %s (%s)", rebuilt, strings.Join(definitions, ", ")),
+			fmt.Sprintf("INSERT INTO %s(%s) SELECT %s FROM %s", rebuilt, columns, columns, TableName),
+			fmt.Sprintf("DROP TABLE %s", TableName),
+			fmt.Sprintf("ALTER TABLE %s RENAME TO %s", rebuilt, TableName),
+		}
+		for _, statement := range statements {
+			if _, err := e.Exec(statement); err != nil {
+				return fmt.Errorf("transaction: cannot rebuild table: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// NormalizeDates moves the dates of transactions that were added by older
+// versions of budgeter, which stored the time that they were added, to the
+// start of their day in the given time zone, in the form that DateOf returns.
+// It should be the user's time zone, which Init can't know, so it's run
+// separately. Dates that are already in that form are left alone, so it only
+// changes anything once. A transaction that would become the same as another
+// one is left alone too.
+func (t *Table) NormalizeDates(loc *time.Location) error {
+	const day = 24 * 60 * 60
+	rows, err := t.DB.Query(
+		fmt.Sprintf("SELECT %s, %s FROM %s WHERE %s %% %d <> 0", IDCol, DateCol, TableName, DateCol, day),
+	)
+	if err != nil {
+		return fmt.Errorf("transaction: cannot read dates: %w", err)
+	}
+	dates := make(map[int]int64)
+	for rows.Next() {
+		var id int
+		var date int64
+		if err := rows.Scan(&id, &date); err != nil {
+			rows.Close()
+			return fmt.Errorf("transaction: cannot read dates: %w", err)
+		}
+		dates[id] = date
+	}
+	if err := rows.Close(); err != nil {
+		return fmt.Errorf("transaction: cannot read dates: %w", err)
+	}
+
+	for id, date := range dates {
+		_, err := t.DB.Exec(
+			fmt.Sprintf("UPDATE %s SET %s=? WHERE %s=?", TableName, DateCol, IDCol),
+			DateOf(time.Unix(date, 0).In(loc)),
+			id,
+		)
+		if errors.Is(constraintError(err), ErrDuplicate) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("transaction: cannot change the date of transaction #%d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// constraintError returns ErrDuplicate if "e" was caused by a transaction
+// being the same as one already in the table. Otherwise, it returns "e".
+func constraintError(e error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(e, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		return ErrDuplicate
+	}
+	return e
+}
+
 func queryError(e error) error {
 	return fmt.Errorf("transaction: could not query table: %w", e)
 }
 
 // Search returns the most recent transactions that include the given "query".
+// Like every other query, it leaves out transactions that are in the trash.
 // It returns, at most, "limit" transactions, and returns more recent
 // transactions first. A negative "limit" will return as many
 // transactions as are available.
@@ -49,11 +260,14 @@ func (t *Table) Search(query string, limit int) (*Rows, error) {
 	query = "%" + query + "%"
 	rows, err := t.DB.Query(
 		fmt.Sprintf(
-			"SELECT * FROM %s WHERE %s LIKE ? OR %s LIKE ? ORDER BY %s DESC LIMIT ?",
+			"SELECT %s FROM %s WHERE (%s LIKE ? OR %s LIKE ?) AND %s=0 ORDER BY %s DESC, %s DESC LIMIT ?",
+			columns,
 			TableName,
 			EntityCol,
 			NoteCol,
+			DeletedCol,
 			DateCol,
+			IDCol,
 		),
 		query,
 		query,
@@ -68,16 +282,23 @@ func (t *Table) Search(query string, limit int) (*Rows, error) {
 // Range returns the transactions that occurred within the give range of time.
 // It returns, at most, "limit" transactions, and returns them in chronological
 // order. A negative "limit" will return as many transactions as are available.
+//
+// The bounds are compared to transaction dates using their wall clock time in
+// their own location, so e.g. the start and end of a month in the user's time
+// zone select the transactions that they made that month.
 func (t *Table) Range(start, end time.Time, limit int) (*Rows, error) {
-	startUnix := start.UTC().Unix()
-	stopUnix := end.UTC().Unix()
+	startUnix := wallClock(start)
+	stopUnix := wallClock(end)
 	rows, err := t.DB.Query(
 		fmt.Sprintf(
-			"SELECT * FROM %s WHERE %s >= ? AND %s <= ? ORDER BY %s ASC LIMIT ?",
+			"SELECT %s FROM %s WHERE %s >= ? AND %s <= ? AND %s=0 ORDER BY %s ASC, %s ASC LIMIT ?",
+			columns,
 			TableName,
 			DateCol,
 			DateCol,
+			DeletedCol,
 			DateCol,
+			IDCol,
 		),
 		startUnix,
 		stopUnix,
@@ -89,27 +310,39 @@ func (t *Table) Range(start, end time.Time, limit int) (*Rows, error) {
 	return &Rows{rows}, nil
 }
 
+// excluding returns a condition that leaves out void transactions, those in
+// the trash, and those with any of the given statuses, along with its
+// arguments.
+func excluding(exclude []Status) (string, []interface{}) {
+	args := []interface{}{Void}
+	for _, s := range exclude {
+		args = append(args, s)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(args)), ", ")
+	return fmt.Sprintf("%s NOT IN (%s) AND %s=0", StatusCol, placeholders, DeletedCol), args
+}
+
 // RangeTotal returns the cost of the transactions that occurred within the give
-// range of time.
+// range of time. The bounds are compared in the same way as in Range. Only
+// the user's part of each transaction is counted, i.e. its shares aren't.
 //
-// It uses, at most, "limit" transactions. A negative "limit" will use as many
-// transactions as are available.
-func (t *Table) RangeTotal(start, end time.Time) (Cent, error) {
-	startUnix := start.UTC().Unix()
-	stopUnix := end.UTC().Unix()
+// Void transactions and those with any of the statuses in "exclude" aren't
+// counted, e.g. Pending ones when only money that has gone through the bank
+// matters.
+func (t *Table) RangeTotal(start, end time.Time, exclude ...Status) (Cent, error) {
+	condition, args := excluding(exclude)
 	row := t.DB.QueryRow(
 		fmt.Sprintf(
-			"SELECT COALESCE(SUM(%s), 0) FROM %s WHERE %s >= ? AND %s <= ? ORDER BY %s ASC",
-			AmountCol,
+			"SELECT COALESCE(SUM(%s), 0) FROM %s WHERE %s >= ? AND %s <= ? AND %s",
+			ownAmount,
 			TableName,
 			DateCol,
 			DateCol,
-			DateCol,
+			condition,
 		),
-		startUnix,
-		stopUnix,
+		append([]interface{}{wallClock(start), wallClock(end)}, args...)...,
 	)
-	var total int
+	var total int64
 	err := row.Scan(&total)
 	if err != nil {
 		return 0, fmt.Errorf("could not get total from %s to %s: %w", start, end, err)
@@ -117,40 +350,513 @@ func (t *Table) RangeTotal(start, end time.Time) (Cent, error) {
 	return Cent(total), nil
 }
 
-// Insert inserts a transaction into the transactions table. The ID provided by
-// "tx" is ignored, as the database determines the ID.
-func (t *Table) Insert(tx Transaction) error {
-	_, err := t.DB.Exec(
+// RangeTotals returns the cost of the transactions that occurred within the
+// given range of time in each currency, keyed by currency code. The bounds are
+// compared in the same way as in Range, and statuses and shares are left out
+// in the same way as in RangeTotal.
+func (t *Table) RangeTotals(start, end time.Time, exclude ...Status) (map[string]Cent, error) {
+	condition, args := excluding(exclude)
+	rows, err := t.DB.Query(
+		fmt.Sprintf(
+			"SELECT %s, SUM(%s) FROM %s WHERE %s >= ? AND %s <= ? AND %s GROUP BY %s",
+			CurrencyCol,
+			ownAmount,
+			TableName,
+			DateCol,
+			DateCol,
+			condition,
+			CurrencyCol,
+		),
+		append([]interface{}{wallClock(start), wallClock(end)}, args...)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not get totals from %s to %s: %w", start, end, err)
+	}
+	return scanTotals(rows)
+}
+
+// RangeCategoryTotals returns the cost of the transactions that occurred
+// within the given range of time in each category and currency, keyed by
+// category and then by currency code. Uncategorized transactions are under "".
+// The bounds, statuses and shares are treated in the same way as in
+// RangeTotal.
+func (t *Table) RangeCategoryTotals(start, end time.Time, exclude ...Status) (map[string]map[string]Cent, error) {
+	return t.rangeTotalsBy(CategoryCol, "", start, end, exclude)
+}
+
+// RangeEntityExpenses returns the cost of the expenses (see Classify) that
+// occurred within the given range of time with each entity and in each
+// currency, keyed by entity and then by currency code. The bounds, statuses
+// and shares are treated in the same way as in RangeTotal.
+func (t *Table) RangeEntityExpenses(start, end time.Time, exclude ...Status) (map[string]map[string]Cent, error) {
+	return t.rangeTotalsBy(EntityCol, Expense, start, end, exclude)
+}
+
+// rangeTotalsBy returns the cost of the transactions that occurred within the
+// given range of time, grouped by the text column "column" and by currency. If
+// "kind" isn't empty, only transactions of that kind are counted.
+func (t *Table) rangeTotalsBy(column string, kind Kind, start, end time.Time, exclude []Status) (map[string]map[string]Cent, error) {
+	condition, args := excluding(exclude)
+	if kind != "" {
+		condition += fmt.Sprintf(" AND %s = ?", kindOf)
+		args = append(args, kind)
+	}
+	rows, err := t.DB.Query(
+		fmt.Sprintf(
+			"SELECT %s, %s, SUM(%s) FROM %s WHERE %s >= ? AND %s <= ? AND %s GROUP BY %s, %s",
+			column,
+			CurrencyCol,
+			ownAmount,
+			TableName,
+			DateCol,
+			DateCol,
+			condition,
+			column,
+			CurrencyCol,
+		),
+		append([]interface{}{wallClock(start), wallClock(end)}, args...)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not get %s totals from %s to %s: %w", strings.ToLower(column), start, end, err)
+	}
+	defer rows.Close()
+	result := make(map[string]map[string]Cent)
+	for rows.Next() {
+		var key, code string
+		var total int64
+		if err := rows.Scan(&key, &code, &total); err != nil {
+			return nil, fmt.Errorf("transaction: could not scan totals: %w", err)
+		}
+		if result[key] == nil {
+			result[key] = make(map[string]Cent)
+		}
+		result[key][code] = Cent(total)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("transaction: failed to scan totals: %w", err)
+	}
+	return result, nil
+}
+
+// Totals returns the total of all the transactions in the database in each
+// currency, keyed by currency code. Void transactions aren't counted, and like
+// in the range totals, only the user's part of a shared transaction is.
+func (t *Table) Totals() (map[string]Cent, error) {
+	condition, args := excluding(nil)
+	rows, err := t.DB.Query(
+		fmt.Sprintf(
+			"SELECT %s, SUM(%s) FROM %s WHERE %s GROUP BY %s",
+			CurrencyCol,
+			ownAmount,
+			TableName,
+			condition,
+			CurrencyCol,
+		),
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("transaction: could not query database for totals: %w", err)
+	}
+	return scanTotals(rows)
+}
+
+// scanTotals reads rows of currency codes and totals into a map.
+func scanTotals(rows *sql.Rows) (map[string]Cent, error) {
+	defer rows.Close()
+	result := make(map[string]Cent)
+	for rows.Next() {
+		var code string
+		var total int64
+		if err := rows.Scan(&code, &total); err != nil {
+			return nil, fmt.Errorf("transaction: could not scan totals: %w", err)
+		}
+		result[code] = Cent(total)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("transaction: failed to scan totals: %w", err)
+	}
+	return result, nil
+}
+
+// Get returns the transaction with the given ID, or ErrNotFound if there isn't
+// one or it's in the trash.
+func (t *Table) Get(transactionID int) (Transaction, error) {
+	tx, err := Snapshot(t.DB, transactionID)
+	if err != nil {
+		return Transaction{}, err
+	}
+	if tx == nil || tx.Deleted != 0 {
+		return Transaction{}, fmt.Errorf("%w #%d", ErrNotFound, transactionID)
+	}
+	return *tx, nil
+}
+
+// Execer runs statements against a database. Both *sql.DB and *sql.Tx are
+// Execers, so the functions that take one can be part of a larger database
+// transaction.
+type Execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Snapshot returns the transaction with the given ID as it's stored, even if
+// it's in the trash, or nil if there isn't one.
+func (t *Table) Snapshot(transactionID int) (*Transaction, error) {
+	return Snapshot(t.DB, transactionID)
+}
+
+// Snapshot returns the transaction with the given ID as it's stored, with its
+// shares, even if it's in the trash, or nil if there isn't one.
+func Snapshot(e Execer, transactionID int) (*Transaction, error) {
+	rows, err := e.Query(
+		fmt.Sprintf("SELECT %s FROM %s WHERE %s=?", columns, TableName, IDCol),
+		transactionID,
+	)
+	if err != nil {
+		return nil, queryError(err)
+	}
+	result := &Rows{rows}
+	if !result.Next() {
+		err := result.Err()
+		result.Close()
+		if err != nil {
+			return nil, queryError(err)
+		}
+		return nil, nil
+	}
+	tx, err := result.Scan()
+	// The rows are closed before reading the shares, since a database with
+	// only one connection can't run both queries at once.
+	result.Close()
+	if err != nil {
+		return nil, err
+	}
+	if tx.Shares, err = readShares(e, transactionID); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// Restore puts the transaction with the given ID back the way that "image"
+// is, including its ID, status, shares and whether it's in the trash, or
+// removes it for good if "image" is nil. Restore ignores locks, since it's
+// meant for undoing changes that have already been allowed. Like every other
+// change, it's recorded in the audit log.
+func Restore(e Execer, transactionID int, image *Transaction) error {
+	_, err := audit(e, ActionRestore, transactionID, nil, func() (int, error) {
+		_, err := e.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s=?", TableName, IDCol), transactionID)
+		if err != nil {
+			return 0, fmt.Errorf("transaction: could not restore transaction #%d: %w", transactionID, err)
+		}
+		if image == nil {
+			return transactionID, writeShares(e, transactionID, nil)
+		}
+		_, err = e.Exec(
+			fmt.Sprintf("INSERT INTO %s(%s) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", TableName, columns),
+			transactionID,
+			image.Entity,
+			image.Amount,
+			image.Date,
+			image.Note,
+			image.Category,
+			strings.Join(image.Tags, TagSeparator),
+			image.Account,
+			image.CurrencyInfo().Code,
+			image.Status.orDefault(),
+			image.Deleted,
+			image.Kind,
+		)
+		if err != nil {
+			return 0, fmt.Errorf(
+				"transaction: could not restore transaction #%d: %w", transactionID, constraintError(err),
+			)
+		}
+		return transactionID, writeShares(e, transactionID, image.Shares)
+	})
+	return err
+}
+
+// Insert inserts a transaction into the transactions table, along with its
+// shares, and returns its ID. The ID provided by "tx" is ignored, as the
+// database determines the ID.
+func (t *Table) Insert(tx Transaction) (int, error) {
+	if _, err := ParseStatus(string(tx.Status.orDefault())); err != nil {
+		return 0, err
+	}
+	if err := checkKind(tx.Kind); err != nil {
+		return 0, err
+	}
+	if err := checkShares(tx.Amount, tx.Shares); err != nil {
+		return 0, err
+	}
+	return t.audited(ActionInsert, 0, func(e Execer) (int, error) {
+		return insert(e, tx)
+	})
+}
+
+// insert inserts a transaction and returns its ID.
+func insert(e Execer, tx Transaction) (int, error) {
+	result, err := e.Exec(
 		fmt.Sprintf(
-			"INSERT INTO %s(%s, %s, %s, %s) VALUES (?, ?, ?, ?)",
+			"INSERT INTO %s(%s, %s, %s, %s, %s, %s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
 			TableName,
 			EntityCol,
 			AmountCol,
 			DateCol,
 			NoteCol,
+			CategoryCol,
+			TagsCol,
+			AccountCol,
+			CurrencyCol,
+			StatusCol,
+			KindCol,
 		),
 		tx.Entity,
 		tx.Amount,
 		tx.Date,
 		tx.Note,
+		tx.Category,
+		strings.Join(tx.Tags, TagSeparator),
+		tx.Account,
+		tx.CurrencyInfo().Code,
+		tx.Status.orDefault(),
+		tx.Kind,
 	)
 	if err != nil {
-		return fmt.Errorf("transaction: could not insert %+v: %w", tx, err)
+		return 0, fmt.Errorf("transaction: could not insert %+v: %w", tx, constraintError(err))
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("transaction: could not get the ID of %+v: %w", tx, err)
+	}
+	return int(id), writeShares(e, int(id), tx.Shares)
+}
+
+// checkChanged returns ErrNotFound or ErrLocked if "result" of changing an
+// unlocked transaction that isn't in the trash didn't affect any rows.
+func checkChanged(e Execer, result sql.Result, transactionID int) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	tx, err := Snapshot(e, transactionID)
+	if err != nil {
+		return err
+	}
+	if tx == nil || tx.Deleted != 0 {
+		return fmt.Errorf("%w #%d", ErrNotFound, transactionID)
+	}
+	return fmt.Errorf("%w (#%d)", ErrLocked, transactionID)
+}
+
+// Update overwrites the transaction in the table that has the same ID as "tx"
+// with "tx", except for its status and shares, which are changed with
+// SetStatus and SetShares. It returns ErrNotFound if there isn't one,
+// ErrLocked if it's reconciled, or ErrShares if its shares don't fit its new
+// amount.
+func (t *Table) Update(tx Transaction) error {
+	if err := checkKind(tx.Kind); err != nil {
+		return err
+	}
+	_, err := t.audited(ActionUpdate, tx.ID, func(e Execer) (int, error) {
+		return tx.ID, update(e, tx)
+	})
+	return err
+}
+
+// Clear updates a pending transaction like Update and clears it in one
+// database transaction, so that it's never left updated but still pending. It
+// returns the same errors as Update and SetStatus.
+func (t *Table) Clear(tx Transaction) error {
+	if err := checkKind(tx.Kind); err != nil {
+		return err
+	}
+	return t.inTx(func(e Execer) error {
+		_, err := audit(e, ActionUpdate, tx.ID, t.observer, func() (int, error) {
+			return tx.ID, update(e, tx)
+		})
+		if err != nil {
+			return err
+		}
+		_, err = audit(e, ActionStatus, tx.ID, t.observer, func() (int, error) {
+			return tx.ID, setStatus(e, tx.ID, Cleared)
+		})
+		return err
+	})
+}
+
+// UpdateAll updates the given transactions like Update in one database
+// transaction, so either all of them are updated or none of them are. It
+// returns the error of the first transaction that can't be updated. If "also"
+// isn't nil, it's run in the same database transaction afterwards, so that
+// related changes to other tables are made or rolled back along with the
+// updates.
+func (t *Table) UpdateAll(txs []Transaction, also func(e Execer) error) error {
+	for _, tx := range txs {
+		if err := checkKind(tx.Kind); err != nil {
+			return err
+		}
+	}
+	return t.inTx(func(e Execer) error {
+		for _, tx := range txs {
+			tx := tx
+			_, err := audit(e, ActionUpdate, tx.ID, t.observer, func() (int, error) {
+				return tx.ID, update(e, tx)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if also == nil {
+			return nil
+		}
+		return also(e)
+	})
+}
+
+// update overwrites the unlocked transaction that has the same ID as "tx".
+func update(e Execer, tx Transaction) error {
+	result, err := e.Exec(
+		fmt.Sprintf(
+			"UPDATE %s SET %s=?, %s=?, %s=?, %s=?, %s=?, %s=?, %s=?, %s=?, %s=? WHERE %s=? AND %s<>? AND %s=0",
+			TableName,
+			EntityCol,
+			AmountCol,
+			DateCol,
+			NoteCol,
+			CategoryCol,
+			TagsCol,
+			AccountCol,
+			CurrencyCol,
+			KindCol,
+			IDCol,
+			StatusCol,
+			DeletedCol,
+		),
+		tx.Entity,
+		tx.Amount,
+		tx.Date,
+		tx.Note,
+		tx.Category,
+		strings.Join(tx.Tags, TagSeparator),
+		tx.Account,
+		tx.CurrencyInfo().Code,
+		tx.Kind,
+		tx.ID,
+		Reconciled,
+	)
+	if err != nil {
+		return fmt.Errorf("transaction: could not update transaction #%d: %w", tx.ID, constraintError(err))
+	}
+	if err := checkChanged(e, result, tx.ID); err != nil {
+		return err
+	}
+	shares, err := readShares(e, tx.ID)
+	if err != nil {
+		return err
+	}
+	if err := checkShares(tx.Amount, shares); err != nil {
+		return fmt.Errorf("%w (#%d)", err, tx.ID)
 	}
 	return nil
 }
 
-// Total returns the total of all the transactions in the database
+// SetStatus changes the status of the transaction with the given ID. It's
+// the only way to change a reconciled transaction, so that locking it can be
+// undone on purpose. It returns ErrNotFound if there isn't one, or
+// ErrTransition if it can't go to "status" from its current status (see
+// Status.CanBecome). Setting a transaction to the status it already has does
+// nothing.
+func (t *Table) SetStatus(transactionID int, status Status) error {
+	if _, err := ParseStatus(string(status)); err != nil {
+		return err
+	}
+	_, err := t.audited(ActionStatus, transactionID, func(e Execer) (int, error) {
+		return transactionID, setStatus(e, transactionID, status)
+	})
+	return err
+}
+
+// SetStatusAll changes the status of the given transactions like SetStatus in
+// one database transaction, so either all of them change or none of them do.
+// It returns the error of the first transaction that can't change.
+func (t *Table) SetStatusAll(transactionIDs []int, status Status) error {
+	if _, err := ParseStatus(string(status)); err != nil {
+		return err
+	}
+	return t.inTx(func(e Execer) error {
+		for _, id := range transactionIDs {
+			id := id
+			_, err := audit(e, ActionStatus, id, t.observer, func() (int, error) {
+				return id, setStatus(e, id, status)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// setStatus changes the status of a transaction if it can go to "status" from
+// its current one.
+func setStatus(e Execer, transactionID int, status Status) error {
+	from := status.from()
+	args := []interface{}{status, transactionID}
+	for _, s := range from {
+		args = append(args, s)
+	}
+	result, err := e.Exec(
+		fmt.Sprintf(
+			"UPDATE %s SET %s=? WHERE %s=? AND %s=0 AND %s IN (%s)",
+			TableName,
+			StatusCol,
+			IDCol,
+			DeletedCol,
+			StatusCol,
+			strings.TrimSuffix(strings.Repeat("?, ", len(from)), ", "),
+		),
+		args...,
+	)
+	if err != nil {
+		return fmt.Errorf("transaction: could not set the status of transaction #%d: %w", transactionID, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil || n > 0 {
+		return err
+	}
+	tx, err := Snapshot(e, transactionID)
+	if err != nil {
+		return err
+	}
+	if tx == nil || tx.Deleted != 0 {
+		return fmt.Errorf("%w #%d", ErrNotFound, transactionID)
+	}
+	if tx.Status == status {
+		return nil
+	}
+	return fmt.Errorf("%w from %s to %s (#%d)", ErrTransition, tx.Status, status, transactionID)
+}
+
+// Total returns the total of all the transactions in the database, except
+// for void ones. Like Totals, it only counts the user's part of a shared
+// transaction.
 // ? will this become slow over time?
 func (t *Table) Total() (Cent, error) {
+	condition, args := excluding(nil)
 	row := t.DB.QueryRow(
 		fmt.Sprintf(
-			"SELECT COALESCE(SUM(%s), 0) FROM %s",
-			AmountCol,
+			"SELECT COALESCE(SUM(%s), 0) FROM %s WHERE %s",
+			ownAmount,
 			TableName,
+			condition,
 		),
+		args...,
 	)
-	var total int
+	var total int64
 	err := row.Scan(&total)
 	if err != nil {
 		return 0, fmt.Errorf("transaction: could not query database for total: %w", err)
@@ -158,15 +864,50 @@ func (t *Table) Total() (Cent, error) {
 	return Cent(total), nil
 }
 
-// Remove deletes the given transaction from the table.
+// Remove moves the given transaction to the trash, where it's left out of
+// every query and total until it's recovered or purged. It returns
+// ErrNotFound if there isn't one, or ErrLocked if it's reconciled.
 func (t *Table) Remove(transactionID int) error {
-	_, err := t.DB.Exec(
+	_, err := t.audited(ActionRemove, transactionID, func(e Execer) (int, error) {
+		return transactionID, remove(e, transactionID, time.Now())
+	})
+	return err
+}
+
+// RemoveAll moves the given transactions to the trash in one database
+// transaction, so either all of them are removed or none of them are. Like
+// Remove, it returns ErrNotFound or ErrLocked for the first transaction that
+// can't be removed.
+func (t *Table) RemoveAll(transactionIDs []int) error {
+	at := time.Now()
+	return t.inTx(func(e Execer) error {
+		for _, id := range transactionIDs {
+			id := id
+			_, err := audit(e, ActionRemove, id, t.observer, func() (int, error) {
+				return id, remove(e, id, at)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// remove moves an unlocked transaction to the trash.
+func remove(e Execer, transactionID int, at time.Time) error {
+	result, err := e.Exec(
 		fmt.Sprintf(
-			"DELETE FROM %s WHERE %s=?",
+			"UPDATE %s SET %s=? WHERE %s=? AND %s<>? AND %s=0",
 			TableName,
+			DeletedCol,
 			IDCol,
+			StatusCol,
+			DeletedCol,
 		),
+		at.Unix(),
 		transactionID,
+		Reconciled,
 	)
 	if err != nil {
 		return fmt.Errorf(
@@ -175,7 +916,7 @@ func (t *Table) Remove(transactionID int) error {
 			err,
 		)
 	}
-	return nil
+	return checkChanged(e, result, transactionID)
 }
 
 // Rows wraps *sql.Rows to easily scan Transactions from a DB
@@ -184,10 +925,15 @@ type Rows struct{ *sql.Rows }
 // Scan scans a transaction from the current result set.
 func (r *Rows) Scan() (Transaction, error) {
 	tx := Transaction{}
-	err := r.Rows.Scan(&tx.ID, &tx.Entity, &tx.Amount, &tx.Date, &tx.Note)
+	var tags string
+	err := r.Rows.Scan(
+		&tx.ID, &tx.Entity, &tx.Amount, &tx.Date, &tx.Note, &tx.Category, &tags, &tx.Account,
+		&tx.Currency, &tx.Status, &tx.Deleted, &tx.Kind,
+	)
 	if err != nil {
 		return Transaction{}, err
 	}
+	tx.Tags = ParseTags(tags)
 	return tx, err
 }
 
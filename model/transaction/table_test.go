@@ -2,9 +2,11 @@ package transaction_test
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/Anthony-Fiddes/budgeter/model/transaction"
 	_ "github.com/mattn/go-sqlite3"
@@ -74,15 +76,27 @@ func TestTable(t *testing.T) {
 
 	// Insert test
 	for _, tx := range testData {
-		err := table.Insert(tx)
+		id, err := table.Insert(tx)
 		if err != nil {
 			t.Log(err)
 			t.Fatalf("could not insert %+v into table", tx)
 		}
 
-		err = table.Insert(tx)
-		if err == nil {
-			t.Fatal("table is expected to error when inserting a transaction that already exists in the table")
+		// Get Test
+		result, err := table.Get(id)
+		if err != nil {
+			t.Fatalf("could not get transaction #%d: %v", id, err)
+		}
+		expected := tx
+		expected.ID = id
+		if !equal(result, expected) || result.ID != id {
+			t.Fatalf("Get returned %+v but expected %+v", result, expected)
+		}
+
+		_, err = table.Insert(tx)
+		if !errors.Is(err, transaction.ErrDuplicate) {
+			t.Log(err)
+			t.Fatal("table is expected to return ErrDuplicate when inserting a transaction that already exists in the table")
 		}
 	}
 
@@ -111,7 +125,7 @@ func TestTable(t *testing.T) {
 	// Range Test
 	{
 		expected := testData[2:]
-		rows, err := table.Range(time.Unix(5, 0), time.Unix(6, 0), -1)
+		rows, err := table.Range(time.Unix(5, 0).UTC(), time.Unix(6, 0).UTC(), -1)
 		if err != nil {
 			t.Fatalf("table.Range failed: %v", err)
 		}
@@ -137,7 +151,7 @@ func TestTable(t *testing.T) {
 		for _, tx := range testData[2:] {
 			expected += tx.Amount
 		}
-		result, err := table.RangeTotal(time.Unix(5, 0), time.Unix(6, 0))
+		result, err := table.RangeTotal(time.Unix(5, 0).UTC(), time.Unix(6, 0).UTC())
 		if err != nil || result != expected {
 			t.Logf("result total: %d", result)
 			t.Logf("expected total: %d", expected)
@@ -147,7 +161,7 @@ func TestTable(t *testing.T) {
 
 		// RangeTotal should return 0 if it selects no rows
 		expected = 0
-		result, err = table.RangeTotal(time.Unix(-1000, 0), time.Unix(-1000, 0))
+		result, err = table.RangeTotal(time.Unix(-1000, 0).UTC(), time.Unix(-1000, 0).UTC())
 		if err != nil || result != expected {
 			t.Logf("result total: %d", result)
 			t.Logf("expected total: %d", expected)
@@ -171,6 +185,83 @@ func TestTable(t *testing.T) {
 		}
 	}
 
+	// Totals Test
+	{
+		yen := transaction.Transaction{Entity: "Ramen", Amount: -1200, Date: 5, Currency: "JPY"}
+		if _, err := table.Insert(yen); err != nil {
+			t.Fatal(err)
+		}
+		var usd transaction.Cent
+		for _, tx := range testData {
+			usd += tx.Amount
+		}
+		totals, err := table.Totals()
+		if err != nil || len(totals) != 2 || totals["USD"] != usd || totals["JPY"] != yen.Amount {
+			t.Fatalf("unexpected totals %+v: %v", totals, err)
+		}
+		totals, err = table.RangeTotals(time.Unix(5, 0).UTC(), time.Unix(5, 0).UTC())
+		if err != nil || len(totals) != 2 || totals["USD"] != testData[2].Amount || totals["JPY"] != yen.Amount {
+			t.Fatalf("unexpected range totals %+v: %v", totals, err)
+		}
+
+		rows, err := table.Search(yen.Entity, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		transactions, err := rows.ScanSet()
+		if err != nil || len(transactions) != 1 || transactions[0].Currency != "JPY" {
+			t.Fatalf("expected to find %+v but received %+v: %v", yen, transactions, err)
+		}
+		if err := table.Remove(transactions[0].ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Update Test
+	{
+		rows, err := table.Search(testData[3].Entity, 1)
+		if err != nil {
+			t.Fatalf("table.Search failed: %v", err)
+		}
+		transactions, err := rows.ScanSet()
+		if err != nil || len(transactions) != 1 {
+			t.Fatalf("could not find %+v to update: %v", testData[3], err)
+		}
+		tx := transactions[0]
+		tx.Category = "Medical"
+		tx.Tags = []string{"health", "ride"}
+		tx.Account = "Checking"
+		if err := table.Update(tx); err != nil {
+			t.Fatal(err)
+		}
+		rows, err = table.Search(testData[3].Entity, 1)
+		if err != nil {
+			t.Fatalf("table.Search failed: %v", err)
+		}
+		transactions, err = rows.ScanSet()
+		if err != nil || len(transactions) != 1 {
+			t.Fatalf("could not find %+v after updating it: %v", tx, err)
+		}
+		result := transactions[0]
+		if result.Category != tx.Category || result.Account != tx.Account ||
+			len(result.Tags) != 2 || !result.HasTag("health") || !result.HasTag("ride") {
+			t.Fatalf("received %+v but expected %+v", result, tx)
+		}
+
+		// Updating a transaction so that it duplicates another should fail.
+		tx.Entity = testData[4].Entity
+		tx.Amount = testData[4].Amount
+		tx.Note = testData[4].Note
+		if err := table.Update(tx); !errors.Is(err, transaction.ErrDuplicate) {
+			t.Fatalf("expected ErrDuplicate but received %v", err)
+		}
+
+		tx.ID = -1
+		if err := table.Update(tx); !errors.Is(err, transaction.ErrNotFound) {
+			t.Fatalf("expected ErrNotFound but received %v", err)
+		}
+	}
+
 	// Remove Test
 	{
 		rows, err := table.Search("", -1)
@@ -197,6 +288,12 @@ func TestTable(t *testing.T) {
 		if err != nil {
 			t.Errorf("unexpected error scanning rows: %v", err)
 		}
+		if err := table.Remove(transactions[0].ID); !errors.Is(err, transaction.ErrNotFound) {
+			t.Errorf("expected ErrNotFound removing a transaction twice but received %v", err)
+		}
+		if _, err := table.Get(transactions[0].ID); !errors.Is(err, transaction.ErrNotFound) {
+			t.Errorf("expected ErrNotFound getting a removed transaction but received %v", err)
+		}
 		if len(remaining) != 0 {
 			t.Log("remaining transactions:")
 			t.Logf("%+v", remaining)
@@ -204,3 +301,395 @@ func TestTable(t *testing.T) {
 		}
 	}
 }
+
+// TestMigrate tests that Init adds the columns that are missing from tables
+// created by older versions of budgeter.
+func TestMigrate(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	_, err = db.Exec(
+		"CREATE TABLE transactions (ID INTEGER NOT NULL PRIMARY KEY, Entity TEXT NOT NULL, " +
+			"Amount INTEGER NOT NULL, Date INTEGER NOT NULL, Note TEXT NOT NULL, " +
+			"UNIQUE(Entity,Amount,Date,Note))",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec("INSERT INTO transactions(Entity, Amount, Date, Note) VALUES ('Kroger', -1212, 6, 'Groceries')")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table := &transaction.Table{DB: db}
+	for i := 0; i < 2; i++ {
+		if err := table.Init(); err != nil {
+			t.Fatalf("could not initialize an old table (attempt %d): %v", i+1, err)
+		}
+	}
+	rows, err := table.Search("", -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactions, err := rows.ScanSet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 1 || transactions[0].Entity != "Kroger" || transactions[0].Category != "" {
+		t.Fatalf("unexpected transactions after migrating: %+v", transactions)
+	}
+
+	// Old tables' transactions had to be unique even in the trash.
+	kroger := transactions[0]
+	if err := table.Remove(kroger.ID); err != nil {
+		t.Fatal(err)
+	}
+	kroger.ID = 0
+	again, err := table.Insert(kroger)
+	if err != nil {
+		t.Fatalf("expected a trashed transaction to be added again after migrating but got %v", err)
+	}
+	if _, err := table.Insert(kroger); !errors.Is(err, transaction.ErrDuplicate) {
+		t.Errorf("expected ErrDuplicate after migrating but got %v", err)
+	}
+
+	// Restoring the trashed transaction would make it a duplicate of the one
+	// that was added again.
+	trashed, err := table.Snapshot(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trashed.Deleted = 0
+	if err := transaction.Restore(db, 1, trashed); !errors.Is(err, transaction.ErrDuplicate) {
+		t.Errorf("expected ErrDuplicate when restoring over #%d but got %v", again, err)
+	}
+}
+
+// TestNormalizeDates tests that Init moves dates stored by older versions of
+// budgeter, which were the time that a transaction was added, to the start of
+// its day.
+func TestNormalizeDates(t *testing.T) {
+	chicago := time.FixedZone("CST", -6*60*60)
+	table, err := getMemTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.DB.Close()
+	table.DB.SetMaxOpenConns(1)
+
+	// 9pm on the 5th in Chicago is 3am on the 6th in UTC.
+	added := time.Date(2021, time.July, 5, 21, 0, 0, 0, chicago).Unix()
+	_, err = table.DB.Exec("INSERT INTO transactions(Entity, Amount, Date, Note) VALUES ('Kroger', -1212, ?, '')", added)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := table.NormalizeDates(chicago); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tx, err := table.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.DateString() != "7/5/2021" || tx.Date%(24*60*60) != 0 {
+		t.Errorf("expected the transaction to be on 7/5/2021 but got %s (%d)", tx.DateString(), tx.Date)
+	}
+}
+
+func TestUpdateAll(t *testing.T) {
+	table, err := getMemTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.DB.Close()
+	table.DB.SetMaxOpenConns(1)
+
+	var txs []transaction.Transaction
+	for _, tx := range []transaction.Transaction{
+		{Entity: "KROGER #123", Amount: -2000, Date: 86400},
+		{Entity: "kroger #123", Amount: -1368, Date: 86400},
+		{Entity: "Kroger #123", Amount: -500, Date: 86400},
+	} {
+		if tx.ID, err = table.Insert(tx); err != nil {
+			t.Fatal(err)
+		}
+		tx.Entity = "Kroger"
+		txs = append(txs, tx)
+	}
+	if err := table.SetStatus(txs[2].ID, transaction.Reconciled); err != nil {
+		t.Fatal(err)
+	}
+
+	// The reconciled transaction can't be updated, so none of them are.
+	called := false
+	also := func(e transaction.Execer) error {
+		called = true
+		return nil
+	}
+	if err := table.UpdateAll(txs, also); !errors.Is(err, transaction.ErrLocked) {
+		t.Fatalf("expected ErrLocked but got %v", err)
+	}
+	if tx, err := table.Get(txs[0].ID); err != nil || tx.Entity != "KROGER #123" || called {
+		t.Errorf("expected nothing to be updated but got %+v: %v", tx, err)
+	}
+
+	// Neither are the transactions if "also" fails.
+	errAlso := errors.New("also failed")
+	if err := table.UpdateAll(txs[:2], func(e transaction.Execer) error { return errAlso }); err != errAlso {
+		t.Fatalf("expected the error from also but got %v", err)
+	}
+	if tx, err := table.Get(txs[0].ID); err != nil || tx.Entity != "KROGER #123" {
+		t.Errorf("expected nothing to be updated but got %+v: %v", tx, err)
+	}
+
+	if err := table.UpdateAll(txs[:2], also); err != nil || !called {
+		t.Fatalf("expected the updates to succeed and call also but got %v", err)
+	}
+	for _, want := range txs[:2] {
+		if tx, err := table.Get(want.ID); err != nil || tx.Entity != "Kroger" {
+			t.Errorf("expected #%d to be updated but got %+v: %v", want.ID, tx, err)
+		}
+	}
+}
+
+func TestRangeTimezone(t *testing.T) {
+	table, err := getMemTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.DB.Close()
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 11pm on Halloween in New York is already November in UTC, but the
+	// transaction should still count towards October.
+	late := time.Date(2021, time.October, 31, 23, 0, 0, 0, newYork)
+	tx := transaction.Transaction{Entity: "Costume Shop", Amount: -2500, Date: transaction.DateOf(late)}
+	if tx.DateString() != "10/31/2021" {
+		t.Fatalf("transaction is dated %s instead of 10/31/2021", tx.DateString())
+	}
+	if _, err := table.Insert(tx); err != nil {
+		t.Fatal(err)
+	}
+
+	october := time.Date(2021, time.October, 1, 0, 0, 0, 0, newYork)
+	total, err := table.RangeTotal(october, october.AddDate(0, 1, 0).Add(-time.Nanosecond))
+	if err != nil || total != tx.Amount {
+		t.Errorf("October's total is %d instead of %d: %v", total, tx.Amount, err)
+	}
+	november := october.AddDate(0, 1, 0)
+	total, err = table.RangeTotal(november, november.AddDate(0, 1, 0).Add(-time.Nanosecond))
+	if err != nil || total != 0 {
+		t.Errorf("November's total is %d instead of 0: %v", total, err)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	table, err := getMemTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.DB.Close()
+
+	id, err := table.Insert(transaction.Transaction{Entity: "Kroger", Amount: -1212, Date: 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := table.Get(id)
+	if err != nil || tx.Status != transaction.Cleared {
+		t.Fatalf("expected a new transaction to be cleared but received %+v: %v", tx, err)
+	}
+
+	if err := table.SetStatus(id, transaction.Reconciled); err != nil {
+		t.Fatal(err)
+	}
+	tx.Note = "Groceries"
+	if err := table.Update(tx); !errors.Is(err, transaction.ErrLocked) {
+		t.Errorf("expected ErrLocked updating a reconciled transaction but received %v", err)
+	}
+	if err := table.Remove(id); !errors.Is(err, transaction.ErrLocked) {
+		t.Errorf("expected ErrLocked removing a reconciled transaction but received %v", err)
+	}
+	if result, err := table.Get(id); err != nil || result.Note != "" || result.Status != transaction.Reconciled {
+		t.Errorf("a reconciled transaction changed to %+v: %v", result, err)
+	}
+
+	if err := table.SetStatus(id, "bounced"); err == nil {
+		t.Error("expected an error setting an unknown status")
+	}
+	if err := table.SetStatus(-1, transaction.Cleared); !errors.Is(err, transaction.ErrNotFound) {
+		t.Errorf("expected ErrNotFound but received %v", err)
+	}
+	if err := table.SetStatus(id, transaction.Cleared); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Update(tx); err != nil {
+		t.Errorf("could not update a transaction after unlocking it: %v", err)
+	}
+	if err := table.Remove(id); err != nil {
+		t.Errorf("could not remove a transaction after unlocking it: %v", err)
+	}
+}
+
+func TestStatusTransitions(t *testing.T) {
+	table, err := getMemTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.DB.Close()
+
+	pending := transaction.Transaction{Entity: "Kroger", Amount: -1212, Date: 6, Status: transaction.Pending}
+	id, err := table.Insert(pending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := []struct {
+		status transaction.Status
+		ok     bool
+	}{
+		{transaction.Reconciled, false},
+		{transaction.Cleared, true},
+		{transaction.Cleared, true},
+		{transaction.Pending, false},
+		{transaction.Reconciled, true},
+		{transaction.Void, false},
+		{transaction.Cleared, true},
+		{transaction.Void, true},
+		{transaction.Cleared, false},
+		{transaction.Pending, false},
+	}
+	for _, step := range steps {
+		before, err := table.Get(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = table.SetStatus(id, step.status)
+		if step.ok && err != nil {
+			t.Errorf("could not change %s to %s: %v", before.Status, step.status, err)
+		} else if !step.ok && !errors.Is(err, transaction.ErrTransition) {
+			t.Errorf("expected ErrTransition changing %s to %s but received %v", before.Status, step.status, err)
+		}
+	}
+}
+
+func TestSetStatusAll(t *testing.T) {
+	table, err := getMemTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.DB.Close()
+
+	var ids []int
+	for i, status := range []transaction.Status{transaction.Cleared, transaction.Cleared, transaction.Pending} {
+		id, err := table.Insert(transaction.Transaction{Entity: "Kroger", Amount: -1212, Date: int64(i), Status: status})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	// A pending transaction can't be reconciled, so none of them are.
+	if err := table.SetStatusAll(ids, transaction.Reconciled); !errors.Is(err, transaction.ErrTransition) {
+		t.Errorf("expected ErrTransition but received %v", err)
+	}
+	for _, id := range ids[:2] {
+		if tx, err := table.Get(id); err != nil || tx.Status != transaction.Cleared {
+			t.Errorf("expected transaction #%d to still be cleared but received %+v: %v", id, tx, err)
+		}
+	}
+
+	if err := table.SetStatusAll(ids[:2], transaction.Reconciled); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range ids[:2] {
+		if tx, err := table.Get(id); err != nil || tx.Status != transaction.Reconciled {
+			t.Errorf("expected transaction #%d to be reconciled but received %+v: %v", id, tx, err)
+		}
+	}
+	if err := table.SetStatusAll(ids, "bounced"); err == nil {
+		t.Error("expected an error setting an unknown status")
+	}
+}
+
+func TestClear(t *testing.T) {
+	table, err := getMemTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.DB.Close()
+
+	id, err := table.Insert(transaction.Transaction{Entity: "Hotel", Amount: -10000, Date: 6, Status: transaction.Pending})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := table.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Amount = -12000
+	if err := table.Clear(tx); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := table.Get(id); err != nil || got.Amount != -12000 || got.Status != transaction.Cleared {
+		t.Errorf("expected the transaction to be updated and cleared but received %+v: %v", got, err)
+	}
+
+	// A void transaction can't be cleared, so it isn't updated either.
+	if err := table.SetStatus(id, transaction.Void); err != nil {
+		t.Fatal(err)
+	}
+	tx.Amount = -15000
+	if err := table.Clear(tx); !errors.Is(err, transaction.ErrTransition) {
+		t.Errorf("expected ErrTransition but received %v", err)
+	}
+	if got, err := table.Get(id); err != nil || got.Amount != -12000 || got.Status != transaction.Void {
+		t.Errorf("expected the void transaction to be left alone but received %+v: %v", got, err)
+	}
+}
+
+func TestStatusTotals(t *testing.T) {
+	table, err := getMemTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.DB.Close()
+
+	for _, tx := range []transaction.Transaction{
+		{Entity: "Paycheck", Amount: 100000, Date: 5},
+		{Entity: "Kroger", Amount: -1212, Date: 5, Status: transaction.Pending},
+		{Entity: "Lyft", Amount: -1368, Date: 6, Status: transaction.Reconciled},
+		{Entity: "Refunded", Amount: -5000, Date: 6, Status: transaction.Void},
+	} {
+		if _, err := table.Insert(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	start, end := time.Unix(0, 0).UTC(), time.Unix(10, 0).UTC()
+	if total, err := table.RangeTotal(start, end); err != nil || total != 100000-1212-1368 {
+		t.Errorf("expected void transactions to be left out of the total but it was %d: %v", total, err)
+	}
+	if total, err := table.RangeTotal(start, end, transaction.Pending); err != nil || total != 100000-1368 {
+		t.Errorf("expected pending transactions to be left out of the total but it was %d: %v", total, err)
+	}
+	totals, err := table.RangeTotals(start, end, transaction.Pending, transaction.Reconciled)
+	if err != nil || totals["USD"] != 100000 {
+		t.Errorf("expected only cleared transactions in the totals but they were %v: %v", totals, err)
+	}
+	if total, err := table.Total(); err != nil || total != 100000-1212-1368 {
+		t.Errorf("expected void transactions to be left out of Total but it was %d: %v", total, err)
+	}
+	if totals, err := table.Totals(); err != nil || totals["USD"] != 100000-1212-1368 {
+		t.Errorf("expected void transactions to be left out of Totals but they were %v: %v", totals, err)
+	}
+	entities, err := table.RangeEntityExpenses(start, end)
+	if err != nil || len(entities) != 2 || entities["Kroger"]["USD"] != -1212 || entities["Refunded"] != nil {
+		t.Errorf("expected income and void transactions to be left out of the entity totals but they were %v: %v", entities, err)
+	}
+}
@@ -4,41 +4,75 @@ package transaction
 
 import (
 	"fmt"
-	"strconv"
 	"strings"
 	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/internal/money"
+	"github.com/Anthony-Fiddes/budgeter/model/currency"
 )
 
 const (
-	TableName  = "transactions"
-	IDCol      = "ID"
-	EntityCol  = "Entity"
-	AmountCol  = "Amount"
-	DateCol    = "Date"
-	NoteCol    = "Note"
-	DateLayout = "1/2/2006"
-	// TODO: this should probably be configurable, but I currently only use US dollars
-	Currency  = "$"
-	Point     = "."
-	Thousands = ","
+	TableName   = "transactions"
+	IDCol       = "ID"
+	EntityCol   = "Entity"
+	AmountCol   = "Amount"
+	DateCol     = "Date"
+	NoteCol     = "Note"
+	CategoryCol = "Category"
+	TagsCol     = "Tags"
+	AccountCol  = "Account"
+	CurrencyCol = "Currency"
+	StatusCol   = "Status"
+	DeletedCol  = "Deleted"
+	KindCol     = "Kind"
+	// TagSeparator separates the tags of a transaction when they're written
+	// as a single string.
+	TagSeparator = ","
+	DateLayout   = "1/2/2006"
 )
 
-// Cent represents 1/100th of a Dollar
-type Cent int
-
-// String returns a string that represents the value of the given number of
-// "cents".
-func (c Cent) String() string {
-	sign := ""
-	negative := c < 0
-	if negative {
-		c *= -1
-		sign = "-"
-	}
-	dollars := c / 100
-	pennies := c % 100
-	// TODO: determine whether or not I want this to add in thousands separators
-	return fmt.Sprintf("%s%s%d.%02d", sign, Currency, dollars, pennies)
+// home is the currency that amounts are in when no other currency is given.
+var home = currency.USD
+
+// Home returns the currency that amounts are in when no other currency is
+// given. It's USD by default.
+func Home() currency.Currency {
+	return home
+}
+
+// SetHome sets the currency that amounts are in when no other currency is
+// given.
+func SetHome(c currency.Currency) {
+	home = c
+}
+
+// locale determines how amounts are formatted and parsed.
+var locale = money.Default
+
+// Locale returns the locale that amounts are formatted and parsed with. It's
+// en-US by default.
+func Locale() money.Locale {
+	return locale
+}
+
+// SetLocale sets the locale that amounts are formatted and parsed with.
+func SetLocale(l money.Locale) {
+	locale = l
+}
+
+// signs is the sign convention of amounts in files.
+var signs = IncomePositive
+
+// FileSigns returns the sign convention of the amounts in the files that
+// transactions are read from and written to. It's IncomePositive by default.
+func FileSigns() Signs {
+	return signs
+}
+
+// SetFileSigns sets the sign convention of the amounts in the files that
+// transactions are read from and written to.
+func SetFileSigns(s Signs) {
+	signs = s
 }
 
 // TODO: add a String() function
@@ -47,12 +81,93 @@ type Transaction struct {
 	ID int
 	// Entity is the person or company the transaction was made with.
 	Entity string
-	// Amount is the cost of the transaction in cents
+	// Amount is the cost of the transaction in the minor units of its
+	// Currency, e.g. cents. It's positive for money coming in, like a
+	// paycheck or a refund, and negative for money going out, like a
+	// purchase. See Signs for reading amounts that are signed the other way.
 	Amount Cent
-	// Date is the Unix Time the transaction occurred in seconds.
+	// Currency is the ISO 4217 code of the currency that the transaction was
+	// made in. If it's empty, the transaction is in the home currency.
+	Currency string
+	// Date is the day the transaction occurred. It's stored as the Unix time
+	// in seconds of midnight UTC at the start of that calendar day, so that
+	// it's the same day in every time zone. See DateOf.
 	Date int64
 	// Note is any note the user wants to add about the transaction.
 	Note string
+	// Category is the budgeting category that the transaction belongs to, e.g.
+	// "Groceries".
+	Category string
+	// Tags are any labels the user wants to add to the transaction.
+	Tags []string
+	// Account is the name of the account that the transaction was made with.
+	Account string
+	// Status is where the transaction is in its life. If it's empty, the
+	// transaction is Cleared.
+	Status Status
+	// Deleted is when the transaction was moved to the trash, in Unix
+	// seconds, or 0 if it isn't in the trash.
+	Deleted int64
+	// Kind is whether the transaction is income, an expense or a transfer. If
+	// it's empty, the transaction is classified by its amount. See Classify.
+	Kind Kind `json:",omitempty"`
+	// Shares are the parts of the transaction that belong to other people.
+	// They're only filled in by Get and Snapshot, and only changed by Insert
+	// and SetShares.
+	Shares []Share `json:",omitempty"`
+}
+
+// CurrencyInfo returns the currency that the transaction was made in.
+// Transactions with an unknown Currency are treated as if they're in the home
+// currency.
+func (t Transaction) CurrencyInfo() currency.Currency {
+	if t.Currency == "" {
+		return home
+	}
+	c, err := currency.Lookup(t.Currency)
+	if err != nil {
+		return home
+	}
+	return c
+}
+
+// AmountString returns the transaction's amount formatted in its currency.
+func (t Transaction) AmountString() string {
+	return t.Amount.Format(t.CurrencyInfo())
+}
+
+// HasTag returns whether or not the transaction has the given tag. Tags are
+// not case sensitive.
+func (t Transaction) HasTag(tag string) bool {
+	for _, tt := range t.Tags {
+		if strings.EqualFold(tt, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddTags adds the given tags to the transaction, skipping any that it already
+// has.
+func (t *Transaction) AddTags(tags ...string) {
+	for _, tag := range tags {
+		if !t.HasTag(tag) {
+			t.Tags = append(t.Tags, tag)
+		}
+	}
+}
+
+// ParseTags splits a string of tags separated by TagSeparator, trimming the
+// whitespace around each one and dropping any that are empty.
+func ParseTags(tags string) []string {
+	var result []string
+	for _, tag := range strings.Split(tags, TagSeparator) {
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			result = append(result, tag)
+		}
+	}
+	return result
 }
 
 // DateString returns the Transaction's date in M/D/YYYY format.
@@ -62,6 +177,24 @@ func (t Transaction) DateString() string {
 	return date
 }
 
+// DateOf returns the calendar day that "t" falls on in its own location, in
+// the form that transaction dates are stored in. E.g. 11pm on the 5th in New
+// York is stored as the 5th, even though it's the 6th in UTC.
+func DateOf(t time.Time) int64 {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix()
+}
+
+// wallClock returns the Unix time in seconds of "t"'s wall clock time in its
+// own location, as if it were in UTC. This puts times in the same frame as
+// transaction dates.
+func wallClock(t time.Time) int64 {
+	return time.Date(
+		t.Year(), t.Month(), t.Day(),
+		t.Hour(), t.Minute(), t.Second(), t.Nanosecond(),
+		time.UTC,
+	).Unix()
+}
+
 // Unix converts a string of format M/D/YYYY and converts it to the appropriate
 // Unix time in seconds. This function is useful for working with the "Transaction" type.
 func Unix(date string) (int64, error) {
@@ -72,50 +205,21 @@ func Unix(date string) (int64, error) {
 	return result.Unix(), nil
 }
 
-// GetCents takes a currency string formatted as [$]X.XX and returns the number of
-// cents that it represents
-func GetCents(currency string) (Cent, error) {
-	currencyErr := func() error {
-		errCurrencyFmt := fmt.Sprintf(
-			"transaction: currency \"%%s\" must be provided in [%s]X%sXX format (\"%s\" is allowed)",
-			Currency, Point, Thousands,
-		)
-		return fmt.Errorf(errCurrencyFmt, currency)
-	}
-	currency = strings.Replace(currency, Currency, "", 1)
-	currency = strings.Replace(currency, Thousands, "", 1)
-	currency = strings.TrimSpace(currency)
-	negative := false
-	if strings.ContainsRune(currency, '-') {
-		negative = true
-		currency = strings.Replace(currency, "-", "", 1)
-	}
-	currStr := strings.Split(currency, ".")
-	if currStr[0] == "" {
-		currStr[0] = "0"
-	}
-	dollars, err := strconv.Atoi(currStr[0])
+// GetCents takes a currency string formatted like [$]X,XXX.XX in the current
+// locale and returns the number of cents that it represents. It reads amounts
+// in the home currency.
+func GetCents(amount string) (Cent, error) {
+	return ParseAmount(amount, home)
+}
+
+// ParseAmount takes an amount of "cur" formatted like [$]X,XXX.XX in the
+// current locale and returns the number of minor units that it represents. See
+// money.Locale.Parse for every format that's accepted and how amounts with too
+// many digits after the point are rounded.
+func ParseAmount(amount string, cur currency.Currency) (Cent, error) {
+	result, err := locale.Parse(amount, cur)
 	if err != nil {
-		return 0, currencyErr()
-	}
-	if negative {
-		dollars *= -1
-	}
-	pennies := 0
-	if len(currStr) == 2 {
-		penStr := currStr[1]
-		pennies, err = strconv.Atoi(penStr)
-		if len(penStr) == 1 {
-			pennies *= 10
-		}
-		if err != nil {
-			return 0, currencyErr()
-		}
-		if negative {
-			pennies *= -1
-		}
-	} else if len(currStr) > 2 {
-		return 0, currencyErr()
+		return 0, err
 	}
-	return Cent(dollars*100 + pennies), nil
+	return Cent(result), nil
 }
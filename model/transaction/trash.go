@@ -0,0 +1,96 @@
+package transaction
+
+import (
+	"fmt"
+	"time"
+)
+
+// Trash returns up to "limit" of the transactions in the trash, the most
+// recently removed first. A negative "limit" will return all of them.
+func (t *Table) Trash(limit int) (*Rows, error) {
+	rows, err := t.DB.Query(
+		fmt.Sprintf(
+			"SELECT %s FROM %s WHERE %s<>0 ORDER BY %s DESC, %s DESC LIMIT ?",
+			columns,
+			TableName,
+			DeletedCol,
+			DeletedCol,
+			IDCol,
+		),
+		limit,
+	)
+	if err != nil {
+		return nil, queryError(err)
+	}
+	return &Rows{rows}, nil
+}
+
+// Recover takes the transaction with the given ID out of the trash. It
+// returns ErrNotTrashed if it isn't in the trash, or ErrDuplicate if the same
+// transaction has been added again since it was removed.
+func (t *Table) Recover(transactionID int) error {
+	_, err := t.audited(ActionRecover, transactionID, func(e Execer) (int, error) {
+		result, err := e.Exec(
+			fmt.Sprintf("UPDATE %s SET %s=0 WHERE %s=? AND %s<>0", TableName, DeletedCol, IDCol, DeletedCol),
+			transactionID,
+		)
+		if err != nil {
+			return 0, fmt.Errorf(
+				"transaction: could not recover transaction #%d: %w", transactionID, constraintError(err),
+			)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		if n == 0 {
+			return 0, fmt.Errorf("%w (#%d)", ErrNotTrashed, transactionID)
+		}
+		return transactionID, nil
+	})
+	return err
+}
+
+// Purge deletes the transactions that were moved to the trash before "before"
+// for good, and returns how many there were. They're all deleted or none of
+// them are.
+func (t *Table) Purge(before time.Time) (int, error) {
+	count := 0
+	err := t.inTx(func(e Execer) error {
+		rows, err := e.Query(
+			fmt.Sprintf("SELECT %s FROM %s WHERE %s<>0 AND %s<?", IDCol, TableName, DeletedCol, DeletedCol),
+			before.Unix(),
+		)
+		if err != nil {
+			return queryError(err)
+		}
+		var ids []int
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return queryError(err)
+			}
+			ids = append(ids, id)
+		}
+		if err := rows.Close(); err != nil {
+			return queryError(err)
+		}
+		for _, id := range ids {
+			id := id
+			_, err := audit(e, ActionPurge, id, nil, func() (int, error) {
+				_, err := e.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s=?", TableName, IDCol), id)
+				if err != nil {
+					return 0, fmt.Errorf("transaction: could not purge transaction #%d: %w", id, err)
+				}
+				return id, writeShares(e, id, nil)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		count = len(ids)
+		return nil
+	})
+	return count, err
+}
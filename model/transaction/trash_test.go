@@ -0,0 +1,156 @@
+package transaction_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Anthony-Fiddes/budgeter/model/transaction"
+)
+
+func TestTrash(t *testing.T) {
+	table, err := getMemTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.DB.Close()
+	table.DB.SetMaxOpenConns(1)
+
+	var ids []int
+	for _, tx := range []transaction.Transaction{
+		{Entity: "Kroger", Amount: -2000, Date: 86400},
+		{Entity: "Lyft", Amount: -1368, Date: 86400},
+	} {
+		id, err := table.Insert(tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	kroger, lyft := ids[0], ids[1]
+	if err := table.Remove(kroger); err != nil {
+		t.Fatal(err)
+	}
+
+	// Trashed transactions are left out of everything.
+	if _, err := table.Get(kroger); !errors.Is(err, transaction.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a trashed transaction but got %v", err)
+	}
+	if err := table.Remove(kroger); !errors.Is(err, transaction.ErrNotFound) {
+		t.Errorf("expected ErrNotFound when removing a trashed transaction but got %v", err)
+	}
+	if err := table.SetStatus(kroger, transaction.Void); !errors.Is(err, transaction.ErrNotFound) {
+		t.Errorf("expected ErrNotFound when voiding a trashed transaction but got %v", err)
+	}
+	if total, err := table.Total(); err != nil || total != -1368 {
+		t.Errorf("expected the total to leave out the trash but got %s: %v", total, err)
+	}
+	rows, err := table.Search("", -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := rows.ScanSet()
+	if err != nil || len(found) != 1 || found[0].ID != lyft {
+		t.Errorf("expected only #%d to be found but got %+v: %v", lyft, found, err)
+	}
+	// They don't count as duplicates, so a transaction that was removed by
+	// mistake can be added again. Then the one in the trash can't be
+	// recovered, since it would be a duplicate.
+	again, err := table.Insert(transaction.Transaction{Entity: "Kroger", Amount: -2000, Date: 86400})
+	if err != nil {
+		t.Fatalf("expected a trashed transaction to be added again but got %v", err)
+	}
+	if err := table.Recover(kroger); !errors.Is(err, transaction.ErrDuplicate) {
+		t.Errorf("expected ErrDuplicate when recovering over a live transaction but got %v", err)
+	}
+	if err := table.Remove(again); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err = table.Trash(-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trashed, err := rows.ScanSet()
+	if err != nil || len(trashed) != 2 || trashed[1].ID != kroger || trashed[1].Deleted == 0 {
+		t.Fatalf("expected #%d to be in the trash but got %+v: %v", kroger, trashed, err)
+	}
+
+	if err := table.Recover(lyft); !errors.Is(err, transaction.ErrNotTrashed) {
+		t.Errorf("expected ErrNotTrashed but got %v", err)
+	}
+	if err := table.Recover(kroger); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := table.Get(kroger); err != nil {
+		t.Errorf("expected the recovered transaction to be back but got %v", err)
+	}
+
+	if err := table.Remove(kroger); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Remove(lyft); err != nil {
+		t.Fatal(err)
+	}
+	// Nothing was removed before an hour ago.
+	n, err := table.Purge(time.Now().Add(-time.Hour))
+	if err != nil || n != 0 {
+		t.Errorf("expected nothing to be purged but got %d: %v", n, err)
+	}
+	n, err = table.Purge(time.Now().Add(time.Second))
+	if err != nil || n != 3 {
+		t.Errorf("expected 3 transactions to be purged but got %d: %v", n, err)
+	}
+	if tx, err := table.Snapshot(kroger); err != nil || tx != nil {
+		t.Errorf("expected #%d to be gone but got %+v: %v", kroger, tx, err)
+	}
+	entries, err := table.Audit(1)
+	if err != nil || len(entries) != 1 || entries[0].Action != transaction.ActionPurge {
+		t.Errorf("expected the purge to be in the audit log but got %+v: %v", entries, err)
+	}
+}
+
+func TestRemoveAll(t *testing.T) {
+	table, err := getMemTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer table.DB.Close()
+	table.DB.SetMaxOpenConns(1)
+
+	var ids []int
+	for _, tx := range []transaction.Transaction{
+		{Entity: "Kroger", Amount: -2000, Date: 86400},
+		{Entity: "Lyft", Amount: -1368, Date: 86400},
+		{Entity: "Rent", Amount: -100000, Date: 86400},
+	} {
+		id, err := table.Insert(tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if err := table.SetStatus(ids[2], transaction.Reconciled); err != nil {
+		t.Fatal(err)
+	}
+
+	// The reconciled transaction can't be removed, so none of them are.
+	if err := table.RemoveAll(ids); !errors.Is(err, transaction.ErrLocked) {
+		t.Fatalf("expected ErrLocked but got %v", err)
+	}
+	if total, err := table.Total(); err != nil || total != -103368 {
+		t.Errorf("expected nothing to be removed but the total is %s: %v", total, err)
+	}
+
+	if err := table.RemoveAll(ids[:2]); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := table.Trash(-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trashed, err := rows.ScanSet()
+	if err != nil || len(trashed) != 2 {
+		t.Errorf("expected 2 transactions in the trash but got %+v: %v", trashed, err)
+	}
+}